@@ -12,6 +12,78 @@ type PluginSettings struct {
 	Path      string                `json:"path"`
 	CacheTime time.Duration         `json:"cacheTime"`
 	Secrets   *SecretPluginSettings `json:"-"`
+
+	// Scheme is "https" (default) or "http" for the PRTG server. Without it a scheme typed
+	// in Path is used.
+	Scheme string `json:"scheme,omitempty"`
+
+	// FailoverPaths are the paths of further PRTG cluster nodes, like Path. They are tried in
+	// order if the current node fails with a connection error or a 5xx status.
+	FailoverPaths []string `json:"failoverPaths,omitempty"`
+
+	// ProxyURL is the proxy requests to PRTG are sent through, e.g. "http://proxy:3128". It
+	// overrides the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string `json:"proxyUrl,omitempty"`
+
+	// TrustedRedirectHosts lists additional hosts the API token may be forwarded to on redirects.
+	TrustedRedirectHosts []string `json:"trustedRedirectHosts,omitempty"`
+
+	// UnknownStatusPolicy controls how unknown status is treated: "ignore" (default), "down" or "up".
+	UnknownStatusPolicy string `json:"unknownStatusPolicy,omitempty"`
+
+	// RetryAttempts is the number of attempts per API request, AttemptTimeout (in seconds)
+	// bounds every single attempt. The overall request timeout stays unchanged.
+	RetryAttempts  int `json:"retryAttempts,omitempty"`
+	AttemptTimeout int `json:"attemptTimeout,omitempty"`
+
+	// RetryDelay is the base delay between attempts in milliseconds, it doubles with every
+	// attempt. 0 uses the default of 200ms.
+	RetryDelay int `json:"retryDelay,omitempty"`
+
+	// HistoricMaxPoints is the count of points requested per historicdata request, 50000 by
+	// default. Chunks hitting it are paged by splitting their date range.
+	HistoricMaxPoints int `json:"historicMaxPoints,omitempty"`
+
+	// TreeCacheTime is the TTL of the group/device/sensor list cache in seconds.
+	TreeCacheTime int `json:"treeCacheTime,omitempty"`
+
+	// Timezone is the IANA name of the PRTG server timezone, e.g. "Europe/Berlin".
+	// If empty it is derived from the PRTG clock, falling back to the local timezone of the Grafana server.
+	Timezone string `json:"timezone,omitempty"`
+
+	// DefaultColumns overrides the table columns per content type ("groups", "devices", "sensors").
+	DefaultColumns map[string][]string `json:"defaultColumns,omitempty"`
+
+	// TruncationWarnInterval is the minimum time in seconds between two warnings about
+	// truncated object lists per content type.
+	TruncationWarnInterval int `json:"truncationWarnInterval,omitempty"`
+
+	// MinAvgInterval is the smallest averaging interval in seconds requested from PRTG.
+	// 0 (default) allows raw data.
+	MinAvgInterval int64 `json:"minAvgInterval,omitempty"`
+
+	// UnitTagPrefix is the tag prefix sensors use to declare their unit, e.g. "unit:" for
+	// the tag "unit:mbps". Empty (default) disables units from tags.
+	UnitTagPrefix string `json:"unitTagPrefix,omitempty"`
+
+	// NonNumericValuePolicy controls values like "<1" or ">99": "drop" (default), "bound" or "estimate".
+	NonNumericValuePolicy string `json:"nonNumericValuePolicy,omitempty"`
+
+	// NeverPolledPolicy controls objects without datetime in property queries: "drop" (default)
+	// or "include" with an epoch-zero timestamp.
+	NeverPolledPolicy string `json:"neverPolledPolicy,omitempty"`
+
+	// DryRun logs the request URLs instead of sending them and returns empty data.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// RequestTimeout is the timeout of every API request in seconds, 10 by default.
+	RequestTimeout int `json:"requestTimeout,omitempty"`
+
+	// StatusTimeout, TableTimeout and HistoricTimeout override the request timeout (in seconds)
+	// of status.json, table.json and historicdata.json. 0 uses RequestTimeout.
+	StatusTimeout   int `json:"statusTimeout,omitempty"`
+	TableTimeout    int `json:"tableTimeout,omitempty"`
+	HistoricTimeout int `json:"historicTimeout,omitempty"`
 }
 
 type SecretPluginSettings struct {
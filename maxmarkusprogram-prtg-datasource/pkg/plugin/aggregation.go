@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bucket aggregation
+//
+// PRTG's historicdata API only delivers averages, it has no parameter for minimum, maximum or
+// sums. Like percentiles (see percentile.go) these aggregations are computed in the plugin: raw
+// (avg=0) historicdata is fetched and the points of every time bucket are combined. The same
+// accuracy limits apply. Without aggregation the averages of PRTG are used as delivered.
+
+// bucketAggregation is the aggregation of a metrics query computed from raw data.
+type bucketAggregation struct {
+	name       string  // lower case name, part of the display name
+	percentile float64 // the percentile for aggregations like "p95", 0 otherwise
+}
+
+// parseBucketAggregation validates the aggregation of a metrics query. ok is false for an
+// empty aggregation, which keeps PRTG's own averages.
+func parseBucketAggregation(aggregation string) (bucketAggregation, bool, error) {
+	name := strings.ToLower(strings.TrimSpace(aggregation))
+	switch name {
+	case "":
+		return bucketAggregation{}, false, nil
+	case tagAggregationAvg, tagAggregationMin, tagAggregationMax, tagAggregationSum:
+		return bucketAggregation{name: name}, true, nil
+	}
+	if p, ok := parsePercentileAggregation(name); ok {
+		return bucketAggregation{name: name, percentile: p}, true, nil
+	}
+	return bucketAggregation{}, false, fmt.Errorf("invalid query: unknown aggregation %q, expected avg, min, max, sum or a percentile like p95", aggregation)
+}
+
+// apply combines the values of a bucket.
+func (a bucketAggregation) apply(values []float64) float64 {
+	if a.percentile > 0 {
+		return percentile(values, a.percentile)
+	}
+	return aggregateValues(values, a.name)
+}
+
+// bucketAggregate groups the points into buckets of the given width and returns the
+// aggregation of every non-empty bucket, timestamped with the bucket start. Buckets are
+// aligned in loc, see bucketStart.
+func bucketAggregate(times []time.Time, values []float64, bucket time.Duration, aggregation bucketAggregation, loc *time.Location) ([]time.Time, []float64) {
+	buckets := make(map[int64][]float64)
+	for i, t := range times {
+		key := bucketStart(t, bucket, loc).UnixMilli()
+		buckets[key] = append(buckets[key], values[i])
+	}
+
+	keys := make([]int64, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	outTimes := make([]time.Time, 0, len(keys))
+	outValues := make([]float64, 0, len(keys))
+	for _, key := range keys {
+		outTimes = append(outTimes, time.UnixMilli(key).UTC())
+		outValues = append(outValues, aggregation.apply(buckets[key]))
+	}
+	return outTimes, outValues
+}
+
+// bucketStart returns the start of the bucket holding t. Buckets of whole days start at
+// midnight in loc, so daily buckets follow the calendar of the PRTG server, including days
+// of 23 or 25 hours around DST changes. Shorter buckets are aligned to the UTC offset of loc
+// at t, which matters for zones with offsets like +05:30.
+func bucketStart(t time.Time, bucket time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+
+	const day = 24 * time.Hour
+	if bucket >= day && bucket%day == 0 {
+		// Days since the epoch in the calendar of loc, floored to a multiple of the bucket
+		year, month, dayOfMonth := local.Date()
+		days := time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second)
+		width := int64(bucket / day)
+		days -= ((days % width) + width) % width
+		year, month, dayOfMonth = time.Unix(days*int64(day/time.Second), 0).UTC().Date()
+		return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, loc)
+	}
+
+	_, offset := local.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(bucket).Add(-shift)
+}
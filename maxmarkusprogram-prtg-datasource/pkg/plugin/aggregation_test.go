@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ parseBucketAggregation test: Allowed aggregations
+func TestParseBucketAggregation(t *testing.T) {
+	tests := []struct {
+		input   string
+		name    string
+		ok      bool
+		wantErr bool
+	}{
+		{"", "", false, false},
+		{" ", "", false, false},
+		{"avg", "avg", true, false},
+		{"MAX", "max", true, false},
+		{"min", "min", true, false},
+		{" sum ", "sum", true, false},
+		{"p95", "p95", true, false},
+		{"median", "", false, true},
+		{"p100", "", false, true},
+		{"sumsd", "", false, true},
+	}
+
+	for _, tt := range tests {
+		aggregation, ok, err := parseBucketAggregation(tt.input)
+		if (err != nil) != tt.wantErr || ok != tt.ok || aggregation.name != tt.name {
+			t.Errorf("parseBucketAggregation(%q) = %+v, %v, %v; expected %q, %v, error %v", tt.input, aggregation, ok, err, tt.name, tt.ok, tt.wantErr)
+		}
+	}
+}
+
+// ✅ bucketAggregate test: Minimum, maximum and sum per bucket
+func TestBucketAggregate(t *testing.T) {
+	start := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	times := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(10 * time.Minute)}
+	values := []float64{3, 1, 5, 7}
+
+	tests := []struct {
+		name     string
+		expected []float64
+	}{
+		{"avg", []float64{3, 7}},
+		{"min", []float64{1, 7}},
+		{"max", []float64{5, 7}},
+		{"sum", []float64{9, 7}},
+	}
+	for _, tt := range tests {
+		aggregation, _, _ := parseBucketAggregation(tt.name)
+		outTimes, outValues := bucketAggregate(times, values, 10*time.Minute, aggregation, time.UTC)
+		if len(outTimes) != 2 || !outTimes[1].Equal(start.Add(10*time.Minute)) {
+			t.Fatalf("%s: unexpected buckets %v", tt.name, outTimes)
+		}
+		if outValues[0] != tt.expected[0] || outValues[1] != tt.expected[1] {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, outValues)
+		}
+	}
+}
+
+// ✅ bucketStart test: Day buckets follow the calendar of the timezone, shorter ones its offset
+func TestBucketStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		bucket   time.Duration
+		loc      *time.Location
+		expected time.Time
+	}{
+		{"UTC day", time.Date(2025, 2, 15, 23, 30, 0, 0, time.UTC), 24 * time.Hour, time.UTC, time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{"Berlin day", time.Date(2025, 2, 15, 23, 30, 0, 0, time.UTC), 24 * time.Hour, berlin, time.Date(2025, 2, 16, 0, 0, 0, 0, berlin)},
+		{"Berlin DST day", time.Date(2025, 3, 30, 20, 0, 0, 0, time.UTC), 24 * time.Hour, berlin, time.Date(2025, 3, 30, 0, 0, 0, 0, berlin)},
+		{"Berlin week", time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC), 7 * 24 * time.Hour, berlin, time.Date(2025, 2, 13, 0, 0, 0, 0, berlin)},
+		{"Half hour offset", time.Date(2025, 2, 15, 12, 10, 0, 0, time.UTC), time.Hour, kolkata, time.Date(2025, 2, 15, 17, 0, 0, 0, kolkata)},
+		{"No zone", time.Date(2025, 2, 15, 12, 10, 0, 0, time.UTC), 10 * time.Minute, nil, time.Date(2025, 2, 15, 12, 10, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := bucketStart(tt.t, tt.bucket, tt.loc); !got.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+// ✅ Metrics query: Sum aggregation from raw data, unknown aggregations are rejected
+func TestQueryData_SumAggregation(t *testing.T) {
+	start := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	var avg string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		avg = r.URL.Query().Get("avg")
+		fmt.Fprintf(w, `{"histdata": [
+			{"datetime": %q, "Volume": 2},
+			{"datetime": %q, "Volume": 3},
+			{"datetime": %q, "Volume": 4}
+		]}`, start.Format(time.RFC3339), start.Add(time.Minute).Format(time.RFC3339), start.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC}
+	request := func(aggregation string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"Volume","aggregation":"` + aggregation + `"}`),
+			Interval:  10 * time.Minute,
+			TimeRange: backend.TimeRange{From: start.Add(-7 * 24 * time.Hour), To: start.Add(2 * time.Hour)},
+		})
+	}
+
+	resp := request("SUM")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if avg != "0" {
+		t.Errorf("Expected raw data (avg=0) to be requested, got avg=%s", avg)
+	}
+	field := resp.Frames[0].Fields[1]
+	if field.Len() != 2 || field.At(0).(float64) != 5 || field.At(1).(float64) != 4 {
+		t.Errorf("Expected bucket sums [5 4], got %d values", field.Len())
+	}
+	if !strings.HasSuffix(field.Config.DisplayName, "(sum)") {
+		t.Errorf("Expected the display name to mention sum, got %q", field.Config.DisplayName)
+	}
+
+	resp = request("sumsd")
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "unknown aggregation") {
+		t.Errorf("Expected an unknown aggregation error, got %v", resp.Error)
+	}
+}
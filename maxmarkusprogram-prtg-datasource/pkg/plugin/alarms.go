@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// PRTG priorities range from one to five stars.
+const (
+	minPriority = 1
+	maxPriority = 5
+)
+
+// parsePriority returns the priority (1-5) of an object. PRTG delivers the raw value as
+// number and the display value as stars ("***"), older versions also as plain number.
+func parsePriority(priorityRAW int, priority string) (int, bool) {
+	if priorityRAW >= minPriority && priorityRAW <= maxPriority {
+		return priorityRAW, true
+	}
+
+	priority = strings.TrimSpace(cleanMessageHTML(priority))
+	if stars := strings.Count(priority, "*"); stars > 0 && stars == len(priority) {
+		return clampPriority(stars), true
+	}
+	if p, err := strconv.Atoi(priority); err == nil && p >= minPriority && p <= maxPriority {
+		return p, true
+	}
+	return 0, false
+}
+
+// clampPriority limits p to the valid priority range.
+func clampPriority(p int) int {
+	if p < minPriority {
+		return minPriority
+	}
+	if p > maxPriority {
+		return maxPriority
+	}
+	return p
+}
+
+// alarmCountsByPriority counts the alarms per priority. The result always contains all
+// priorities from 1 to 5, so panels get stable axes. Alarms without a readable priority
+// are skipped and reported as the second return value.
+func alarmCountsByPriority(alarms []PrtgSensorListItemStruct) ([]int64, []int64, int) {
+	counts := make([]int64, maxPriority-minPriority+1)
+	skipped := 0
+	for _, alarm := range alarms {
+		p, ok := parsePriority(alarm.PriorityRAW, alarm.Priority)
+		if !ok {
+			skipped++
+			continue
+		}
+		counts[p-minPriority]++
+	}
+
+	priorities := make([]int64, len(counts))
+	for i := range priorities {
+		priorities[i] = int64(minPriority + i)
+	}
+	return priorities, counts, skipped
+}
+
+// handleAlarmsQuery returns the current alarms as table, one row per alarm sensor.
+func (d *Datasource) handleAlarmsQuery(ctx context.Context) backend.DataResponse {
+	var response backend.DataResponse
+
+	alarms, err := d.api.GetAlarms(ctx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	timezone, _ := d.effectiveTimezone(ctx)
+	n := len(alarms.Alarms)
+	times := make([]*time.Time, n)
+	objids := make([]int64, n)
+	sensors := make([]string, n)
+	devices := make([]string, n)
+	groups := make([]string, n)
+	statuses := make([]string, n)
+	priorities := make([]*int64, n)
+	messages := make([]string, n)
+	for i, alarm := range alarms.Alarms {
+		if t, _, err := parsePRTGDateTimeIn(alarm.Datetime, timezone); err == nil {
+			times[i] = &t
+		}
+		if p, ok := parsePriority(alarm.PriorityRAW, alarm.Priority); ok {
+			priority := int64(p)
+			priorities[i] = &priority
+		}
+		objids[i] = alarm.ObjectId
+		sensors[i] = alarm.Sensor
+		devices[i] = alarm.Device
+		groups[i] = alarm.Group
+		statuses[i] = cleanMessageHTML(alarm.Status)
+		messages[i] = cleanMessageHTML(alarm.Message)
+	}
+
+	frame := data.NewFrame("response",
+		data.NewField("Time", nil, times),
+		data.NewField("Object ID", nil, objids),
+		data.NewField("Sensor", nil, sensors),
+		data.NewField("Device", nil, devices),
+		data.NewField("Group", nil, groups),
+		data.NewField("Status", nil, statuses),
+		data.NewField("Priority", nil, priorities),
+		data.NewField("Message", nil, messages),
+	)
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+	response.Frames = append(response.Frames, frame)
+	return response
+}
@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ parsePriority test: Raw values, stars and invalid input
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		raw      int
+		text     string
+		expected int
+		ok       bool
+	}{
+		{3, "***", 3, true},
+		{0, "*****", 5, true},
+		{0, " ** ", 2, true},
+		{0, "4", 4, true},
+		{9, "*", 1, true},
+		{0, "", 0, false},
+		{0, "high", 0, false},
+		{0, "7", 0, false},
+	}
+
+	for _, tt := range tests {
+		p, ok := parsePriority(tt.raw, tt.text)
+		if p != tt.expected || ok != tt.ok {
+			t.Errorf("parsePriority(%d, %q): expected %d/%v, got %d/%v", tt.raw, tt.text, tt.expected, tt.ok, p, ok)
+		}
+	}
+}
+
+// ✅ alarmCountsByPriority test: Mixed priorities including empty ones
+func TestAlarmCountsByPriority(t *testing.T) {
+	alarms := []PrtgSensorListItemStruct{
+		{Sensor: "A", PriorityRAW: 5},
+		{Sensor: "B", PriorityRAW: 5},
+		{Sensor: "C", Priority: "***"},
+		{Sensor: "D", PriorityRAW: 1},
+		{Sensor: "E", Priority: "unknown"},
+	}
+
+	priorities, counts, skipped := alarmCountsByPriority(alarms)
+	expected := []int64{1, 0, 1, 0, 2}
+	if len(priorities) != 5 || priorities[0] != 1 || priorities[4] != 5 {
+		t.Fatalf("Expected priorities 1-5, got %v", priorities)
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("Priority %d: expected %d alarms, got %d", priorities[i], expected[i], counts[i])
+		}
+	}
+	if skipped != 1 {
+		t.Errorf("Expected 1 skipped alarm, got %d", skipped)
+	}
+}
+
+// ✅ QueryData test: Alarm counts by priority
+func TestQueryData_AlarmsByPriority(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "A", "priority_raw": 4}, {"sensor": "B", "priority": "****"}, {"sensor": "C", "priority_raw": 2}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"alarmsByPriority"}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	countField := resp.Frames[0].Fields[1]
+	if countField.Len() != 5 {
+		t.Fatalf("Expected 5 priorities, got %d", countField.Len())
+	}
+	if countField.At(1).(int64) != 1 || countField.At(3).(int64) != 2 || countField.At(0).(int64) != 0 {
+		t.Errorf("Unexpected counts: %v, %v, %v", countField.At(0), countField.At(1), countField.At(3))
+	}
+}
+
+// ✅ GetAlarms sends one filter_status parameter per alarm status
+func TestBuildApiUrl_FilterStatus(t *testing.T) {
+	api := NewApi("http://localhost", "test-api-key", 10*time.Second, 10*time.Second)
+	apiUrl, err := api.buildApiUrl("table.json", map[string]string{"filter_status": "5,4,10"})
+	if err != nil {
+		t.Fatalf("Failed to build API URL: %v", err)
+	}
+	parsedUrl, _ := url.Parse(apiUrl)
+	if statuses := parsedUrl.Query()["filter_status"]; len(statuses) != 3 || statuses[0] != "5" {
+		t.Errorf("Expected three filter_status values, got %v", statuses)
+	}
+}
+
+// ✅ Alarms query: One table row per alarm with priority, time and message
+func TestQueryData_Alarms(t *testing.T) {
+	server, api := setupMockAPI(`{"treesize": 2, "sensors": [
+		{"objid": 2001, "datetime": "15.02.2025 12:00:00", "sensor": "Ping", "device": "Router", "group": "Network",
+		 "status": "Down", "priority": "****", "priority_raw": 4, "message": "<div class=\"status\">Timeout</div>"},
+		{"objid": 2002, "datetime": "", "sensor": "Disk", "device": "Server", "group": "Servers",
+		 "status": "Warning", "priority": "", "message": "90 % used"}
+	]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, timezone: time.UTC}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"alarms"}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 2 {
+		t.Fatalf("Expected 2 alarms, got %d", rows)
+	}
+	if v := frame.Fields[0].At(0).(*time.Time); v == nil || !v.Equal(time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected time: %v", v)
+	}
+	if frame.Fields[0].At(1).(*time.Time) != nil {
+		t.Errorf("Expected no time for the second alarm")
+	}
+	if frame.Fields[1].At(0).(int64) != 2001 || frame.Fields[2].At(0) != "Ping" || frame.Fields[5].At(0) != "Down" {
+		t.Errorf("Unexpected row: %v %v %v", frame.Fields[1].At(0), frame.Fields[2].At(0), frame.Fields[5].At(0))
+	}
+	if p := frame.Fields[6].At(0).(*int64); p == nil || *p != 4 {
+		t.Errorf("Expected priority 4, got %v", p)
+	}
+	if frame.Fields[6].At(1).(*int64) != nil {
+		t.Errorf("Expected no priority for the second alarm")
+	}
+	if frame.Fields[7].At(0) != "Timeout" {
+		t.Errorf("Expected the cleaned message, got %q", frame.Fields[7].At(0))
+	}
+}
+
+// ✅ CallResource test: Current alarms
+func TestCallResourceAlarms(t *testing.T) {
+	server, api := setupMockServer(`{"sensors": [{"objid": 2001, "sensor": "Ping", "status": "Down"}]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "alarms"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK || !strings.Contains(string(respSender.body), `"sensor":"Ping"`) {
+		t.Errorf("Expected the alarm list, got %d %s", respSender.status, respSender.body)
+	}
+}
+
+// ✅ alarmcounts route: Counts per priority including zero counts
+func TestCallResourceAlarmCounts(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "A", "priority_raw": 4}, {"sensor": "B", "priority": "****"}, {"sensor": "C", "priority": "?"}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "alarmcounts"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	expected := `{"counts":[{"priority":1,"count":0},{"priority":2,"count":0},{"priority":3,"count":0},{"priority":4,"count":2},{"priority":5,"count":0}],"skipped":1}`
+	if respSender.status != http.StatusOK || string(respSender.body) != expected {
+		t.Errorf("Expected %s, got %d %s", expected, respSender.status, respSender.body)
+	}
+}
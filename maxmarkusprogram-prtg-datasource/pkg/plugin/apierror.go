@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxErrorBodySize bounds the part of an error response that is read for the message.
+const maxErrorBodySize = 64 << 10
+
+// maxErrorMessageLength bounds the PRTG message in an error, HTML pages can be long.
+const maxErrorMessageLength = 300
+
+// PRTGAPIError is returned for PRTG responses with a status other than 200. Message is the
+// error text PRTG delivered in the body, it may be empty.
+type PRTGAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *PRTGAPIError) Error() string {
+	text := fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		// PRTG did not accept the token at all, e.g. a typo or a revoked token
+		text = "authentication failed: please verify the API token"
+	case http.StatusForbidden:
+		// The token is valid, but its user lacks the rights for the object or action
+		text = "access denied: the API token lacks the permissions for this request"
+	}
+	if e.Message != "" {
+		text += ": " + e.Message
+	}
+	return text
+}
+
+var (
+	htmlSkipPattern       = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlTagPattern        = regexp.MustCompile(`(?s)<[^>]*>`)
+	xmlErrorPattern       = regexp.MustCompile(`(?is)<error>(.*?)</error>`)
+	whitespaceRunsPattern = regexp.MustCompile(`\s+`)
+)
+
+// prtgErrorMessage extracts the error text of a PRTG error response. PRTG answers with JSON
+// ({"errormessage": ...}), XML (<error>...</error>) or an HTML page depending on the endpoint;
+// anything else is used as plain text.
+func prtgErrorMessage(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if text == "" {
+		return ""
+	}
+
+	var payload map[string]interface{}
+	if json.Unmarshal(body, &payload) == nil {
+		text = ""
+		for _, key := range []string{"errormessage", "error", "message"} {
+			if message, ok := payload[key].(string); ok && strings.TrimSpace(message) != "" {
+				text = message
+				break
+			}
+		}
+	} else if match := xmlErrorPattern.FindStringSubmatch(text); match != nil {
+		text = match[1]
+	}
+
+	text = htmlSkipPattern.ReplaceAllString(text, " ")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = strings.TrimSpace(whitespaceRunsPattern.ReplaceAllString(text, " "))
+	if runes := []rune(text); len(runes) > maxErrorMessageLength {
+		text = string(runes[:maxErrorMessageLength]) + "…"
+	}
+	return text
+}
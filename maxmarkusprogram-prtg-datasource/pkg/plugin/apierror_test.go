@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ✅ prtgErrorMessage test: JSON, XML, HTML and plain text bodies
+func TestPrtgErrorMessage(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{`{"prtg-version": "24.1", "errormessage": "The object id 999 is unknown."}`, "The object id 999 is unknown."},
+		{`{"error": "Bad column"}`, "Bad column"},
+		{`{"prtg-version": "24.1"}`, ""},
+		{`<?xml version="1.0"?><prtg><error>Sorry, the selected object cannot be used here.</error></prtg>`, "Sorry, the selected object cannot be used here."},
+		{"<html><head><title>PRTG</title><style>p {}</style></head><body><h1>Error</h1>\n<p>Unknown   column <b>foo</b></p></body></html>", "Error Unknown column foo"},
+		{"Service Unavailable\n", "Service Unavailable"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := prtgErrorMessage([]byte(tt.body)); got != tt.expected {
+			t.Errorf("prtgErrorMessage(%q) = %q; expected %q", tt.body, got, tt.expected)
+		}
+	}
+
+	long := prtgErrorMessage([]byte(strings.Repeat("x", 2*maxErrorMessageLength)))
+	if len([]rune(long)) != maxErrorMessageLength+1 {
+		t.Errorf("Expected the message to be truncated, got %d characters", len([]rune(long)))
+	}
+}
+
+// ✅ API error test: Status code and PRTG message are returned as PRTGAPIError
+func TestPRTGAPIError(t *testing.T) {
+	server, api := setupMockServer(`{"errormessage": "Unknown id 999 (apitoken=test-api-key)"}`, http.StatusBadRequest)
+	defer server.Close()
+
+	_, err := api.GetHistoricalData(context.Background(), "999", time.Now().Add(-time.Hour).UnixMilli(), time.Now().UnixMilli())
+	var apiErr *PRTGAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected a PRTGAPIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || !strings.Contains(apiErr.Message, "Unknown id 999") {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+	if strings.Contains(err.Error(), "test-api-key") || !strings.Contains(err.Error(), "unexpected status code: 400: Unknown id 999") {
+		t.Errorf("Unexpected error text: %q", err.Error())
+	}
+
+	// A rejected token and missing permissions are told apart
+	unauthorized := &PRTGAPIError{StatusCode: http.StatusUnauthorized, Message: "Unauthorized"}
+	if unauthorized.Error() != "authentication failed: please verify the API token: Unauthorized" {
+		t.Errorf("Unexpected error text: %q", unauthorized.Error())
+	}
+	forbidden := &PRTGAPIError{StatusCode: http.StatusForbidden}
+	if forbidden.Error() != "access denied: the API token lacks the permissions for this request" {
+		t.Errorf("Unexpected error text: %q", forbidden.Error())
+	}
+}
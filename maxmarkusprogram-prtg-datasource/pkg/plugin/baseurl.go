@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultScheme is used for the PRTG server if neither the settings nor the path name one.
+const defaultScheme = "https"
+
+// normalizeBaseURL builds the base URL of a PRTG server from the path setting, e.g.
+// "prtg.example.com", "https://prtg.example.com/" or "prtg.example.com:8443/prtg". A scheme
+// typed in the path is stripped and only used if scheme is empty; trailing slashes are
+// removed. Hosts that cannot be valid return an error naming the setting.
+func normalizeBaseURL(path, scheme string) (string, error) {
+	raw := strings.TrimSpace(path)
+	if raw == "" {
+		return "", fmt.Errorf("invalid path: the PRTG server is missing")
+	}
+
+	typed := ""
+	if i := strings.Index(raw, "://"); i >= 0 {
+		typed, raw = strings.ToLower(raw[:i]), raw[i+3:]
+	}
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" {
+		scheme = typed
+	}
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("invalid scheme %q: expected http or https", scheme)
+	}
+
+	raw = strings.TrimRight(raw, "/")
+	if raw == "" || strings.ContainsAny(raw, " \t?#") {
+		return "", fmt.Errorf("invalid path %q: expected a host like prtg.example.com", path)
+	}
+	u, err := url.Parse(scheme + "://" + raw)
+	if err != nil || u.User != nil {
+		return "", fmt.Errorf("invalid path %q: expected a host like prtg.example.com", path)
+	}
+	if err := validateHostPort(u.Host); err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	return u.String(), nil
+}
+
+// validateHostPort checks the host and optional port of a base URL.
+func validateHostPort(hostport string) error {
+	host, port := hostport, ""
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	} else if strings.Count(hostport, ":") == 1 {
+		return fmt.Errorf("invalid port in %q", hostport)
+	}
+	host = strings.Trim(host, "[]")
+
+	if port != "" || strings.HasSuffix(hostport, ":") {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if host == "" || len(host) > 253 || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") || strings.Contains(host, "..") {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("invalid host %q", host)
+		}
+		for _, r := range label {
+			if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return fmt.Errorf("invalid host %q", host)
+			}
+		}
+	}
+	return nil
+}
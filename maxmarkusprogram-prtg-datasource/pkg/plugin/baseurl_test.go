@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ normalizeBaseURL test: Common ways to type the PRTG server
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		path     string
+		scheme   string
+		expected string
+		wantErr  bool
+	}{
+		{"prtg.example.com", "", "https://prtg.example.com", false},
+		{"  prtg.example.com/  ", "", "https://prtg.example.com", false},
+		{"https://prtg.example.com//", "", "https://prtg.example.com", false},
+		{"HTTP://prtg.local:8080", "", "http://prtg.local:8080", false},
+		{"prtg.local:8080", "http", "http://prtg.local:8080", false},
+		{"https://prtg.local", "HTTP", "http://prtg.local", false},
+		{"prtg.example.com/prtg/", "", "https://prtg.example.com/prtg", false},
+		{"192.168.1.10", "", "https://192.168.1.10", false},
+		{"[::1]:8443", "", "https://[::1]:8443", false},
+		{"", "", "", true},
+		{"https://", "", "", true},
+		{"ftp://prtg.example.com", "", "", true},
+		{"prtg.example.com", "ws", "", true},
+		{"prtg example.com", "", "", true},
+		{"prtg.example.com?x=1", "", "", true},
+		{"prtg.example.com:", "", "", true},
+		{"prtg.example.com:99999", "", "", true},
+		{"prtg.example.com:abc", "", "", true},
+		{"prtg..example.com", "", "", true},
+		{"-prtg.example.com", "", "", true},
+		{"user:secret@prtg.example.com", "", "", true},
+	}
+
+	for _, tt := range tests {
+		baseURL, err := normalizeBaseURL(tt.path, tt.scheme)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q/%q: expected an error, got %s", tt.path, tt.scheme, baseURL)
+			}
+			continue
+		}
+		if err != nil || baseURL != tt.expected {
+			t.Errorf("%q/%q: expected %s, got %s (%v)", tt.path, tt.scheme, tt.expected, baseURL, err)
+		}
+	}
+}
+
+// ✅ Datasource oluşturma test: Invalid paths are configuration errors
+func TestNewDatasourceBaseURL(t *testing.T) {
+	ds, err := NewDatasource(context.Background(), backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"path":"http://prtg.local:8080/","failoverPaths":["prtg-2.local:8080","https://prtg-3.local"]}`),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create datasource: %v", err)
+	}
+	api := ds.(*Datasource).api
+	if api.baseURL != "http://prtg.local:8080" || len(api.failoverURLs) != 2 || api.failoverURLs[0] != "http://prtg-2.local:8080" || api.failoverURLs[1] != "https://prtg-3.local" {
+		t.Errorf("Unexpected URLs %s %v", api.baseURL, api.failoverURLs)
+	}
+
+	for _, jsonData := range []string{`{"path":"prtg example.com"}`, `{"path":"prtg.local","failoverPaths":["prtg 2"]}`} {
+		if _, err := NewDatasource(context.Background(), backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)}); err == nil {
+			t.Errorf("%s: expected an error", jsonData)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTreeCacheTime is the TTL of the object tree cache if none is configured.
+// The tree changes slowly, so it is cached much longer than metric data.
+const defaultTreeCacheTime = 5 * time.Minute
+
+// maxCacheEntries caps the number of entries of a ttlCache. Keys include the query
+// parameters, so without a cap every distinct time range or filter would add an entry.
+const maxCacheEntries = 1000
+
+// ttlCache is a small in-memory cache whose entries expire after a fixed TTL.
+// Expired entries are swept on set at most once per TTL, and when the cache holds
+// maxEntries entries the one expiring first is dropped. A nil *ttlCache is valid and
+// caches nothing.
+type ttlCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]ttlCacheEntry
+	counts     map[string]*cacheStats
+	lastSweep  time.Time
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+type ttlCacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// cacheStats counts the lookups of an endpoint. Evictions are entries dropped because their
+// TTL had passed or the cache was full, a high count next to few hits suggests the TTL is too
+// short.
+type cacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// cacheEndpoint returns the endpoint a key is counted for, the key without its parameters.
+func cacheEndpoint(key string) string {
+	endpoint, _, _ := strings.Cut(key, "?")
+	return endpoint
+}
+
+// newTTLCache creates a cache with the given TTL.
+func newTTLCache(ttl time.Duration) *ttlCache {
+	return &ttlCache{
+		ttl:        ttl,
+		maxEntries: maxCacheEntries,
+		entries:    make(map[string]ttlCacheEntry),
+		counts:     make(map[string]*cacheStats),
+		now:        time.Now,
+	}
+}
+
+// countsFor returns the counters of the endpoint of key. c.mu must be held.
+func (c *ttlCache) countsFor(key string) *cacheStats {
+	counts := c.counts[cacheEndpoint(key)]
+	if counts == nil {
+		counts = &cacheStats{}
+		c.counts[cacheEndpoint(key)] = counts
+	}
+	return counts
+}
+
+// get returns the cached value for key if it has not expired yet.
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := c.countsFor(key)
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		if ok {
+			counts.Evictions++
+			delete(c.entries, key)
+		}
+		counts.Misses++
+		return nil, false
+	}
+	counts.Hits++
+	return entry.value, true
+}
+
+// stats returns a snapshot of the counters per endpoint. They are kept when the cache is cleared.
+func (c *ttlCache) stats() map[string]cacheStats {
+	stats := make(map[string]cacheStats)
+	if c == nil {
+		return stats
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for endpoint, counts := range c.counts {
+		stats[endpoint] = *counts
+	}
+	return stats
+}
+
+// set stores value for key.
+func (c *ttlCache) set(key string, value interface{}) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, ok := c.entries[key]; !ok {
+		if !now.Before(c.lastSweep.Add(c.ttl)) || len(c.entries) >= c.maxEntries {
+			c.sweep(now)
+		}
+		if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+			c.evictOldest()
+		}
+	}
+	c.entries[key] = ttlCacheEntry{value: value, expires: now.Add(c.ttl)}
+}
+
+// sweep removes all expired entries. c.mu must be held.
+func (c *ttlCache) sweep(now time.Time) {
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			c.countsFor(key).Evictions++
+			delete(c.entries, key)
+		}
+	}
+}
+
+// evictOldest removes the entry that expires first. c.mu must be held.
+func (c *ttlCache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if oldestKey != "" {
+		c.countsFor(oldestKey).Evictions++
+		delete(c.entries, oldestKey)
+	}
+}
+
+// clear removes all entries.
+func (c *ttlCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]ttlCacheEntry)
+}
+
+// noResponseCacheKey marks a context whose requests bypass the API response cache.
+type noResponseCacheKey struct{}
+
+// withoutResponseCache returns a context whose requests skip the API response cache, used
+// where the result is cached at a higher level already.
+func withoutResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noResponseCacheKey{}, true)
+}
+
+// responseCacheDisabled reports whether ctx was created by withoutResponseCache.
+func responseCacheDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noResponseCacheKey{}).(bool)
+	return disabled
+}
+
+// cachedTree returns the cached list response for key or fetches and caches the full list.
+// The result is a shallow copy that shares its slices with the cache: callers may assign new
+// slices to it, but must clone a slice before modifying it in place, e.g. before sorting.
+// The fetch bypasses the API response cache, otherwise a tree entry refreshed from a response
+// about to expire would be stale for up to both TTLs.
+func cachedTree[T any](ctx context.Context, c *ttlCache, key string, fetch func(context.Context, listFilter) (*T, error)) (*T, error) {
+	if value, ok := c.get(key); ok {
+		cached := *value.(*T)
+		return &cached, nil
+	}
+
+	response, err := fetch(withoutResponseCache(ctx), listFilter{})
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, response)
+
+	result := *response
+	return &result, nil
+}
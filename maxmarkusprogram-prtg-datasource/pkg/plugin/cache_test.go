@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ ttlCache test: Entries expire after the TTL
+func TestTTLCache(t *testing.T) {
+	now := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	cache := newTTLCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.set("key", 42)
+	if value, ok := cache.get("key"); !ok || value.(int) != 42 {
+		t.Fatalf("Expected cached value 42, got %v/%v", value, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("key"); ok {
+		t.Errorf("Expected the entry to expire after the TTL")
+	}
+
+	// Expired entries are swept on set once per TTL
+	cache.set("other", 1)
+	now = now.Add(time.Minute)
+	cache.set("new", 2)
+	if len(cache.entries) != 1 || cache.stats()["other"].Evictions != 1 {
+		t.Errorf("Expected the expired entry to be swept, got %d entries", len(cache.entries))
+	}
+
+	// A full cache drops the entry expiring first
+	cache.maxEntries = 2
+	now = now.Add(time.Second)
+	cache.set("newer", 3)
+	cache.set("newest", 4)
+	if _, ok := cache.entries["new"]; ok || len(cache.entries) != 2 {
+		t.Errorf("Expected the oldest entry to be dropped, got %d entries", len(cache.entries))
+	}
+
+	// A nil cache caches nothing
+	var nilCache *ttlCache
+	nilCache.set("key", 1)
+	if _, ok := nilCache.get("key"); ok {
+		t.Errorf("Expected a nil cache to never hit")
+	}
+}
+
+// ✅ Tree routes are cached for the longer tree TTL and can be cleared
+func TestCallResourceGroups_TreeCache(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, `{"groups": [{"group": "Network Devices"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	cache := newTTLCache(defaultTreeCacheTime)
+	cache.now = func() time.Time { return now }
+
+	api := NewApi(server.URL, "test-api-key", 30*time.Second, 10*time.Second)
+	api.responses.now = func() time.Time { return now }
+	ds := &Datasource{
+		api:       api,
+		treeCache: cache,
+	}
+	fetch := func() {
+		respSender := &mockResourceResponseSender{}
+		if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "groups"}, respSender); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+		if respSender.status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %v", respSender.status)
+		}
+	}
+
+	fetch()
+	// Well past the data cache time, but within the tree TTL
+	now = now.Add(2 * time.Minute)
+	fetch()
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 API call within the tree TTL, got %d", n)
+	}
+
+	now = now.Add(defaultTreeCacheTime)
+	fetch()
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected a new API call after the tree TTL, got %d", n)
+	}
+
+	// Manual invalidation
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "cache/clear"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK {
+		t.Errorf("Expected status 200 for cache/clear, got %v", respSender.status)
+	}
+	fetch()
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected a new API call after clearing the cache, got %d", n)
+	}
+}
+
+func TestApiResponseCache(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, `{"prtgversion": "21.2.68.1492", "sensors": [{"objid": 1}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	api := NewApi(server.URL, "test-api-key", 30*time.Second, 10*time.Second)
+	api.responses.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+			t.Fatalf("GetSensors failed: %v", err)
+		}
+		if _, err := api.GetStatusList(context.Background()); err != nil {
+			t.Fatalf("GetStatusList failed: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 API calls within the TTL, got %d", n)
+	}
+
+	// The health check bypasses the cache
+	if _, err := api.GetLiveStatusList(context.Background()); err != nil {
+		t.Fatalf("GetLiveStatusList failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected the live status to call the API, got %d", n)
+	}
+
+	// Stale entries are refreshed on the next request
+	now = now.Add(31 * time.Second)
+	if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetSensors failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Errorf("Expected a new API call after the TTL, got %d", n)
+	}
+
+	api.ClearCache()
+	if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetSensors failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 5 {
+		t.Errorf("Expected a new API call after clearing the cache, got %d", n)
+	}
+}
+
+// ✅ Cache stats: Hits, misses and evictions per endpoint across repeated requests
+func TestCallResource_CacheStats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"groups": [{"group": "Network Devices"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	cache := newTTLCache(defaultTreeCacheTime)
+	cache.now = func() time.Time { return now }
+	api := NewApi(server.URL, "test-api-key", 30*time.Second, 10*time.Second)
+	api.responses.now = func() time.Time { return now }
+	ds := &Datasource{api: api, treeCache: cache}
+
+	call := func(path string) []byte {
+		respSender := &mockResourceResponseSender{}
+		if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: path}, respSender); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+		if respSender.status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %v", respSender.status)
+		}
+		return respSender.body
+	}
+	stats := func() (map[string]cacheStats, map[string]cacheStats) {
+		var response struct {
+			Tree      map[string]cacheStats `json:"tree"`
+			Responses map[string]cacheStats `json:"responses"`
+		}
+		if err := json.Unmarshal(call("cachestats"), &response); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+		return response.Tree, response.Responses
+	}
+
+	call("groups")
+	call("groups")
+	tree, responses := stats()
+	if expected := (cacheStats{Hits: 1, Misses: 1}); tree["groups"] != expected {
+		t.Errorf("Expected tree stats %+v, got %+v", expected, tree["groups"])
+	}
+	// Tree fetches bypass the response cache
+	if stats, ok := responses["table.json"]; ok {
+		t.Errorf("Expected no response cache lookups for the tree, got %+v", stats)
+	}
+
+	// Past the TTL the entry is evicted and fetched again
+	now = now.Add(defaultTreeCacheTime)
+	call("groups")
+	tree, responses = stats()
+	if expected := (cacheStats{Hits: 1, Misses: 2, Evictions: 1}); tree["groups"] != expected {
+		t.Errorf("Expected tree stats %+v, got %+v", expected, tree["groups"])
+	}
+	if stats, ok := responses["table.json"]; ok {
+		t.Errorf("Expected no response cache lookups for the tree, got %+v", stats)
+	}
+}
+
+// ✅ ttlCache stats: Counters stay consistent under concurrent lookups
+func TestTTLCacheStatsConcurrent(t *testing.T) {
+	cache := newTTLCache(time.Minute)
+	cache.set("table.json?id=1", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cache.get("table.json?id=1")
+				cache.get("table.json?id=2")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if expected := (cacheStats{Hits: 800, Misses: 800}); cache.stats()["table.json"] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cache.stats()["table.json"])
+	}
+}
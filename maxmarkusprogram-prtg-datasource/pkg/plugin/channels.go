@@ -0,0 +1,189 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// primaryChannel is the special channel value that resolves to the sensor's primary channel.
+const primaryChannel = "primary"
+
+// isPrimaryChannel reports whether channel requests the primary channel.
+func isPrimaryChannel(channel string) bool {
+	return strings.EqualFold(strings.TrimSpace(channel), primaryChannel)
+}
+
+// parsePrimaryChannelSetting splits PRTG's "<id>|<name>" primary channel setting.
+func parsePrimaryChannelSetting(setting string) (int64, string, bool) {
+	idPart, name, _ := strings.Cut(strings.TrimSpace(setting), "|")
+	id, err := strconv.ParseInt(strings.TrimSpace(idPart), 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return id, strings.TrimSpace(name), true
+}
+
+// firstNumericChannel returns the first regular channel with a numeric last value.
+// Channels with negative ids (e.g. Downtime) are PRTG internal and skipped.
+func firstNumericChannel(channels []PrtgChannelListItemStruct) (string, bool) {
+	for _, ch := range channels {
+		if ch.ObjectId < 0 {
+			continue
+		}
+		if _, ok := ch.LastValueRAW.(float64); ok {
+			return ch.Name, true
+		}
+	}
+	return "", false
+}
+
+// resolvePrimaryChannel returns the name of the primary channel of a sensor. If PRTG has
+// no primary channel marked, the first numeric channel is used.
+func (d *Datasource) resolvePrimaryChannel(ctx context.Context, objid string) (string, error) {
+	setting, err := d.api.GetPrimaryChannel(ctx, objid)
+	if err == nil {
+		if _, name, ok := parsePrimaryChannelSetting(setting); ok && name != "" {
+			return name, nil
+		}
+	}
+
+	channels, listErr := d.api.GetChannelList(ctx, objid)
+	if listErr != nil {
+		return "", fmt.Errorf("failed to resolve primary channel: %w", listErr)
+	}
+
+	// The setting may only contain the id
+	if id, _, ok := parsePrimaryChannelSetting(setting); ok {
+		for _, ch := range channels.Channels {
+			if ch.ObjectId == id {
+				return ch.Name, nil
+			}
+		}
+	}
+
+	if name, ok := firstNumericChannel(channels.Channels); ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("sensor %s has no numeric channel", objid)
+}
+
+// channelColorPattern matches the hex colors PRTG stores for channels.
+var channelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// PRTG color modes of a channel, only manual colors are used.
+const channelColorModeManual = "1"
+
+// normalizeChannelColor returns the color in lower case if it is a valid hex color.
+func normalizeChannelColor(color string) (string, bool) {
+	color = strings.TrimSpace(color)
+	if !channelColorPattern.MatchString(color) {
+		return "", false
+	}
+	return strings.ToLower(color), true
+}
+
+// channelColors returns the manually configured PRTG colors of the given channels by name.
+// Channels with automatic coloring or unreadable settings are left out.
+func (d *Datasource) channelColors(ctx context.Context, objid string, names []string) map[string]string {
+	channels, err := d.api.GetChannelList(ctx, objid)
+	if err != nil {
+		backend.Logger.Warn("Could not read channel colors", "objectId", objid, "error", err)
+		return nil
+	}
+
+	ids := make(map[string]int64, len(channels.Channels))
+	for _, ch := range channels.Channels {
+		ids[strings.TrimSpace(ch.Name)] = ch.ObjectId
+	}
+
+	colors := make(map[string]string)
+	for _, name := range names {
+		id, ok := ids[strings.TrimSpace(name)]
+		if !ok {
+			continue
+		}
+		mode, err := d.api.GetChannelProperty(ctx, objid, id, "colmode")
+		if err != nil || mode != channelColorModeManual {
+			continue
+		}
+		color, err := d.api.GetChannelProperty(ctx, objid, id, "color")
+		if err != nil {
+			continue
+		}
+		if color, ok := normalizeChannelColor(color); ok {
+			colors[name] = color
+		}
+	}
+	return colors
+}
+
+// PRTG decimal modes of a channel, only custom decimal places are applied.
+const channelDecimalModeCustom = "2"
+
+// channelFormat is the display format of a channel configured in PRTG.
+type channelFormat struct {
+	decimals *uint16
+	unit     string
+}
+
+// channelUnit maps the unit of a channel to a Grafana unit id. Units Grafana does not know
+// are shown as suffix.
+func channelUnit(unit string) string {
+	unit = strings.TrimSpace(unit)
+	if unit == "" {
+		return ""
+	}
+	if known, ok := lookupCaptionUnit(unit); ok {
+		return known.grafana
+	}
+	if alias, ok := unitAliases[strings.ToLower(unit)]; ok {
+		return alias
+	}
+	return "suffix:" + unit
+}
+
+// channelFormats returns the decimal places and units configured in PRTG for the given
+// channels by name. Channels without custom decimals or unit are left out, Grafana's
+// defaults apply to them.
+func (d *Datasource) channelFormats(ctx context.Context, objid string, names []string) map[string]channelFormat {
+	channels, err := d.api.GetChannelList(ctx, objid)
+	if err != nil {
+		backend.Logger.Warn("Could not read channel formats", "objectId", objid, "error", err)
+		return nil
+	}
+
+	ids := make(map[string]int64, len(channels.Channels))
+	for _, ch := range channels.Channels {
+		ids[strings.TrimSpace(ch.Name)] = ch.ObjectId
+	}
+
+	formats := make(map[string]channelFormat)
+	for _, name := range names {
+		id, ok := ids[strings.TrimSpace(name)]
+		if !ok {
+			continue
+		}
+
+		var format channelFormat
+		if mode, err := d.api.GetChannelProperty(ctx, objid, id, "decimalmode"); err == nil && mode == channelDecimalModeCustom {
+			digits, err := d.api.GetChannelProperty(ctx, objid, id, "decimaldigits")
+			if n, convErr := strconv.ParseUint(digits, 10, 16); err == nil && convErr == nil {
+				decimals := uint16(n)
+				format.decimals = &decimals
+			}
+		}
+		if unit, err := d.api.GetChannelProperty(ctx, objid, id, "customunit"); err == nil {
+			format.unit = channelUnit(unit)
+		}
+
+		if format.decimals != nil || format.unit != "" {
+			formats[name] = format
+		}
+	}
+	return formats
+}
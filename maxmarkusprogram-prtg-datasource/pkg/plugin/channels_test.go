@@ -0,0 +1,309 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ parsePrimaryChannelSetting test
+func TestParsePrimaryChannelSetting(t *testing.T) {
+	tests := []struct {
+		setting      string
+		expectedID   int64
+		expectedName string
+		ok           bool
+	}{
+		{"2|Total", 2, "Total", true},
+		{" 0 | Ping Time ", 0, "Ping Time", true},
+		{"3", 3, "", true},
+		{"", 0, "", false},
+		{"Total", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		id, name, ok := parsePrimaryChannelSetting(tt.setting)
+		if id != tt.expectedID || name != tt.expectedName || ok != tt.ok {
+			t.Errorf("parsePrimaryChannelSetting(%q): expected %d/%q/%v, got %d/%q/%v", tt.setting, tt.expectedID, tt.expectedName, tt.ok, id, name, ok)
+		}
+	}
+}
+
+// ✅ Primary channel resolution: marked primary, id only and numeric fallback
+func TestResolvePrimaryChannel(t *testing.T) {
+	channelList := `{"channels": [
+		{"objid": -4, "name": "Downtime", "lastvalue_raw": 0},
+		{"objid": 0, "name": "Status", "lastvalue": "Up", "lastvalue_raw": ""},
+		{"objid": 1, "name": "Packet Loss", "lastvalue_raw": 0.5},
+		{"objid": 2, "name": "Ping Time", "lastvalue_raw": 12}
+	]}`
+
+	tests := []struct {
+		name     string
+		property string
+		expected string
+	}{
+		{"Marked primary", `<?xml version="1.0" encoding="UTF-8"?><prtg><version>23.1</version><result>2|Ping Time</result></prtg>`, "Ping Time"},
+		{"Id only", `<prtg><result>2</result></prtg>`, "Ping Time"},
+		{"Fallback to first numeric", `<prtg><result></result></prtg>`, "Packet Loss"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/getobjectproperty.htm", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.property)
+			})
+			mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, channelList)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+			channel, err := ds.resolvePrimaryChannel(context.Background(), "1234")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if channel != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, channel)
+			}
+		})
+	}
+}
+
+// ✅ Metrics query with channel "primary"
+func TestQueryData_PrimaryChannel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/getobjectproperty.htm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<prtg><result>2|Ping Time</result></prtg>`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Ping Time": 12, "Packet Loss": 0}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"primary"}`),
+		TimeRange: histdataTimeRange,
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	valueField := resp.Frames[0].Fields[1]
+	if valueField.Name != "Ping Time" || valueField.At(0).(float64) != 12 {
+		t.Errorf("Expected the Ping Time channel, got %s=%v", valueField.Name, valueField.At(0))
+	}
+}
+
+// ✅ Metrics query without a channel: all channels, the primary channel or an error
+func TestQueryData_EmptyChannel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/getobjectproperty.htm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<prtg><result>2|Ping Time</result></prtg>`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Ping Time": 12, "Packet Loss": 0}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	request := func(policy string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":" ","emptyChannel":"` + policy + `","splitFrames":true}`),
+			TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+		})
+	}
+
+	resp := request("")
+	if resp.Error != nil || len(resp.Frames) != 2 {
+		t.Fatalf("Expected a frame per channel, got %d (%v)", len(resp.Frames), resp.Error)
+	}
+
+	resp = request("primary")
+	if resp.Error != nil || len(resp.Frames) != 1 {
+		t.Fatalf("Expected one frame, got %d (%v)", len(resp.Frames), resp.Error)
+	}
+	if name := resp.Frames[0].Fields[1].Name; name != "Ping Time" {
+		t.Errorf("Expected the primary channel Ping Time, got %s", name)
+	}
+	notices := resp.Frames[0].Meta.Notices
+	if len(notices) != 1 || !strings.Contains(notices[0].Text, "primary channel Ping Time") {
+		t.Errorf("Expected a notice naming the primary channel, got %+v", notices)
+	}
+
+	resp = request("error")
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "no channel selected") {
+		t.Errorf("Expected an error asking to select a channel, got %v", resp.Error)
+	}
+
+	if resp := request("first"); resp.Error == nil {
+		t.Errorf("Expected an error for an unknown policy")
+	}
+}
+
+// ✅ normalizeChannelColor test
+func TestNormalizeChannelColor(t *testing.T) {
+	tests := []struct {
+		color    string
+		expected string
+		ok       bool
+	}{
+		{"#FF0000", "#ff0000", true},
+		{" #00a0e6 ", "#00a0e6", true},
+		{"red", "", false},
+		{"#fff", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		color, ok := normalizeChannelColor(tt.color)
+		if color != tt.expected || ok != tt.ok {
+			t.Errorf("normalizeChannelColor(%q) = %q, %v; expected %q, %v", tt.color, color, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ Metrics query with PRTG channel colors
+func TestQueryData_ChannelColors(t *testing.T) {
+	var propertyRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"channels": [{"objid": 2, "name": "Ping Time"}, {"objid": 3, "name": "Packet Loss"}]}`)
+	})
+	mux.HandleFunc("/api/getobjectproperty.htm", func(w http.ResponseWriter, r *http.Request) {
+		propertyRequests++
+		q := r.URL.Query()
+		switch {
+		case q.Get("subid") == "2" && q.Get("name") == "colmode":
+			fmt.Fprint(w, `<prtg><result>1</result></prtg>`)
+		case q.Get("subid") == "2" && q.Get("name") == "color":
+			fmt.Fprint(w, `<prtg><result>#FF0000</result></prtg>`)
+		default:
+			// Packet Loss uses automatic coloring
+			fmt.Fprint(w, `<prtg><result>0</result></prtg>`)
+		}
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Ping Time": 12, "Packet Loss": 0}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channels":["Ping Time","Packet Loss"],"channelColors":true,"splitFrames":true}`),
+		TimeRange: backend.TimeRange{
+			From: time.Now().Add(-time.Hour),
+			To:   time.Now(),
+		},
+	}
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(resp.Frames))
+	}
+
+	color, ok := resp.Frames[0].Fields[1].Config.Color["fixedColor"]
+	if !ok || color != "#ff0000" {
+		t.Errorf("Expected the PRTG color for Ping Time, got %v", resp.Frames[0].Fields[1].Config.Color)
+	}
+	if resp.Frames[1].Fields[1].Config.Color != nil {
+		t.Errorf("Expected the automatic color for Packet Loss, got %v", resp.Frames[1].Fields[1].Config.Color)
+	}
+
+	// The channel properties are served from the response cache on refresh
+	requests := propertyRequests
+	if resp := ds.query(context.Background(), backend.PluginContext{}, query); resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if propertyRequests != requests {
+		t.Errorf("Expected no property requests on refresh, got %d", propertyRequests-requests)
+	}
+}
+
+func TestChannelUnit(t *testing.T) {
+	tests := map[string]string{
+		"":       "",
+		"ms":     "ms",
+		"Mbit/s": "Mbits",
+		"%":      "percent",
+		"°C":     "suffix:°C",
+	}
+	for unit, expected := range tests {
+		if got := channelUnit(unit); got != expected {
+			t.Errorf("channelUnit(%q) = %q, expected %q", unit, got, expected)
+		}
+	}
+}
+
+func TestQueryData_ChannelFormat(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"channels": [{"objid": 2, "name": "Ping Time"}, {"objid": 3, "name": "Packet Loss"}]}`)
+	})
+	mux.HandleFunc("/api/getobjectproperty.htm", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("subid") == "2" && q.Get("name") == "decimalmode":
+			fmt.Fprint(w, `<prtg><result>2</result></prtg>`)
+		case q.Get("subid") == "2" && q.Get("name") == "decimaldigits":
+			fmt.Fprint(w, `<prtg><result>3</result></prtg>`)
+		case q.Get("subid") == "2" && q.Get("name") == "customunit":
+			fmt.Fprint(w, `<prtg><result>ms</result></prtg>`)
+		case q.Get("name") == "decimalmode":
+			// Packet Loss uses automatic decimal places
+			fmt.Fprint(w, `<prtg><result>0</result></prtg>`)
+		default:
+			fmt.Fprint(w, `<prtg><result></result></prtg>`)
+		}
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Ping Time": 12.3456, "Packet Loss": 0}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channels":["Ping Time","Packet Loss"],"channelFormat":true,"splitFrames":true}`),
+		TimeRange: backend.TimeRange{
+			From: time.Now().Add(-time.Hour),
+			To:   time.Now(),
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(resp.Frames))
+	}
+
+	config := resp.Frames[0].Fields[1].Config
+	if config.Decimals == nil || *config.Decimals != 3 {
+		t.Errorf("Expected 3 decimals for Ping Time, got %v", config.Decimals)
+	}
+	if config.Unit != "ms" {
+		t.Errorf("Expected unit ms for Ping Time, got %q", config.Unit)
+	}
+
+	config = resp.Frames[1].Fields[1].Config
+	if config.Decimals != nil || config.Unit != "" {
+		t.Errorf("Expected Grafana defaults for Packet Loss, got decimals %v and unit %q", config.Decimals, config.Unit)
+	}
+}
@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// channelValue is the current value of a channel. value is nil if PRTG delivered no number.
+type channelValue struct {
+	name  string
+	value *float64
+	unit  string
+}
+
+// latestChannelRecord returns the newest record of a GetChannels response. The records are
+// delivered under "values" or "histdata" depending on the PRTG version; the newest one is
+// found by datetime_raw, without it the last record is used.
+func latestChannelRecord(channels PrtgChannelValueStruct) (map[string]interface{}, bool) {
+	var records []interface{}
+	for _, key := range []string{"values", "histdata"} {
+		if list, ok := channels[key].([]interface{}); ok {
+			records = list
+			break
+		}
+	}
+
+	var latest map[string]interface{}
+	var latestTime float64
+	for _, item := range records {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		datetime, hasTime := record["datetime_raw"].(float64)
+		if latest == nil || !hasTime || datetime >= latestTime {
+			latest = record
+			latestTime = datetime
+		}
+	}
+	return latest, latest != nil
+}
+
+// isChannelColumn tells whether a record key is a channel. The timestamp, the coverage and the
+// raw columns PRTG delivers next to captions are not.
+func isChannelColumn(key string) bool {
+	switch key {
+	case "datetime", "datetime_raw", "coverage", "coverage_raw":
+		return false
+	}
+	return !strings.HasSuffix(key, "(RAW)") && !strings.HasSuffix(key, "_raw")
+}
+
+// channelNames returns the names of all channels found in the records of a GetChannels
+// response, sorted and without duplicates. It is never nil.
+func channelNames(channels PrtgChannelValueStruct) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, key := range []string{"values", "histdata"} {
+		records, _ := channels[key].([]interface{})
+		for _, item := range records {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for name := range record {
+				if isChannelColumn(name) && !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// channelValues extracts one value per channel from a record, sorted by channel name. Captions
+// like "1,5 Mbit/s" are split into number and unit, the raw column is preferred as value.
+func channelValues(record map[string]interface{}) []channelValue {
+	names := make([]string, 0, len(record))
+	for key := range record {
+		if isChannelColumn(key) {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+
+	values := make([]channelValue, 0, len(names))
+	for _, name := range names {
+		item := channelValue{name: name}
+		switch v := record[name].(type) {
+		case float64:
+			item.value = &v
+		case string:
+			text := strings.TrimSpace(cleanMessageHTML(v))
+			if number, unit, ok := splitFormattedValue(text); ok {
+				item.value = &number
+				item.unit = unit
+			}
+			if raw, ok := channelRawValue(record, name); ok {
+				item.value = &raw
+			}
+		}
+		values = append(values, item)
+	}
+	return values
+}
+
+// handleChannelsQuery returns the current values of all channels of a sensor as a table with
+// one row per channel.
+func (d *Datasource) handleChannelsQuery(ctx context.Context, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	if qm.ObjectId == "" {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "invalid query: missing object ID")
+	}
+
+	channels, err := d.api.GetChannels(ctx, qm.ObjectId)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	var values []channelValue
+	if record, ok := latestChannelRecord(*channels); ok {
+		values = channelValues(record)
+	}
+
+	names := make([]string, len(values))
+	numbers := make([]*float64, len(values))
+	units := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.name
+		numbers[i] = v.value
+		units[i] = v.unit
+	}
+
+	frame := data.NewFrame("response",
+		data.NewField("Channel", nil, names),
+		data.NewField("Value", nil, numbers),
+		data.NewField("Unit", nil, units),
+	)
+	if len(values) == 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("No channel values found for sensor %s", qm.ObjectId),
+		})
+	}
+
+	response.Frames = append(response.Frames, frame)
+	return response
+}
@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ channelValues test: Captions are split, raw columns preferred and rows sorted
+func TestChannelValues(t *testing.T) {
+	record := map[string]interface{}{
+		"datetime":         "15.02.2025 12:00:00",
+		"datetime_raw":     45703.5,
+		"Traffic Out":      "1,5 Mbit/s",
+		"Traffic In":       "500 kbit/s",
+		"Traffic In(RAW)":  62500.0,
+		"Downtime":         0.0,
+		"Response":         "No data",
+		"coverage":         "100 %",
+		"coverage_raw":     10000.0,
+		"Traffic Out_raw":  187500.0,
+		"Traffic Out(RAW)": 187500.0,
+	}
+
+	values := channelValues(record)
+	expected := []struct {
+		name     string
+		value    float64
+		hasValue bool
+		unit     string
+	}{
+		{"Downtime", 0, true, ""},
+		{"Response", 0, false, ""},
+		{"Traffic In", 62500, true, "kbit/s"},
+		{"Traffic Out", 187500, true, "Mbit/s"},
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %d channels, got %+v", len(expected), values)
+	}
+	for i, e := range expected {
+		v := values[i]
+		if v.name != e.name || v.unit != e.unit || (v.value != nil) != e.hasValue ||
+			(v.value != nil && *v.value != e.value) {
+			t.Errorf("Row %d: expected %s %v (%v) %q, got %s %v %q", i, e.name, e.value, e.hasValue, e.unit, v.name, v.value, v.unit)
+		}
+	}
+}
+
+// ✅ latestChannelRecord test: The newest record is used
+func TestLatestChannelRecord(t *testing.T) {
+	channels := PrtgChannelValueStruct{"values": []interface{}{
+		map[string]interface{}{"datetime_raw": 2.0, "Load": 2.0},
+		map[string]interface{}{"datetime_raw": 3.0, "Load": 3.0},
+		map[string]interface{}{"datetime_raw": 1.0, "Load": 1.0},
+	}}
+	record, ok := latestChannelRecord(channels)
+	if !ok || record["Load"] != 3.0 {
+		t.Errorf("Expected the record with Load 3, got %v", record)
+	}
+
+	if _, ok := latestChannelRecord(PrtgChannelValueStruct{"prtg-version": "24.1"}); ok {
+		t.Errorf("Expected no record without values")
+	}
+}
+
+// ✅ channelNames test: Channels of all records, sorted and without duplicates
+func TestChannelNames(t *testing.T) {
+	channels := PrtgChannelValueStruct{"prtg-version": "24.1", "values": []interface{}{
+		map[string]interface{}{"datetime": "15.02.2025 12:00:00", "Traffic Out": "1 Mbit/s", "Traffic Out(RAW)": 125000.0},
+		map[string]interface{}{"datetime": "15.02.2025 12:01:00", "Traffic In": "2 Mbit/s", "Traffic Out": "1 Mbit/s", "coverage": "100 %"},
+	}}
+	if names := channelNames(channels); !reflect.DeepEqual(names, []string{"Traffic In", "Traffic Out"}) {
+		t.Errorf("Expected [Traffic In Traffic Out], got %v", names)
+	}
+
+	if names := channelNames(PrtgChannelValueStruct{}); names == nil || len(names) != 0 {
+		t.Errorf("Expected an empty list, got %#v", names)
+	}
+}
+
+// ✅ CallResource test: Channel names for the query editor
+func TestCallResourceChannelNames(t *testing.T) {
+	server, api := setupMockAPI(`{"values": [{"datetime": "15.02.2025 12:00:00", "Ping Time": "5 msec", "Downtime": 0}]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "channelnames/1234"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK || string(respSender.body) != `["Downtime","Ping Time"]` {
+		t.Errorf("Expected the sorted channel names, got %d %s", respSender.status, respSender.body)
+	}
+
+	for _, path := range []string{"channelnames", "channelnames/abc"} {
+		respSender := &mockResourceResponseSender{}
+		if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: path}, respSender); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+		if respSender.status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", path, respSender.status)
+		}
+	}
+}
+
+// ✅ Channels query: One row per channel
+func TestQueryData_Channels(t *testing.T) {
+	server, api := setupMockAPI(`{"prtg-version": "24.1", "treesize": 1, "values": [
+		{"datetime": "15.02.2025 12:00:00", "Traffic In": "500 kbit/s", "Traffic In(RAW)": 62500, "Downtime": 0}
+	]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"channels","objid":"1234"}`),
+		TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if len(frame.Fields) != 3 || frame.Fields[0].Len() != 2 {
+		t.Fatalf("Expected 3 fields with 2 rows, got %d fields", len(frame.Fields))
+	}
+	if frame.Fields[0].At(0) != "Downtime" || frame.Fields[0].At(1) != "Traffic In" {
+		t.Errorf("Unexpected channel order: %v, %v", frame.Fields[0].At(0), frame.Fields[0].At(1))
+	}
+	if v := frame.Fields[1].At(1).(*float64); v == nil || *v != 62500 {
+		t.Errorf("Expected 62500, got %v", v)
+	}
+	if frame.Fields[2].At(1) != "kbit/s" {
+		t.Errorf("Expected unit kbit/s, got %v", frame.Fields[2].At(1))
+	}
+
+	// A missing object id is rejected
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"channels"}`),
+	})
+	if resp.Error == nil {
+		t.Errorf("Expected an error without objid")
+	}
+}
@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultTableColumns are the built-in columns requested for the object lists.
+const defaultTableColumns = "active,channel,datetime,device,group,icon,message,objid,parentid,position,priority,sensor,status,tags,type"
+
+// allowedTableColumns is the whitelist of table.json columns a deployment may configure.
+// Only columns that are read-only and safe to request for any object are listed.
+var allowedTableColumns = map[string]struct{}{
+	"objid": {}, "type": {}, "name": {}, "tags": {}, "active": {}, "datetime": {},
+	"channel": {}, "icon": {}, "message": {}, "priority": {}, "status": {},
+	"group": {}, "device": {}, "sensor": {}, "probe": {}, "host": {}, "location": {},
+	"comments": {}, "notifiesx": {}, "parentid": {}, "basetype": {}, "baselink": {}, "favorite": {},
+	"position": {}, "interval": {}, "lastcheck": {}, "lastup": {}, "lastdown": {}, "lastvalue": {},
+	"downtime": {}, "downtimetime": {}, "downtimesince": {},
+	"uptime": {}, "uptimetime": {}, "uptimesince": {},
+	"upsens": {}, "downsens": {}, "downacksens": {}, "partialdownsens": {}, "warnsens": {},
+	"pausedsens": {}, "unusualsens": {}, "undefinedsens": {}, "totalsens": {},
+}
+
+// requiredTableColumns are always requested because the plugin itself relies on them.
+var requiredTableColumns = map[string][]string{
+	"groups":  {"objid", "datetime", "group", "status", "parentid", "position"},
+	"devices": {"objid", "datetime", "device", "status", "parentid", "position"},
+	"sensors": {"objid", "datetime", "sensor", "status", "priority", "type", "parentid", "position"},
+}
+
+// normalizeTableColumns validates configured columns against the whitelist and adds the
+// columns required for the content type. The result is sorted and comma separated.
+func normalizeTableColumns(content string, columns []string) (string, error) {
+	required, ok := requiredTableColumns[content]
+	if !ok {
+		return "", fmt.Errorf("unknown content type %q", content)
+	}
+
+	set := make(map[string]struct{})
+	for _, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" {
+			continue
+		}
+		if _, ok := allowedTableColumns[column]; !ok {
+			return "", fmt.Errorf("column %q is not allowed for %s", column, content)
+		}
+		set[column] = struct{}{}
+	}
+	for _, column := range required {
+		set[column] = struct{}{}
+	}
+
+	result := make([]string, 0, len(set))
+	for column := range set {
+		result = append(result, column)
+	}
+	sort.Strings(result)
+	return strings.Join(result, ","), nil
+}
+
+// tableColumns returns the columns requested for the given content type.
+func (a *Api) tableColumns(content string) string {
+	if columns, ok := a.defaultColumns[content]; ok {
+		return columns
+	}
+	return defaultTableColumns
+}
+
+// tableColumnsWith returns the columns of the content type with the given columns added, for
+// requests that rely on columns the configuration may not list. The result is sorted.
+func (a *Api) tableColumnsWith(content string, extra ...string) string {
+	set := make(map[string]struct{})
+	for _, column := range append(strings.Split(a.tableColumns(content), ","), extra...) {
+		if column = strings.TrimSpace(column); column != "" {
+			set[column] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for column := range set {
+		result = append(result, column)
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
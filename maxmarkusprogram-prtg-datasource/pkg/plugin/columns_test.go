@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ✅ normalizeTableColumns test: Whitelist and required columns
+func TestNormalizeTableColumns(t *testing.T) {
+	columns, err := normalizeTableColumns("devices", []string{"host", " Location ", "device"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if columns != "datetime,device,host,location,objid,parentid,position,status" {
+		t.Errorf("Unexpected columns: %s", columns)
+	}
+
+	if _, err := normalizeTableColumns("devices", []string{"host", "passhash"}); err == nil {
+		t.Errorf("Expected an error for a column outside the whitelist")
+	}
+	if _, err := normalizeTableColumns("probes", []string{"host"}); err == nil {
+		t.Errorf("Expected an error for an unknown content type")
+	}
+}
+
+// ✅ Settings-driven default columns are used for the object lists
+func TestSetDefaultColumns(t *testing.T) {
+	var columns string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		columns = r.URL.Query().Get("columns")
+		w.Write([]byte(`{"devices": [], "sensors": []}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	if err := api.SetDefaultColumns(map[string][]string{"devices": {"host", "location"}}); err != nil {
+		t.Fatalf("SetDefaultColumns failed: %v", err)
+	}
+
+	if _, err := api.GetDevices(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetDevices() failed: %v", err)
+	}
+	if columns != "datetime,device,host,location,objid,parentid,position,status" {
+		t.Errorf("Expected the configured device columns, got %s", columns)
+	}
+
+	// Content types without configuration keep the built-in columns
+	if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetSensors() failed: %v", err)
+	}
+	if columns != defaultTableColumns {
+		t.Errorf("Expected the built-in sensor columns, got %s", columns)
+	}
+
+	// Alarms use the configured sensor columns and add the alarm columns
+	if err := api.SetDefaultColumns(map[string][]string{"sensors": {"host"}}); err != nil {
+		t.Fatalf("SetDefaultColumns failed: %v", err)
+	}
+	if _, err := api.GetAlarms(context.Background()); err != nil {
+		t.Fatalf("GetAlarms() failed: %v", err)
+	}
+	if columns != "datetime,device,group,host,message,objid,parentid,position,priority,sensor,status,type" {
+		t.Errorf("Expected the configured sensor columns with the alarm columns, got %s", columns)
+	}
+
+	// Sensor values use the configured sensor columns and add the last value
+	if err := api.SetDefaultColumns(map[string][]string{"sensors": {"host"}}); err != nil {
+		t.Fatalf("SetDefaultColumns failed: %v", err)
+	}
+	if _, err := api.GetSensorValues(context.Background()); err != nil {
+		t.Fatalf("GetSensorValues() failed: %v", err)
+	}
+	if columns != "datetime,device,group,host,lastvalue,objid,parentid,position,priority,sensor,status,type" {
+		t.Errorf("Expected the configured sensor columns with lastvalue, got %s", columns)
+	}
+
+	// Invalid entries fall back to the built-in columns, valid ones still apply
+	err := api.SetDefaultColumns(map[string][]string{"sensors": {"apitoken"}, "devices": {"host"}})
+	if err == nil || !strings.Contains(err.Error(), "apitoken") {
+		t.Errorf("Expected a whitelist error, got %v", err)
+	}
+	api.ClearCache()
+	if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetSensors() failed: %v", err)
+	}
+	if columns != defaultTableColumns {
+		t.Errorf("Expected the built-in sensor columns after an invalid entry, got %s", columns)
+	}
+	if _, err := api.GetDevices(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetDevices() failed: %v", err)
+	}
+	if columns != "datetime,device,host,objid,parentid,position,status" {
+		t.Errorf("Expected the valid device columns, got %s", columns)
+	}
+}
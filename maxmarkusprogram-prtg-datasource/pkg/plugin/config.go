@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"net/url"
+	"sort"
+)
+
+// effectiveConfig returns the configuration the datasource actually uses, for support and
+// diagnosis. It must never contain secrets: the API token is not part of it and the URL
+// is redacted.
+func (d *Datasource) effectiveConfig() map[string]interface{} {
+	config := map[string]interface{}{
+		"baseURL":             redactURL(d.baseURL),
+		"unknownStatusPolicy": normalizeUnknownStatusPolicy(d.unknownStatusPolicy),
+		"nonNumericPolicy":    normalizeNonNumericPolicy(d.nonNumericPolicy),
+		"unitTagPrefix":       d.unitTagPrefix,
+	}
+	if u, err := url.Parse(d.baseURL); err == nil {
+		config["host"] = u.Host
+	}
+
+	// Without a configured timezone it is derived from the PRTG clock on the first query
+	timezone, timezoneSource := "", timezoneSourcePRTG
+	if d.timezone != nil {
+		timezone, timezoneSource = d.timezone.String(), timezoneSourceConfigured
+	}
+	config["timezone"] = timezone
+	config["timezoneSource"] = timezoneSource
+
+	if d.treeCache != nil {
+		config["treeCacheTime"] = d.treeCache.ttl.String()
+	}
+
+	if a := d.api; a != nil {
+		columns := make(map[string]string, len(a.defaultColumns))
+		for content, list := range a.defaultColumns {
+			columns[content] = list
+		}
+		hosts := append([]string(nil), a.trustedRedirectHosts...)
+		sort.Strings(hosts)
+
+		config["timeout"] = a.timeout.String()
+		endpointTimeouts := map[string]string{}
+		for endpoint, timeout := range a.endpointTimeouts {
+			endpointTimeouts[endpoint] = timeout.String()
+		}
+		config["endpointTimeouts"] = endpointTimeouts
+		config["cacheTime"] = a.cacheTime.String()
+		config["attemptTimeout"] = a.attemptTimeout.String()
+		config["retryAttempts"] = a.maxAttempts
+		config["retryDelay"] = a.backoffBase().String()
+		config["minAvgInterval"] = a.minAvgInterval
+		config["historicMaxPoints"] = a.historicMaxPoints
+		config["defaultColumns"] = columns
+		config["trustedRedirectHosts"] = hosts
+		failoverURLs := make([]string, 0, len(a.failoverURLs))
+		for _, node := range a.failoverURLs {
+			failoverURLs = append(failoverURLs, redactURL(node))
+		}
+		config["failoverURLs"] = failoverURLs
+		config["activeURL"] = redactURL(a.ActiveBaseURL())
+		config["dryRun"] = a.dryRun
+		// "environment" stands for the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables
+		proxy := "environment"
+		if a.proxyURL != nil {
+			proxy = redactURL(a.proxyURL.String())
+		}
+		config["proxy"] = proxy
+		// Certificate verification is disabled for all requests, see NewApi
+		config["tlsMode"] = "insecureSkipVerify"
+	}
+	return config
+}
@@ -3,14 +3,20 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/maxmarkusprogram/prtg/pkg/models"
 )
 
@@ -28,7 +34,10 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 	if err != nil {
 		return nil, err
 	}
-	baseURL := fmt.Sprintf("https://%s", config.Path)
+	baseURL, err := normalizeBaseURL(config.Path, config.Scheme)
+	if err != nil {
+		return nil, err
+	}
 	backend.Logger.Info("Base URL", "url", baseURL)
 
 	// If cache time is not defined, default to 30 seconds
@@ -37,9 +46,79 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 		cacheTime = 30 * time.Second
 	}
 
+	// If the request timeout is not defined, default to 10 seconds
+	if config.RequestTimeout < 0 {
+		return nil, fmt.Errorf("invalid request timeout %d, expected a positive number of seconds", config.RequestTimeout)
+	}
+	requestTimeout := defaultRequestTimeout
+	if config.RequestTimeout > 0 {
+		requestTimeout = time.Duration(config.RequestTimeout) * time.Second
+	}
+
+	api := NewApi(baseURL, config.Secrets.ApiKey, cacheTime, requestTimeout)
+	api.SetTrustedRedirectHosts(config.TrustedRedirectHosts)
+	// Failover paths without scheme use the scheme of the primary node
+	failoverScheme := config.Scheme
+	if failoverScheme == "" {
+		failoverScheme = baseURL[:strings.Index(baseURL, "://")]
+	}
+	failoverURLs := make([]string, 0, len(config.FailoverPaths))
+	for _, path := range config.FailoverPaths {
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+		scheme := config.Scheme
+		if scheme == "" && !strings.Contains(path, "://") {
+			scheme = failoverScheme
+		}
+		failoverURL, err := normalizeBaseURL(path, scheme)
+		if err != nil {
+			return nil, fmt.Errorf("invalid failover path: %w", err)
+		}
+		failoverURLs = append(failoverURLs, failoverURL)
+	}
+	api.SetFailoverURLs(failoverURLs)
+	api.SetRetries(config.RetryAttempts, time.Duration(config.AttemptTimeout)*time.Second)
+	api.SetRetryDelay(time.Duration(config.RetryDelay) * time.Millisecond)
+	api.SetDryRun(config.DryRun)
+	if err := api.SetDefaultColumns(config.DefaultColumns); err != nil {
+		backend.Logger.Warn("Invalid default columns setting, using the built-in columns", "error", err)
+	}
+	if err := api.SetProxyURL(config.ProxyURL); err != nil {
+		return nil, err
+	}
+	api.SetTruncationWarnInterval(time.Duration(config.TruncationWarnInterval) * time.Second)
+	api.SetMinAvgInterval(config.MinAvgInterval)
+	api.SetHistoricMaxPoints(config.HistoricMaxPoints)
+	api.SetEndpointTimeout("status.json", time.Duration(config.StatusTimeout)*time.Second)
+	api.SetEndpointTimeout("table.json", time.Duration(config.TableTimeout)*time.Second)
+	api.SetEndpointTimeout("historicdata.json", time.Duration(config.HistoricTimeout)*time.Second)
+
+	// The object tree changes slowly and is cached separately from metric data
+	treeCacheTime := time.Duration(config.TreeCacheTime) * time.Second
+	if treeCacheTime <= 0 {
+		treeCacheTime = defaultTreeCacheTime
+	}
+
+	// An invalid timezone is not fatal, the PRTG clock is used instead
+	var timezone *time.Location
+	if config.Timezone != "" {
+		timezone, err = time.LoadLocation(config.Timezone)
+		if err != nil {
+			backend.Logger.Warn("Invalid timezone setting, ignoring it", "timezone", config.Timezone, "error", err)
+			timezone = nil
+		}
+	}
+
 	return &Datasource{
-		baseURL: baseURL,
-		api:     NewApi(baseURL, config.Secrets.ApiKey, cacheTime, 10*time.Second),
+		baseURL:             baseURL,
+		api:                 api,
+		unknownStatusPolicy: normalizeUnknownStatusPolicy(config.UnknownStatusPolicy),
+		treeCache:           newTTLCache(treeCacheTime),
+		timezone:            timezone,
+		unitTagPrefix:       config.UnitTagPrefix,
+		nonNumericPolicy:    normalizeNonNumericPolicy(config.NonNumericValuePolicy),
+		neverPolledPolicy:   normalizeNeverPolledPolicy(config.NeverPolledPolicy),
 	}, nil
 }
 
@@ -47,21 +126,50 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 func (d *Datasource) Dispose() {
 }
 
+// queryConcurrency bounds the queries of a QueryData request that run at the same time.
+const queryConcurrency = 8
+
 // QueryData processes incoming queries and returns the results.
 func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	response := backend.NewQueryDataResponse()
 
-	// Call the query method for each query.
-	for _, q := range req.Queries {
-		res := d.query(ctx, req.PluginContext, q)
-		response.Responses[q.RefID] = res
+	// The queries run concurrently, so the panels of a dashboard do not wait for each other
+	responses := make([]backend.DataResponse, len(req.Queries))
+	sem := make(chan struct{}, queryConcurrency)
+	var wg sync.WaitGroup
+	for i, q := range req.Queries {
+		wg.Add(1)
+		go func(i int, q backend.DataQuery) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			// A panicking query must not take down the others
+			defer func() {
+				if r := recover(); r != nil {
+					backend.Logger.Error("Query panicked", "refId", q.RefID, "panic", r)
+					responses[i] = backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("query failed: %v", r))
+				}
+			}()
+			responses[i] = d.query(ctx, req.PluginContext, q)
+		}(i, q)
+	}
+	wg.Wait()
+	for i, q := range req.Queries {
+		response.Responses[q.RefID] = responses[i]
+	}
+
+	// Series of different queries that requested unit normalization share a common unit
+	var frames []*data.Frame
+	for _, res := range response.Responses {
+		frames = append(frames, res.Frames...)
 	}
+	normalizeUnits(frames)
 
 	return response, nil
 }
 
-// parsePRTGDateTime parses PRTG datetime strings in various formats.
-func parsePRTGDateTime(datetime string) (time.Time, string, error) {
+// parsePRTGDateTimeIn parses PRTG datetime strings, timestamps without zone are read in loc.
+func parsePRTGDateTimeIn(datetime string, loc *time.Location) (time.Time, string, error) {
 	// Try different known PRTG date formats
 	layouts := []string{
 		"02.01.2006 15:04:05",
@@ -70,7 +178,7 @@ func parsePRTGDateTime(datetime string) (time.Time, string, error) {
 
 	var parseErr error
 	for _, layout := range layouts {
-		parsedTime, err := time.Parse(layout, datetime)
+		parsedTime, err := time.ParseInLocation(layout, datetime, loc)
 		if err == nil {
 			unixTime := parsedTime.Unix()
 			return parsedTime, strconv.FormatInt(unixTime, 10), nil
@@ -84,6 +192,63 @@ func parsePRTGDateTime(datetime string) (time.Time, string, error) {
 	return time.Time{}, "", fmt.Errorf("failed to parse time '%s': %w", datetime, parseErr)
 }
 
+// prtgEpoch is day zero of PRTG float dates (OLE automation dates).
+var prtgEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// prtgFloatTime converts a PRTG float date, the days since 1899-12-30 with the time of day as
+// fraction, to a time. Like the formatted datetime it is server local time and read in loc.
+// ok is false for missing dates (<= 0).
+func prtgFloatTime(raw float64, loc *time.Location) (time.Time, bool) {
+	if raw <= 0 || math.IsNaN(raw) || math.IsInf(raw, 0) {
+		return time.Time{}, false
+	}
+	wall := prtgEpoch.Add(time.Duration(math.Round(raw*86400000)) * time.Millisecond)
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc), true
+}
+
+// Positions of the timestamp of an averaged point whose datetime is a range.
+const (
+	rangeTimestampStart  = "start"
+	rangeTimestampMiddle = "middle"
+	rangeTimestampEnd    = "end"
+)
+
+// parsePRTGDateTimeRange parses a datetime that may be a range of an averaged point, e.g.
+// "14.02.2025 13:00:00 - 14.02.2025 14:00:00" or "14.02.2025 13:00:00 - 13:15:00". The
+// timestamp is taken from the start (default), middle or end of the range.
+func parsePRTGDateTimeRange(datetime string, loc *time.Location, position string) (time.Time, error) {
+	startText, endText, isRange := strings.Cut(datetime, " - ")
+	start, _, err := parsePRTGDateTimeIn(strings.TrimSpace(startText), loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !isRange || position == "" || position == rangeTimestampStart {
+		return start, nil
+	}
+
+	endText = strings.TrimSpace(endText)
+	end, _, err := parsePRTGDateTimeIn(endText, loc)
+	if err != nil {
+		// The end may only contain the time of day
+		clock, clockErr := time.ParseInLocation("15:04:05", endText, loc)
+		if clockErr != nil {
+			return time.Time{}, err
+		}
+		end = time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, start.Location())
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+
+	switch position {
+	case rangeTimestampMiddle:
+		return start.Add(end.Sub(start) / 2), nil
+	case rangeTimestampEnd:
+		return end, nil
+	}
+	return start, nil
+}
+
 
 // CheckHealth checks the plugin configuration.
 func (d *Datasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
@@ -104,31 +269,128 @@ func (d *Datasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRe
 		return res, nil
 	}
 
-	// Get PRTG status including version
-	status, err := d.api.GetStatusList()
+	// Get PRTG status including version, bypassing the cache so the check reflects PRTG right now
+	status, err := d.api.GetLiveStatusList(ctx)
 	if err != nil {
 		res.Status = backend.HealthStatusError
-		res.Message = fmt.Sprintf("Failed to get PRTG status: %v", err)
+		// The redacted URL helps to spot a wrong host or sub path
+		res.Message = fmt.Sprintf("Failed to get PRTG status from %s: %s",
+			d.api.redactedApiUrl("status.json", nil), d.api.redactSecrets(err.Error()))
 		return res, nil
 	}
 
 	// Return success with version information
 	res.Status = backend.HealthStatusOk
 	res.Message = fmt.Sprintf("Data source is working. PRTG Version: %s", status.Version)
+
+	// Sensor count and token permissions are informational only, like a pending update
+	maxSensors := strings.TrimSpace(status.MaxSensorCount)
+	if maxSensors != "" {
+		res.Message += fmt.Sprintf(". Sensors: %d of %s", status.TotalSens, maxSensors)
+	} else {
+		res.Message += fmt.Sprintf(". Sensors: %d", status.TotalSens)
+	}
+	// The serving node matters for clusters, the request may have failed over
+	node := redactURL(d.api.ActiveBaseURL())
+	if u, err := url.Parse(node); err == nil && u.Host != "" {
+		node = u.Host
+	}
+	clusterNode := strings.TrimSpace(status.ClusterNodeName)
+	if len(d.api.failoverURLs) > 0 || clusterNode != "" {
+		res.Message += fmt.Sprintf(". Serving node: %s", node)
+		if clusterNode != "" {
+			res.Message += fmt.Sprintf(" (%s)", clusterNode)
+		}
+	}
+	readOnly := strings.EqualFold(strings.TrimSpace(status.ReadOnlyUser), "true")
+	if readOnly {
+		res.Message += ". The API token is read-only"
+	}
+	if status.PRTGUpdateAvailable {
+		res.Message += ". A PRTG update is available"
+	}
+	res.JSONDetails, _ = json.Marshal(map[string]interface{}{
+		"version":         status.Version,
+		"readOnlyUser":    readOnly,
+		"totalSensors":    status.TotalSens,
+		"maxSensorCount":  maxSensors,
+		"updateAvailable": status.PRTGUpdateAvailable,
+		"node":            node,
+		"clusterNodeName": clusterNode,
+	})
 	return res, nil
 }
 
+// resourceRoutes lists the routes served by CallResource.
+var resourceRoutes = []string{
+	"groups",
+	"devices",
+	"sensors",
+	"channels/{objid}",
+	"channels?ids={objid},{objid}",
+	"channelnames/{objid}",
+	"alarms",
+	"alarmcounts",
+	"reachability/{objid}?type={type}&name={name}",
+	"backgroundtasks",
+	"tree",
+	"ping",
+	"cache/clear",
+	"cachestats",
+	"config",
+	"resolvepath?path={path}",
+}
+
 // CallResource routes requests to the appropriate handlers based on the URL path.
 func (d *Datasource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if strings.Trim(req.Path, "/ \t") == "" {
+		return d.handleEmptyPath(sender)
+	}
+
 	pathParts := strings.Split(req.Path, "/")
 	switch pathParts[0] {
-	case "groups":
-		return d.handleGetGroups(sender)
-	case "devices":
-		return d.handleGetDevices(sender)
-	case "sensors":
-		return d.handleGetSensors(sender)
+	case "groups", "devices", "sensors":
+		since, err := parseSinceToken(req.URL, pathParts[0])
+		var order string
+		if err == nil {
+			order, err = parseListSort(req.URL)
+		}
+		var tags []string
+		if err == nil {
+			tags, err = parseListTags(req.URL)
+		}
+		if err != nil {
+			errorResponse := map[string]string{"error": err.Error()}
+			errorJSON, _ := json.Marshal(errorResponse)
+			return sender.Send(&backend.CallResourceResponse{
+				Status:  http.StatusBadRequest,
+				Headers: map[string][]string{"Content-Type": {"application/json"}},
+				Body:    errorJSON,
+			})
+		}
+		switch pathParts[0] {
+		case "groups":
+			return d.handleGetGroups(ctx, sender, order, tags)
+		case "devices":
+			return d.handleGetDevices(ctx, sender, order, tags)
+		default:
+			return d.handleGetSensors(ctx, sender, since, order, tags)
+		}
+	case "resolvepath":
+		return d.handleResolvePath(ctx, sender, req.URL)
+	case "config":
+		return d.handleGetConfig(sender)
+	case "cache":
+		if len(pathParts) < 2 || pathParts[1] != "clear" {
+			return sender.Send(&backend.CallResourceResponse{Status: http.StatusNotFound})
+		}
+		return d.handleClearCache(sender)
+	case "cachestats":
+		return d.handleCacheStats(sender)
 	case "channels":
+		if ids := parseObjectIds(req.URL); len(pathParts) < 2 && len(ids) > 0 {
+			return d.handleGetChannelsBulk(ctx, sender, ids)
+		}
 		if len(pathParts) < 2 {
 			errorResponse := map[string]string{"error": "missing objid parameter"}
 			errorJSON, _ := json.Marshal(errorResponse)
@@ -138,20 +400,102 @@ func (d *Datasource) CallResource(ctx context.Context, req *backend.CallResource
 				Body:    errorJSON,
 			})
 		}
-		return d.handleGetChannel(sender, pathParts[1])
+		return d.handleGetChannel(ctx, sender, pathParts[1])
+	case "alarms":
+		return d.handleGetAlarms(ctx, sender)
+	case "alarmcounts":
+		return d.handleGetAlarmCounts(ctx, sender)
+	case "reachability":
+		objid := ""
+		if len(pathParts) > 1 {
+			objid = pathParts[1]
+		}
+		return d.handleGetReachability(ctx, sender, objid, req.URL)
+	case "backgroundtasks":
+		return d.handleGetBackgroundTasks(ctx, sender)
+	case "tree":
+		return d.handleGetTree(ctx, sender)
+	case "ping":
+		return d.handlePing(ctx, sender)
+	case "channelnames":
+		objid := ""
+		if len(pathParts) > 1 {
+			objid = pathParts[1]
+		}
+		return d.handleGetChannelNames(ctx, sender, objid)
 	default:
 		return sender.Send(&backend.CallResourceResponse{Status: http.StatusNotFound})
 	}
 }
 
-func (d *Datasource) handleGetGroups(sender backend.CallResourceResponseSender) error {
-	groups, err := d.api.GetGroups()
+// handleEmptyPath answers requests without a route with a list of the available routes.
+func (d *Datasource) handleEmptyPath(sender backend.CallResourceResponseSender) error {
+	errorResponse := map[string]interface{}{
+		"error":  "missing resource path, use one of the available routes",
+		"routes": resourceRoutes,
+	}
+	errorJSON, _ := json.Marshal(errorResponse)
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusBadRequest,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    errorJSON,
+	})
+}
+
+// parseSinceToken reads the optional "since" query parameter of the sensors route.
+// A token of zero, or no token at all, requests the full list. Only sensors carry the time
+// of their last change in datetime_raw, so the token is rejected for groups and devices.
+func parseSinceToken(rawURL string, content string) (float64, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid request URL: %w", err)
+	}
+	token := u.Query().Get("since")
+	if token == "" {
+		return 0, nil
+	}
+	if content != "sensors" {
+		return 0, fmt.Errorf("since token is only supported for sensors, %s have no change time", content)
+	}
+	since, err := strconv.ParseFloat(token, 64)
+	if err != nil || since < 0 {
+		return 0, fmt.Errorf("invalid since token %q", token)
+	}
+	return since, nil
+}
+
+// changedSince returns the items whose datetime_raw is newer than since, together with the
+// token for the next incremental request. With since zero all items are returned.
+func changedSince[T any](items []T, since float64, datetimeRaw func(T) float64) ([]T, string) {
+	latest := since
+	changed := make([]T, 0, len(items))
+	for _, item := range items {
+		raw := datetimeRaw(item)
+		if raw > latest {
+			latest = raw
+		}
+		if since <= 0 || raw > since {
+			changed = append(changed, item)
+		}
+	}
+	return changed, strconv.FormatFloat(latest, 'f', -1, 64)
+}
+
+func (d *Datasource) handleGetGroups(ctx context.Context, sender backend.CallResourceResponseSender, order string, tags []string) error {
+	groups, err := treeList(ctx, d.treeCache, "groups", tags, d.api.GetGroups)
 	if err != nil {
 		return sender.Send(&backend.CallResourceResponse{
 			Status: http.StatusInternalServerError,
 			Body:   []byte(err.Error()),
 		})
 	}
+	if order == listSortPosition {
+		// The list shares its slice with the tree cache and is sorted on a copy
+		groups.Groups = slices.Clone(groups.Groups)
+		sortByPosition(groups.Groups,
+			func(g PrtgGroupListItemStruct) int64 { return g.ParentId },
+			func(g PrtgGroupListItemStruct) int64 { return g.PositionRAW })
+	}
 	body, err := json.Marshal(groups)
 	if err != nil {
 		return sender.Send(&backend.CallResourceResponse{
@@ -166,14 +510,20 @@ func (d *Datasource) handleGetGroups(sender backend.CallResourceResponseSender)
 	})
 }
 
-func (d *Datasource) handleGetDevices(sender backend.CallResourceResponseSender) error {
-	devices, err := d.api.GetDevices()
+func (d *Datasource) handleGetDevices(ctx context.Context, sender backend.CallResourceResponseSender, order string, tags []string) error {
+	devices, err := treeList(ctx, d.treeCache, "devices", tags, d.api.GetDevices)
 	if err != nil {
 		return sender.Send(&backend.CallResourceResponse{
 			Status: http.StatusInternalServerError,
 			Body:   []byte(err.Error()),
 		})
 	}
+	if order == listSortPosition {
+		devices.Devices = slices.Clone(devices.Devices)
+		sortByPosition(devices.Devices,
+			func(dev PrtgDeviceListItemStruct) int64 { return dev.ParentId },
+			func(dev PrtgDeviceListItemStruct) int64 { return dev.PositionRAW })
+	}
 	body, err := json.Marshal(devices)
 	if err != nil {
 		return sender.Send(&backend.CallResourceResponse{
@@ -188,14 +538,24 @@ func (d *Datasource) handleGetDevices(sender backend.CallResourceResponseSender)
 	})
 }
 
-func (d *Datasource) handleGetSensors(sender backend.CallResourceResponseSender) error {
-	sensors, err := d.api.GetSensors()
+// handleGetSensors returns the sensor list. With a since token only the sensors changed
+// after it are returned together with the next token. The filter runs on the cached full
+// list, so it saves the transfer to the browser but not the PRTG request once the tree
+// cache has expired.
+func (d *Datasource) handleGetSensors(ctx context.Context, sender backend.CallResourceResponseSender, since float64, order string, tags []string) error {
+	sensors, err := treeList(ctx, d.treeCache, "sensors", tags, d.api.GetSensors)
 	if err != nil {
 		return sender.Send(&backend.CallResourceResponse{
 			Status: http.StatusInternalServerError,
 			Body:   []byte(err.Error()),
 		})
 	}
+	sensors.Sensors, sensors.SinceToken = changedSince(sensors.Sensors, since, func(s PrtgSensorListItemStruct) float64 { return s.DatetimeRAW })
+	if order == listSortPosition {
+		sortByPosition(sensors.Sensors,
+			func(s PrtgSensorListItemStruct) int64 { return s.ParentId },
+			func(s PrtgSensorListItemStruct) int64 { return s.PositionRAW })
+	}
 	body, err := json.Marshal(sensors)
 	if err != nil {
 		return sender.Send(&backend.CallResourceResponse{
@@ -210,7 +570,392 @@ func (d *Datasource) handleGetSensors(sender backend.CallResourceResponseSender)
 	})
 }
 
-func (d *Datasource) handleGetChannel(sender backend.CallResourceResponseSender, objid string) error {
+// handleGetConfig returns the effective, redacted configuration of the datasource.
+func (d *Datasource) handleGetConfig(sender backend.CallResourceResponseSender) error {
+	body, err := json.Marshal(d.effectiveConfig())
+	if err != nil {
+		return sender.Send(&backend.CallResourceResponse{
+			Status: http.StatusInternalServerError,
+			Body:   []byte(fmt.Sprintf("error marshaling config: %v", err)),
+		})
+	}
+	// Defense in depth, the token must not appear even if a setting contains it
+	if d.api != nil {
+		body = []byte(d.api.redactSecrets(string(body)))
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleClearCache invalidates the object tree cache and the API response cache.
+func (d *Datasource) handleClearCache(sender backend.CallResourceResponseSender) error {
+	d.treeCache.clear()
+	if d.api != nil {
+		d.api.ClearCache()
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    []byte(`{"status":"cleared"}`),
+	})
+}
+
+// handleCacheStats returns the hit, miss and eviction counts of the object tree cache and the
+// API response cache per endpoint.
+func (d *Datasource) handleCacheStats(sender backend.CallResourceResponseSender) error {
+	response := struct {
+		Tree      map[string]cacheStats `json:"tree"`
+		Responses map[string]cacheStats `json:"responses"`
+	}{
+		Tree:      d.treeCache.stats(),
+		Responses: map[string]cacheStats{},
+	}
+	if d.api != nil {
+		response.Responses = d.api.CacheStats()
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("error marshaling cache stats: %v", err)})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleResolvePath returns the objid of the object with the given "Group/Device/Sensor" path.
+func (d *Datasource) handleResolvePath(ctx context.Context, sender backend.CallResourceResponseSender, rawURL string) error {
+	var path string
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Query().Get("path")
+	}
+
+	if _, err := splitObjectPath(path); err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusBadRequest,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	status := http.StatusOK
+	var response interface{}
+	objid, err := d.resolveObjectPath(ctx, path)
+	var ambiguous *ambiguousPathError
+	switch {
+	case err == nil:
+		response = map[string]interface{}{"objid": objid, "path": path}
+	case errors.As(err, &ambiguous):
+		status = http.StatusConflict
+		response = map[string]interface{}{"error": err.Error(), "candidates": ambiguous.Candidates}
+	case errors.Is(err, errPathNotFound):
+		status = http.StatusNotFound
+		response = map[string]string{"error": fmt.Sprintf("%v: %s", err, path)}
+	default:
+		status = http.StatusInternalServerError
+		response = map[string]string{"error": err.Error()}
+	}
+
+	body, _ := json.Marshal(response)
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleGetAlarms returns the current alarms, the sensors in a down, warning or unusual state.
+func (d *Datasource) handleGetAlarms(ctx context.Context, sender backend.CallResourceResponseSender) error {
+	alarms, err := d.api.GetAlarms(ctx)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	body, _ := json.Marshal(alarms)
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleGetAlarmCounts returns the number of current alarms per priority, including the
+// priorities without alarms.
+func (d *Datasource) handleGetAlarmCounts(ctx context.Context, sender backend.CallResourceResponseSender) error {
+	alarms, err := d.api.GetAlarms(ctx)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	priorities, counts, skipped := alarmCountsByPriority(alarms.Alarms)
+	type priorityCount struct {
+		Priority int64 `json:"priority"`
+		Count    int64 `json:"count"`
+	}
+	result := make([]priorityCount, len(priorities))
+	for i := range priorities {
+		result[i] = priorityCount{Priority: priorities[i], Count: counts[i]}
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"counts":  result,
+		"skipped": skipped,
+	})
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleGetReachability returns whether a device answers its ping sensor. The optional
+// "type" and "name" parameters override how the ping sensor is identified. Devices without
+// a ping sensor get "found": false and no reachable value.
+func (d *Datasource) handleGetReachability(ctx context.Context, sender backend.CallResourceResponseSender, objid string, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err == nil {
+		_, err = strconv.ParseInt(objid, 10, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid objid %q", objid)
+		}
+	}
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusBadRequest,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	sensors, err := d.api.GetDeviceSensors(ctx, objid)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	result := map[string]interface{}{"objid": objid, "found": false, "reachable": nil}
+	params := u.Query()
+	if ping, found := findPingSensor(sensors.Sensors, params.Get("type"), params.Get("name")); found {
+		result["found"] = true
+		result["sensor"] = ping.Sensor
+		result["status"] = cleanMessageHTML(ping.Status)
+		result["reachable"] = reachability(ping.StatusRAW)
+	}
+
+	body, _ := json.Marshal(result)
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleGetBackgroundTasks returns the task counters of the PRTG core server.
+func (d *Datasource) handleGetBackgroundTasks(ctx context.Context, sender backend.CallResourceResponseSender) error {
+	status, err := d.api.GetStatusList(ctx)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	body, _ := json.Marshal(parseBackgroundTasks(status))
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleGetTree returns the groups with their devices and sensors as nested JSON for the
+// cascading pickers of the query editor.
+func (d *Datasource) handleGetTree(ctx context.Context, sender backend.CallResourceResponseSender) error {
+	tree, err := d.sensorTree(ctx)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	body, _ := json.Marshal(tree)
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handlePing is a lightweight liveness probe: unlike CheckHealth it only verifies that PRTG
+// answers and accepts the API token, and returns the latency in milliseconds.
+func (d *Datasource) handlePing(ctx context.Context, sender backend.CallResourceResponseSender) error {
+	latency, err := d.api.Ping(ctx)
+	latencyMs := float64(latency.Microseconds()) / 1000
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]interface{}{
+			"error":     d.api.redactSecrets(err.Error()),
+			"latencyMs": latencyMs,
+		})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"status":    "ok",
+		"latencyMs": latencyMs,
+	})
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// handleGetChannelNames returns the sorted channel names of a sensor for the channel picker
+// of the query editor.
+func (d *Datasource) handleGetChannelNames(ctx context.Context, sender backend.CallResourceResponseSender, objid string) error {
+	if _, err := strconv.ParseInt(objid, 10, 64); err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid objid %q", objid)})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusBadRequest,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+	channels, err := d.api.GetChannels(ctx, objid)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+
+	body, _ := json.Marshal(channelNames(*channels))
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// bulkChannelConcurrency bounds the concurrent channel requests of the bulk channels route.
+const bulkChannelConcurrency = 4
+
+// parseObjectIds reads the comma separated "ids" query parameter. Empty and duplicate ids are skipped.
+func parseObjectIds(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, id := range strings.Split(u.Query().Get("ids"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// handleGetChannelsBulk fetches the channels of several sensors concurrently. The result is
+// keyed by objid, a failing sensor is reported in "errors" without failing the others.
+func (d *Datasource) handleGetChannelsBulk(ctx context.Context, sender backend.CallResourceResponseSender, ids []string) error {
+	channels := make([]*PrtgChannelValueStruct, len(ids))
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, bulkChannelConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+				errs[i] = fmt.Errorf("invalid objid %q", id)
+				return
+			}
+			channels[i], errs[i] = d.api.GetChannels(ctx, id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	response := struct {
+		Channels map[string]*PrtgChannelValueStruct `json:"channels"`
+		Errors   map[string]string                  `json:"errors,omitempty"`
+	}{
+		Channels: make(map[string]*PrtgChannelValueStruct, len(ids)),
+	}
+	for i, id := range ids {
+		if errs[i] != nil {
+			if response.Errors == nil {
+				response.Errors = make(map[string]string)
+			}
+			response.Errors[id] = errs[i].Error()
+			continue
+		}
+		response.Channels[id] = channels[i]
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("error marshaling channels: %v", err)})
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusInternalServerError,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    errorJSON,
+		})
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+func (d *Datasource) handleGetChannel(ctx context.Context, sender backend.CallResourceResponseSender, objid string) error {
 	if objid == "" {
 		errorResponse := map[string]string{"error": "missing objid parameter"}
 		errorJSON, _ := json.Marshal(errorResponse)
@@ -220,7 +965,7 @@ func (d *Datasource) handleGetChannel(sender backend.CallResourceResponseSender,
 			Body:    errorJSON,
 		})
 	}
-	channels, err := d.api.GetChannels(objid)
+	channels, err := d.api.GetChannels(ctx, objid)
 	if err != nil {
 		errorResponse := map[string]string{"error": err.Error()}
 		errorJSON, _ := json.Marshal(errorResponse)
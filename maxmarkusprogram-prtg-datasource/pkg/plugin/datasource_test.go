@@ -2,7 +2,13 @@ package plugin
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"testing"
 
@@ -28,6 +34,35 @@ func TestNewDatasource(t *testing.T) {
 	}
 }
 
+// ✅ Datasource oluşturma test: Request timeout from the settings
+func TestNewDatasourceRequestTimeout(t *testing.T) {
+	tests := []struct {
+		jsonData string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{`{"path":"prtg.example.com"}`, defaultRequestTimeout, false},
+		{`{"path":"prtg.example.com","requestTimeout":60}`, time.Minute, false},
+		{`{"path":"prtg.example.com","requestTimeout":-5}`, 0, true},
+	}
+
+	for _, tt := range tests {
+		ds, err := NewDatasource(context.Background(), backend.DataSourceInstanceSettings{JSONData: []byte(tt.jsonData)})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.jsonData)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to create datasource: %v", tt.jsonData, err)
+		}
+		if timeout := ds.(*Datasource).api.timeout; timeout != tt.expected {
+			t.Errorf("%s: expected timeout %v, got %v", tt.jsonData, tt.expected, timeout)
+		}
+	}
+}
+
 // ✅ QueryData test
 func TestQueryData(t *testing.T) {
 	server, api := setupMockServer(`{"sensors": [{"sensor": "CPU Load"}]}`, http.StatusOK)
@@ -51,6 +86,53 @@ func TestQueryData(t *testing.T) {
 	}
 }
 
+// ✅ QueryData test: Queries run concurrently and fail independently
+func TestQueryData_Concurrent(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Write([]byte(`{"histdata": [{"datetime": "15.02.2025 12:00:00", "Load": 1}]}`))
+	}))
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC}
+	req := &backend.QueryDataRequest{}
+	for i := 0; i < queryConcurrency; i++ {
+		req.Queries = append(req.Queries, backend.DataQuery{
+			RefID: string(rune('A' + i)),
+			JSON:  []byte(`{"queryType":"metrics","objid":"` + string(rune('1'+i)) + `","channel":"Load"}`),
+			TimeRange: backend.TimeRange{
+				From: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2025, 2, 16, 0, 0, 0, 0, time.UTC),
+			},
+		})
+	}
+	req.Queries = append(req.Queries, backend.DataQuery{RefID: "Z", JSON: []byte(`{invalid`)})
+
+	start := time.Now()
+	resp, err := ds.QueryData(context.Background(), req)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("QueryData failed: %v", err)
+	}
+
+	// Sequentially the queries would take queryConcurrency times the delay
+	if elapsed >= time.Duration(queryConcurrency/2)*delay {
+		t.Errorf("Expected the queries to run concurrently, took %v", elapsed)
+	}
+	if len(resp.Responses) != queryConcurrency+1 {
+		t.Fatalf("Expected %d responses, got %d", queryConcurrency+1, len(resp.Responses))
+	}
+	for _, q := range req.Queries[:queryConcurrency] {
+		if res := resp.Responses[q.RefID]; res.Error != nil || len(res.Frames) == 0 {
+			t.Errorf("%s: expected frames, got %v", q.RefID, res.Error)
+		}
+	}
+	if resp.Responses["Z"].Error == nil {
+		t.Errorf("Expected an error for the invalid query")
+	}
+}
+
 // ✅ CheckHealth test
 func TestCheckHealth(t *testing.T) {
 	server, api := setupMockServer(`{"prtgversion": "21.2.68.1492"}`, http.StatusOK)
@@ -68,6 +150,107 @@ func TestCheckHealth(t *testing.T) {
 	}
 }
 
+// ✅ CheckHealth test: Failure message contains the redacted URL
+func TestCheckHealthRedactedURL(t *testing.T) {
+	server, api := setupMockServer(`error`, http.StatusInternalServerError)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	req := &backend.CheckHealthRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				JSONData:                []byte(`{}`),
+				DecryptedSecureJSONData: map[string]string{"apiKey": "test-api-key"},
+			},
+		},
+	}
+
+	res, err := ds.CheckHealth(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if res.Status != backend.HealthStatusError {
+		t.Fatalf("Expected HealthStatusError, got %v", res.Status)
+	}
+
+	serverURL, _ := url.Parse(server.URL)
+	if !strings.Contains(res.Message, serverURL.Host) || !strings.Contains(res.Message, "/api/status.json") {
+		t.Errorf("Expected the endpoint URL in the message, got %q", res.Message)
+	}
+	if strings.Contains(res.Message, "test-api-key") {
+		t.Errorf("API token leaked into the message: %q", res.Message)
+	}
+}
+
+// ✅ CheckHealth test: An available PRTG update is mentioned but keeps the status OK
+func TestCheckHealthUpdateAvailable(t *testing.T) {
+	server, api := setupMockServer(`{"prtgversion": "21.2.68.1492", "prtgupdateavailable": true}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	req := &backend.CheckHealthRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				JSONData:                []byte(`{}`),
+				DecryptedSecureJSONData: map[string]string{"apiKey": "test-api-key"},
+			},
+		},
+	}
+
+	res, err := ds.CheckHealth(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if res.Status != backend.HealthStatusOk {
+		t.Fatalf("Expected HealthStatusOk, got %v", res.Status)
+	}
+	if !strings.Contains(res.Message, "update is available") {
+		t.Errorf("Expected the available update in the message, got %q", res.Message)
+	}
+}
+
+// ✅ CheckHealth test: Token permissions and sensor count in the message and details
+func TestCheckHealthDetails(t *testing.T) {
+	server, api := setupMockServer(`{"version": "24.1.90.1306", "readonlyuser": "true", "totalsens": 120, "maxsensorcount": "500"}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	req := &backend.CheckHealthRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				JSONData:                []byte(`{}`),
+				DecryptedSecureJSONData: map[string]string{"apiKey": "test-api-key"},
+			},
+		},
+	}
+
+	res, err := ds.CheckHealth(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if res.Status != backend.HealthStatusOk {
+		t.Fatalf("Expected HealthStatusOk, got %v", res.Status)
+	}
+	for _, text := range []string{"Sensors: 120 of 500", "read-only"} {
+		if !strings.Contains(res.Message, text) {
+			t.Errorf("Expected %q in the message, got %q", text, res.Message)
+		}
+	}
+
+	var details struct {
+		ReadOnlyUser    bool   `json:"readOnlyUser"`
+		TotalSensors    int    `json:"totalSensors"`
+		MaxSensorCount  string `json:"maxSensorCount"`
+		UpdateAvailable bool   `json:"updateAvailable"`
+	}
+	if err := json.Unmarshal(res.JSONDetails, &details); err != nil {
+		t.Fatalf("Failed to parse details: %v", err)
+	}
+	if !details.ReadOnlyUser || details.TotalSensors != 120 || details.MaxSensorCount != "500" || details.UpdateAvailable {
+		t.Errorf("Unexpected details: %+v", details)
+	}
+}
+
 // ✅ CallResource test: Grupları çekme
 func TestCallResourceGroups(t *testing.T) {
 	server, api := setupMockServer(`{"groups": [{"group": "Network Devices"}]}`, http.StatusOK)
@@ -144,6 +327,141 @@ func TestCallResourceChannels(t *testing.T) {
 	}
 }
 
+// ✅ CallResource test: Channels of several sensors in one request
+func TestCallResourceChannelsBulk(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]int{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		mu.Lock()
+		requested[id]++
+		mu.Unlock()
+		if id == "3" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"values": [{"value": 45.6, "datetime": "2025-02-15T12:00:00Z"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	req := &backend.CallResourceRequest{Path: "channels", URL: "channels?ids=1,2,3,abc,2"}
+
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), req, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %v", respSender.status)
+	}
+
+	var body struct {
+		Channels map[string]json.RawMessage `json:"channels"`
+		Errors   map[string]string          `json:"errors"`
+	}
+	if err := json.Unmarshal(respSender.body, &body); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if len(body.Channels) != 2 || body.Channels["1"] == nil || body.Channels["2"] == nil {
+		t.Errorf("Expected channels for sensors 1 and 2, got %v", body.Channels)
+	}
+	if len(body.Errors) != 2 || body.Errors["3"] == "" || body.Errors["abc"] == "" {
+		t.Errorf("Expected errors for sensors 3 and abc, got %v", body.Errors)
+	}
+	if requested["2"] != 1 || requested["abc"] != 0 {
+		t.Errorf("Expected duplicate and invalid ids not to be requested, got %v", requested)
+	}
+}
+
+// ✅ CallResource test: Effective configuration without secrets
+func TestCallResourceConfig(t *testing.T) {
+	settings := backend.DataSourceInstanceSettings{
+		JSONData:                []byte(`{"path":"prtg.example.com","timezone":"Europe/Berlin","retryAttempts":3,"treeCacheTime":60}`),
+		DecryptedSecureJSONData: map[string]string{"apiKey": "super-secret-token"},
+	}
+	instance, err := NewDatasource(context.Background(), settings)
+	if err != nil {
+		t.Fatalf("NewDatasource failed: %v", err)
+	}
+	ds := instance.(*Datasource)
+
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "config"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %v", respSender.status)
+	}
+	if strings.Contains(string(respSender.body), "super-secret-token") {
+		t.Fatalf("API token leaked into the config: %s", respSender.body)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(respSender.body, &config); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	expected := map[string]interface{}{
+		"host":          "prtg.example.com",
+		"timeout":       "10s",
+		"cacheTime":     "30s",
+		"treeCacheTime": "1m0s",
+		"timezone":      "Europe/Berlin",
+		"retryAttempts": float64(3),
+		"tlsMode":       "insecureSkipVerify",
+	}
+	for key, value := range expected {
+		if config[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, config[key])
+		}
+	}
+}
+
+// ✅ CallResource test: Ping requests a single objid and reports the latency
+func TestCallResourcePing(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if query.Get("apitoken") != "test-api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"treesize": 120, "sensors": [{"objid": 1001}]}`))
+	}))
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 0, 10*time.Second)
+	ds := &Datasource{api: api}
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "ping"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d %s", respSender.status, respSender.body)
+	}
+	if query.Get("count") != "1" || query.Get("columns") != "objid" {
+		t.Errorf("Expected a minimal request, got %v", query)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(respSender.body, &result); err != nil {
+		t.Fatalf("Invalid response body: %v", err)
+	}
+	if latency, ok := result["latencyMs"].(float64); result["status"] != "ok" || !ok || latency < 0 {
+		t.Errorf("Unexpected ping result %v", result)
+	}
+
+	// A rejected token fails the ping without leaking it
+	ds.api = NewApi(server.URL, "wrong-token", 0, 10*time.Second)
+	respSender = &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "ping"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusInternalServerError || strings.Contains(string(respSender.body), "wrong-token") {
+		t.Errorf("Expected a redacted error, got %d %s", respSender.status, respSender.body)
+	}
+}
+
 // ✅ Hata testleri: CallResource yanlış path
 func TestCallResource_InvalidPath(t *testing.T) {
 	ds := &Datasource{}
@@ -187,3 +505,178 @@ func (m *mockResourceResponseSender) Send(resp *backend.CallResourceResponse) er
 	m.body = resp.Body
 	return nil
 }
+
+// ✅ CallResource test: Empty path returns the available routes
+func TestCallResource_EmptyPath(t *testing.T) {
+	for _, path := range []string{"", "/", "  "} {
+		ds := &Datasource{}
+		req := &backend.CallResourceRequest{Path: path}
+
+		respSender := &mockResourceResponseSender{}
+		err := ds.CallResource(context.Background(), req, respSender)
+		if err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+
+		if respSender.status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for path %q, got %v", path, respSender.status)
+		}
+
+		var body struct {
+			Error  string   `json:"error"`
+			Routes []string `json:"routes"`
+		}
+		if err := json.Unmarshal(respSender.body, &body); err != nil {
+			t.Fatalf("Failed to parse response body: %v", err)
+		}
+		if body.Error == "" || len(body.Routes) != len(resourceRoutes) {
+			t.Errorf("Expected error message and %d routes, got %+v", len(resourceRoutes), body)
+		}
+	}
+}
+
+// ✅ changedSince test: Incremental diff of list items
+func TestChangedSince(t *testing.T) {
+	items := []PrtgSensorListItemStruct{
+		{Sensor: "A", DatetimeRAW: 45000.5},
+		{Sensor: "B", DatetimeRAW: 45001.25},
+		{Sensor: "C", DatetimeRAW: 45000.75},
+	}
+	raw := func(s PrtgSensorListItemStruct) float64 { return s.DatetimeRAW }
+
+	// Full list without token
+	all, token := changedSince(items, 0, raw)
+	if len(all) != 3 || token != "45001.25" {
+		t.Errorf("Expected all items and token 45001.25, got %d items and %q", len(all), token)
+	}
+
+	// Only objects changed after the token
+	changed, token := changedSince(items, 45000.6, raw)
+	if len(changed) != 2 || changed[0].Sensor != "B" || changed[1].Sensor != "C" {
+		t.Errorf("Expected B and C, got %+v", changed)
+	}
+	if token != "45001.25" {
+		t.Errorf("Expected token 45001.25, got %q", token)
+	}
+
+	// Nothing changed keeps the token
+	changed, token = changedSince(items, 45001.25, raw)
+	if len(changed) != 0 || token != "45001.25" {
+		t.Errorf("Expected no items and unchanged token, got %d items and %q", len(changed), token)
+	}
+}
+
+// ✅ CallResource test: Incremental sensor list
+func TestCallResourceSensors_Since(t *testing.T) {
+	server, api := setupMockServer(`{"sensors": [{"sensor": "Old", "datetime_raw": 45000.5}, {"sensor": "New", "datetime_raw": 45001.5}]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	req := &backend.CallResourceRequest{Path: "sensors", URL: "sensors?since=45001"}
+
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), req, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %v", respSender.status)
+	}
+
+	var body PrtgSensorsListResponse
+	if err := json.Unmarshal(respSender.body, &body); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(body.Sensors) != 1 || body.Sensors[0].Sensor != "New" {
+		t.Errorf("Expected only the changed sensor, got %+v", body.Sensors)
+	}
+	if body.SinceToken != "45001.5" {
+		t.Errorf("Expected new token 45001.5, got %q", body.SinceToken)
+	}
+
+	// Invalid tokens are rejected
+	req.URL = "sensors?since=abc"
+	if err := ds.CallResource(context.Background(), req, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid token, got %v", respSender.status)
+	}
+
+	// Groups and devices have no change time to compare the token with
+	for _, path := range []string{"groups", "devices"} {
+		req := &backend.CallResourceRequest{Path: path, URL: path + "?since=45001"}
+		if err := ds.CallResource(context.Background(), req, respSender); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+		if respSender.status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a token on %s, got %v", path, respSender.status)
+		}
+	}
+}
+
+// ✅ parsePRTGDateTimeRange test: Start, middle and end of a range datetime
+func TestParsePRTGDateTimeRange(t *testing.T) {
+	start := time.Date(2025, 2, 14, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		datetime string
+		position string
+		expected time.Time
+	}{
+		{"Default", "14.02.2025 13:00:00 - 14.02.2025 14:00:00", "", start},
+		{"Start", "14.02.2025 13:00:00 - 14.02.2025 14:00:00", rangeTimestampStart, start},
+		{"Middle", "14.02.2025 13:00:00 - 14.02.2025 14:00:00", rangeTimestampMiddle, start.Add(30 * time.Minute)},
+		{"End", "14.02.2025 13:00:00 - 14.02.2025 14:00:00", rangeTimestampEnd, start.Add(time.Hour)},
+		{"End time only", "14.02.2025 13:00:00 - 13:15:00", rangeTimestampEnd, start.Add(15 * time.Minute)},
+		{"End after midnight", "14.02.2025 23:30:00 - 00:30:00", rangeTimestampMiddle, start.Add(11 * time.Hour)},
+		{"Single timestamp", "14.02.2025 13:00:00", rangeTimestampEnd, start},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parsePRTGDateTimeRange(tt.datetime, time.UTC, tt.position)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !parsed.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, parsed)
+			}
+		})
+	}
+
+	if _, err := parsePRTGDateTimeRange("14.02.2025 13:00:00 - soon", time.UTC, rangeTimestampEnd); err == nil {
+		t.Errorf("Expected an error for an invalid range end")
+	}
+}
+
+// ✅ prtgFloatTime test: PRTG float dates are server local time
+func TestPrtgFloatTime(t *testing.T) {
+	parsed, ok := prtgFloatTime(45703.5, time.UTC)
+	if !ok || !parsed.Equal(time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2025-02-15 12:00 UTC, got %v (%v)", parsed, ok)
+	}
+	if _, ok := prtgFloatTime(0, time.UTC); ok {
+		t.Errorf("Expected no time for a missing date")
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+	// 30.03.2025 01:30 Berlin, right before the DST change
+	parsed, _ = prtgFloatTime(45746.0625, berlin)
+	if !parsed.Equal(time.Date(2025, 3, 30, 0, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected 00:30 UTC, got %v", parsed.UTC())
+	}
+
+	// The float date is the fallback for a datetime in an unknown format
+	ds := &Datasource{}
+	parsed, _, err = ds.propertyTimestamp("3/30/2025 1:30:00 AM", 45746.0625, berlin)
+	if err != nil || !parsed.Equal(time.Date(2025, 3, 30, 0, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected 00:30 UTC from the float date, got %v (%v)", parsed.UTC(), err)
+	}
+	if _, _, err := ds.propertyTimestamp("3/30/2025 1:30:00 AM", 0, berlin); err == nil {
+		t.Errorf("Expected an error without float date")
+	}
+}
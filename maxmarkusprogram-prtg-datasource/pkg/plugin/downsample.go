@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"math"
+	"time"
+)
+
+// minDownsamplePoints is the smallest useful LTTB threshold: first point, one bucket, last point.
+const minDownsamplePoints = 3
+
+// lttbIndices selects at most threshold points of a series with the Largest-Triangle-Three-Buckets
+// algorithm and returns their indices in ascending order. The first and last point are always
+// kept; from every bucket in between the point spanning the largest triangle with the previous
+// selected point and the average of the next bucket is taken, so spikes survive. A series not
+// longer than threshold, or a threshold <= 0, is returned unchanged.
+func lttbIndices(times []time.Time, values []float64, threshold int) []int {
+	n := len(values)
+	if threshold > 0 && threshold < minDownsamplePoints {
+		threshold = minDownsamplePoints
+	}
+	if threshold <= 0 || n <= threshold {
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	x := func(i int) float64 {
+		return float64(times[i].Sub(times[0]).Milliseconds())
+	}
+
+	indices := make([]int, 0, threshold)
+	indices = append(indices, 0)
+	bucketSize := float64(n-2) / float64(threshold-2)
+	selected := 0
+	for bucket := 0; bucket < threshold-2; bucket++ {
+		// Average of the next bucket, the last point for the final bucket
+		nextStart := int(float64(bucket+1)*bucketSize) + 1
+		nextEnd := int(float64(bucket+2)*bucketSize) + 1
+		if nextEnd > n {
+			nextEnd = n
+		}
+		var avgX, avgY float64
+		for i := nextStart; i < nextEnd; i++ {
+			avgX += x(i)
+			avgY += values[i]
+		}
+		if count := nextEnd - nextStart; count > 0 {
+			avgX /= float64(count)
+			avgY /= float64(count)
+		} else {
+			avgX, avgY = x(n-1), values[n-1]
+		}
+
+		start := int(float64(bucket)*bucketSize) + 1
+		end := int(float64(bucket+1)*bucketSize) + 1
+		ax, ay := x(selected), values[selected]
+		maxArea := -1.0
+		next := start
+		for i := start; i < end; i++ {
+			area := math.Abs((ax-avgX)*(values[i]-ay) - (ax-x(i))*(avgY-ay))
+			if area > maxArea {
+				maxArea = area
+				next = i
+			}
+		}
+		indices = append(indices, next)
+		selected = next
+	}
+	return append(indices, n-1)
+}
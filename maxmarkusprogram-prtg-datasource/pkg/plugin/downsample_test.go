@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ lttbIndices test: The output is bounded, keeps the ends and the spike
+func TestLttbIndices(t *testing.T) {
+	base := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	times := make([]time.Time, 10000)
+	values := make([]float64, len(times))
+	for i := range times {
+		times[i] = base.Add(time.Duration(i) * time.Minute)
+		values[i] = math.Sin(float64(i) / 100)
+	}
+	values[4321] = 50
+
+	indices := lttbIndices(times, values, 500)
+	if len(indices) != 500 {
+		t.Fatalf("Expected 500 points, got %d", len(indices))
+	}
+	if indices[0] != 0 || indices[len(indices)-1] != len(values)-1 {
+		t.Errorf("Expected the first and last point, got %d and %d", indices[0], indices[len(indices)-1])
+	}
+	spike := false
+	for i, index := range indices {
+		if i > 0 && index <= indices[i-1] {
+			t.Fatalf("Indices not ascending at %d: %d after %d", i, index, indices[i-1])
+		}
+		spike = spike || index == 4321
+	}
+	if !spike {
+		t.Errorf("Expected the spike to be kept")
+	}
+
+	// Short series and a missing threshold are returned unchanged
+	if got := lttbIndices(times[:10], values[:10], 500); len(got) != 10 {
+		t.Errorf("Expected 10 points, got %d", len(got))
+	}
+	if got := lttbIndices(times[:10], values[:10], 0); len(got) != 10 {
+		t.Errorf("Expected 10 points without threshold, got %d", len(got))
+	}
+	if got := lttbIndices(times[:10], values[:10], 1); len(got) != minDownsamplePoints {
+		t.Errorf("Expected %d points, got %d", minDownsamplePoints, len(got))
+	}
+}
+
+// ✅ Metrics query: The output length is bounded by MaxDataPoints
+func TestQueryData_MaxDataPoints(t *testing.T) {
+	base := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+	points := make([]string, 2000)
+	for i := range points {
+		points[i] = fmt.Sprintf(`{"datetime": %q, "Load": %d}`, base.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), i%7)
+	}
+	server, api := setupMockAPI(`{"histdata": [`+strings.Join(points, ",")+`]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, timezone: time.UTC}
+	request := func(maxDataPoints int64) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:         "A",
+			JSON:          []byte(`{"queryType":"metrics","objid":"1234","channel":"Load"}`),
+			MaxDataPoints: maxDataPoints,
+			TimeRange:     backend.TimeRange{From: base, To: base.Add(48 * time.Hour)},
+		})
+	}
+
+	resp := request(300)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if rows, _ := resp.Frames[0].RowLen(); rows > 300 {
+		t.Errorf("Expected at most 300 points, got %d", rows)
+	}
+	if got := resp.Frames[0].Meta.Custom.(map[string]interface{})["pointsDownsampled"]; got != 2000 {
+		t.Errorf("Expected pointsDownsampled 2000, got %v", got)
+	}
+
+	resp = request(0)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if rows, _ := resp.Frames[0].RowLen(); rows != 2000 {
+		t.Errorf("Expected all 2000 points without MaxDataPoints, got %d", rows)
+	}
+}
@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Dry-run mode
+//
+// With dry-run enabled no request is sent to PRTG. Every request URL is built and validated
+// as usual and logged with the API token redacted, then a canned empty response is returned.
+// Queries therefore produce empty frames, which allows to check dashboards against a
+// production PRTG without putting any load on it.
+
+// dryRunVersion is the PRTG version reported in dry-run mode.
+const dryRunVersion = "dry-run"
+
+// dryRunJSON is the canned response of the JSON endpoints, it has an empty list for every
+// content type the plugin reads.
+const dryRunJSON = `{"prtgversion":"` + dryRunVersion + `","version":"` + dryRunVersion + `","treesize":0,` +
+	`"groups":[],"devices":[],"sensors":[],"channels":[],"messages":[],"histdata":[],"sensordata":{}}`
+
+// dryRunXML is the canned response of getobjectproperty.htm.
+const dryRunXML = `<?xml version="1.0" encoding="UTF-8"?><prtg><version>` + dryRunVersion + `</version><result></result></prtg>`
+
+// dryRunResponse returns the canned response body for an endpoint.
+func dryRunResponse(endpoint string) []byte {
+	if strings.HasSuffix(endpoint, ".htm") {
+		return []byte(dryRunXML)
+	}
+	return []byte(dryRunJSON)
+}
+
+// dryRunRequest logs the request that would be sent and returns the canned response.
+func (a *Api) dryRunRequest(endpoint string, params map[string]string) ([]byte, error) {
+	if _, err := a.buildApiUrl(endpoint, params); err != nil {
+		return nil, err
+	}
+	backend.Logger.Info("Dry-run: request not sent", "url", a.redactedApiUrl(endpoint, params))
+	return dryRunResponse(endpoint), nil
+}
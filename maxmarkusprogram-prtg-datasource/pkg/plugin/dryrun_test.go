@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestDryRunSendsNoRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetDryRun(true)
+
+	status, err := api.GetStatusList(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatusList failed: %v", err)
+	}
+	if status.Version != dryRunVersion {
+		t.Errorf("Expected version %q, got %q", dryRunVersion, status.Version)
+	}
+	if sensors, err := api.GetSensors(context.Background(), listFilter{}); err != nil || len(sensors.Sensors) != 0 {
+		t.Errorf("Expected an empty sensor list, got %v (%v)", sensors, err)
+	}
+	if _, err := api.GetChannelProperty(context.Background(), "1234", 2, "color"); err != nil {
+		t.Errorf("GetChannelProperty failed: %v", err)
+	}
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`),
+		TimeRange: backend.TimeRange{
+			From: time.Now().Add(-time.Hour),
+			To:   time.Now(),
+		},
+	})
+	if resp.Error != nil {
+		t.Errorf("Expected no error for a dry-run metrics query, got %v", resp.Error)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("Expected no HTTP request in dry-run mode, got %d", n)
+	}
+}
+
+func TestDryRunValidatesHost(t *testing.T) {
+	api := NewApi("http://", "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetDryRun(true)
+
+	if _, err := api.GetStatusList(context.Background()); err == nil {
+		t.Errorf("Expected an invalid URL to fail in dry-run mode")
+	}
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Cluster failover
+//
+// A PRTG cluster has a primary node and one or more failover nodes. Besides the base URL the
+// Api knows the base URLs of the other nodes. A request is sent to the active node; if it fails
+// with a connection error, a timeout or a 5xx status after all retry attempts, the next node
+// is tried within the same overall deadline. Every node gets an equal share of the remaining
+// time, so a hanging node cannot use up the deadline of the nodes after it; time a node does
+// not need passes on to the next. The node that answered becomes the active node, so later
+// requests go there directly until it fails as well. Failures that would repeat on every node,
+// like 400 or 403, are returned right away.
+
+// SetFailoverURLs legt die Basis-URLs weiterer Cluster-Knoten in der Reihenfolge ihrer Verwendung fest.
+func (a *Api) SetFailoverURLs(urls []string) {
+	a.nodeMu.Lock()
+	defer a.nodeMu.Unlock()
+
+	a.failoverURLs = nil
+	a.activeURL = ""
+	a.allowedHosts = make(map[string]struct{})
+	if u, err := url.Parse(a.baseURL); err == nil && u.Host != "" {
+		a.allowedHosts[strings.ToLower(u.Host)] = struct{}{}
+	}
+	for _, raw := range urls {
+		raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+		if raw == "" || raw == a.baseURL {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			backend.Logger.Warn("Ignoring invalid failover URL", "url", redactURL(raw))
+			continue
+		}
+		a.failoverURLs = append(a.failoverURLs, raw)
+		a.allowedHosts[strings.ToLower(u.Host)] = struct{}{}
+	}
+}
+
+// ActiveBaseURL liefert die Basis-URL des Knotens, der zuletzt erfolgreich geantwortet hat.
+func (a *Api) ActiveBaseURL() string {
+	a.nodeMu.Lock()
+	defer a.nodeMu.Unlock()
+	if a.activeURL != "" {
+		return a.activeURL
+	}
+	return a.baseURL
+}
+
+// nodeOrder returns the base URLs to try: the active node first, then the others in their
+// configured order.
+func (a *Api) nodeOrder() []string {
+	a.nodeMu.Lock()
+	defer a.nodeMu.Unlock()
+
+	active := a.activeURL
+	if active == "" {
+		active = a.baseURL
+	}
+	nodes := []string{active}
+	for _, node := range append([]string{a.baseURL}, a.failoverURLs...) {
+		if node != active {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// setActiveNode remembers the node that answered a request.
+func (a *Api) setActiveNode(node string) {
+	a.nodeMu.Lock()
+	defer a.nodeMu.Unlock()
+
+	previous := a.activeURL
+	if previous == "" {
+		previous = a.baseURL
+	}
+	if node != previous {
+		backend.Logger.Info("Switched PRTG cluster node", "from", redactURL(previous), "to", redactURL(node))
+	}
+	a.activeURL = node
+}
+
+// nodeContext returns the context of a request to one of remaining nodes: with a deadline,
+// the node gets its share of the remaining time, the last node gets all of it.
+func nodeContext(ctx context.Context, remaining int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || remaining <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(remaining))
+}
+
+// isFailoverError reports whether a failed request may succeed on another cluster node.
+func isFailoverError(err error) bool {
+	var apiErr *PRTGAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ✅ Failover test: A failing node is skipped and the serving node stays active
+func TestApiFailover(t *testing.T) {
+	var primaryStatus atomic.Int32
+	primaryStatus.Store(http.StatusServiceUnavailable)
+	var primaryCalls, failoverCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(int(primaryStatus.Load()))
+	}))
+	defer primary.Close()
+	failover := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failoverCalls.Add(1)
+		fmt.Fprint(w, `{"version": "24.1", "clusternodename": "Failover Node"}`)
+	}))
+	defer failover.Close()
+
+	api := NewApi(primary.URL, "test-api-key", 0, 10*time.Second)
+	api.SetFailoverURLs([]string{" ", failover.URL + "/"})
+
+	status, err := api.GetLiveStatusList(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the failover node to answer, got %v", err)
+	}
+	if status.ClusterNodeName != "Failover Node" || api.ActiveBaseURL() != failover.URL {
+		t.Errorf("Expected the failover node to be active, got %q %s", status.ClusterNodeName, api.ActiveBaseURL())
+	}
+
+	// The active node is asked first
+	if _, err := api.GetLiveStatusList(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if primaryCalls.Load() != 1 || failoverCalls.Load() != 2 {
+		t.Errorf("Expected 1 primary and 2 failover calls, got %d and %d", primaryCalls.Load(), failoverCalls.Load())
+	}
+
+	// Errors that repeat on every node are not failed over
+	failover.Close()
+	primaryStatus.Store(http.StatusBadRequest)
+	api = NewApi(primary.URL, "test-api-key", 0, 10*time.Second)
+	api.SetFailoverURLs([]string{failover.URL})
+	_, err = api.GetLiveStatusList(context.Background())
+	var apiErr *PRTGAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected the 400 of the primary node, got %v", err)
+	}
+}
+
+// ✅ Failover test: Connection errors of all nodes return the last error
+func TestApiFailoverAllNodesDown(t *testing.T) {
+	primary := httptest.NewServer(http.NotFoundHandler())
+	primary.Close()
+	failover := httptest.NewServer(http.NotFoundHandler())
+	failover.Close()
+
+	api := NewApi(primary.URL, "test-api-key", 0, 10*time.Second)
+	api.SetFailoverURLs([]string{failover.URL, "://invalid"})
+	if len(api.failoverURLs) != 1 {
+		t.Errorf("Expected the invalid URL to be ignored, got %v", api.failoverURLs)
+	}
+	if _, err := api.GetLiveStatusList(context.Background()); err == nil {
+		t.Errorf("Expected an error if all nodes are down")
+	}
+	if api.ActiveBaseURL() != primary.URL {
+		t.Errorf("Expected the primary node to stay active, got %s", api.ActiveBaseURL())
+	}
+}
+
+// ✅ Failover test: A hanging primary node only gets its share of the deadline
+func TestApiFailoverHangingPrimary(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer primary.Close()
+	failover := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"version": "24.1", "clusternodename": "Failover Node"}`)
+	}))
+	defer failover.Close()
+
+	// No attempt timeout, the request timeout is the only bound
+	api := NewApi(primary.URL, "test-api-key", 0, 2*time.Second)
+	api.SetFailoverURLs([]string{failover.URL})
+
+	start := time.Now()
+	status, err := api.GetLiveStatusList(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the failover node to answer, got %v", err)
+	}
+	if status.ClusterNodeName != "Failover Node" || api.ActiveBaseURL() != failover.URL {
+		t.Errorf("Expected the failover node to be active, got %q %s", status.ClusterNodeName, api.ActiveBaseURL())
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("Expected the primary to get half of the deadline, took %v", elapsed)
+	}
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// latestSeriesProperty distinguishes the series key of a latest value from the channel history.
+const latestSeriesProperty = "latest"
+
+// latestRecordTime returns the timestamp of a channel values record, or now if it has none.
+func latestRecordTime(record map[string]interface{}, loc *time.Location) time.Time {
+	if datetime, ok := record["datetime"].(string); ok {
+		if t, _, err := parsePRTGDateTimeIn(cleanMessageHTML(datetime), loc); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// handleLatestQuery returns only the newest value of the selected channels, one frame with a
+// single row per channel. The values are read from the current channel values of the sensor
+// instead of the history of the time range. Without a channel all channels are returned.
+func (d *Datasource) handleLatestQuery(ctx context.Context, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	if qm.ObjectId == "" {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "invalid query: missing object ID")
+	}
+
+	requested := append([]string(nil), qm.Channels...)
+	if len(requested) == 0 && strings.TrimSpace(qm.Channel) != "" {
+		requested = []string{qm.Channel}
+	}
+	for i, channel := range requested {
+		if isPrimaryChannel(channel) {
+			resolved, err := d.resolvePrimaryChannel(ctx, qm.ObjectId)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+			requested[i] = resolved
+		}
+	}
+
+	channels, err := d.api.GetChannels(ctx, qm.ObjectId)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+	record, ok := latestChannelRecord(*channels)
+	if !ok {
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("no channel values found for sensor %s", qm.ObjectId))
+	}
+
+	values := make(map[string]channelValue)
+	for _, v := range channelValues(record) {
+		values[v.name] = v
+	}
+	if len(requested) == 0 {
+		for _, v := range channelValues(record) {
+			requested = append(requested, v.name)
+		}
+	}
+
+	timezone, _ := d.effectiveTimezone(ctx)
+	timestamp := latestRecordTime(record, timezone)
+	timeFieldName, _ := qm.fieldNames("")
+	var missing []string
+	for _, channel := range requested {
+		v, ok := values[channel]
+		if !ok {
+			missing = append(missing, channel)
+			continue
+		}
+		_, valueFieldName := qm.fieldNames(channel)
+		if len(requested) > 1 {
+			// A configured value field name would be ambiguous for several channels
+			valueFieldName = channel
+		}
+		labels := qm.seriesLabels(channel, latestSeriesProperty)
+		frame := data.NewFrame("response",
+			data.NewField(timeFieldName, nil, []time.Time{timestamp}),
+			data.NewField(valueFieldName, labels, []*float64{v.value}).SetConfig(&data.FieldConfig{
+				DisplayName: qm.seriesName(channel),
+			}),
+		)
+		frame.SetMeta(&data.FrameMeta{
+			Custom: map[string]interface{}{
+				"unit":     v.unit,
+				"timezone": timezone.String(),
+			},
+		})
+		response.Frames = append(response.Frames, frame)
+	}
+
+	if len(response.Frames) == 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("channels not found: %s", strings.Join(missing, ", ")))
+	}
+	if len(missing) > 0 {
+		response.Frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Channels not found: %s", strings.Join(missing, ", ")),
+		})
+	}
+	return response
+}
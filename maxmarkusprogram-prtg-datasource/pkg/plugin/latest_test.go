@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ Latest query: One single-row frame per channel with the newest value
+func TestQueryData_LatestOnly(t *testing.T) {
+	server, api := setupMockAPI(`{"values": [
+		{"datetime": "15.02.2025 11:00:00", "datetime_raw": 1.0, "Ping Time": "4 msec", "Ping Time(RAW)": 4, "Packet Loss": "0 %", "Packet Loss(RAW)": 0},
+		{"datetime": "15.02.2025 12:00:00", "datetime_raw": 2.0, "Ping Time": "5 msec", "Ping Time(RAW)": 5, "Packet Loss": "1 %", "Packet Loss(RAW)": 1}
+	]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, timezone: time.UTC}
+	request := func(query string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID: "A",
+			JSON:  []byte(query),
+		})
+	}
+
+	resp := request(`{"queryType":"metrics","objid":"1234","channel":"Ping Time","latestOnly":true}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 1 {
+		t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 1 {
+		t.Fatalf("Expected 1 row, got %d", rows)
+	}
+	expectedTime := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	if got := frame.Fields[0].At(0).(time.Time); !got.Equal(expectedTime) {
+		t.Errorf("Expected time %v, got %v", expectedTime, got)
+	}
+	if got := frame.Fields[1].At(0).(*float64); got == nil || *got != 5 {
+		t.Errorf("Expected value 5, got %v", got)
+	}
+
+	// Without a channel every channel is returned
+	resp = request(`{"queryType":"metrics","objid":"1234","latestOnly":true}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Errorf("Expected 2 frames, got %d", len(resp.Frames))
+	}
+
+	// Unknown channels are reported
+	resp = request(`{"queryType":"metrics","objid":"1234","channels":["Ping Time","Jitter"],"latestOnly":true}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 1 || resp.Frames[0].Meta == nil || len(resp.Frames[0].Meta.Notices) != 1 {
+		t.Errorf("Expected 1 frame with a notice, got %+v", resp.Frames)
+	}
+	resp = request(`{"queryType":"metrics","objid":"1234","channel":"Jitter","latestOnly":true}`)
+	if resp.Error == nil {
+		t.Errorf("Expected an error for an unknown channel")
+	}
+}
@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tablePageSize is the number of objects requested per table.json page.
+const tablePageSize = 500
+
+// maxTableObjects bounds the objects of a paged list, so a server reporting a wrong tree size
+// cannot keep the plugin paging forever.
+const maxTableObjects = 500000
+
+// objectList is a list of PRTG objects that also accepts a single object. Some PRTG
+// endpoints return an object instead of an array if there is only one result, which
+// would otherwise fail the unmarshal and blank the whole list.
+type objectList[T any] []T
+
+// UnmarshalJSON accepts an array, a single object or null.
+func (l *objectList[T]) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+		*l = nil
+		return nil
+	case trimmed[0] == '{':
+		// An empty object stands for an empty list
+		if bytes.Equal(bytes.Join(bytes.Fields(trimmed), nil), []byte("{}")) {
+			*l = objectList[T]{}
+			return nil
+		}
+		var item T
+		if err := json.Unmarshal(trimmed, &item); err != nil {
+			return err
+		}
+		*l = objectList[T]{item}
+		return nil
+	}
+
+	var items []T
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// listFilter scopes a table.json list request on the PRTG server, so only the matching objects
+// are transferred. ParentId restricts the list to the objects below a probe, group or device;
+// the other fields match the object id or the exact name. ObjectId may list several comma
+// separated ids. Tags matches objects carrying at least one of the tags, inherited tags
+// included. The zero value requests the full list.
+type listFilter struct {
+	ParentId string
+	ObjectId string
+	Group    string
+	Device   string
+	Sensor   string
+	Tags     []string
+}
+
+// tagPattern matches a single PRTG tag. Tags are separated by spaces and commas in PRTG, and
+// parentheses would break the @tag() filter syntax, so none of them can be part of a tag.
+var tagPattern = regexp.MustCompile(`^[^\s,()"'@]+$`)
+
+// validate checks the tags of the filter, see tagPattern.
+func (f listFilter) validate() error {
+	for _, tag := range f.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !tagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q: tags must not contain spaces, commas, quotes, parentheses or @", tag)
+		}
+	}
+	return nil
+}
+
+// tagFilter returns the PRTG filter_tags value of the tags, e.g. "@tag(production,linux)".
+func (f listFilter) tagFilter() string {
+	tags := make([]string, 0, len(f.Tags))
+	for _, tag := range f.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "@tag(" + strings.Join(tags, ",") + ")"
+}
+
+// parseListTags reads the optional "tags" query parameter of the list routes, a comma
+// separated list like "groups?tags=production,linux".
+func parseListTags(rawURL string) ([]string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL: %w", err)
+	}
+	var tags []string
+	for _, tag := range strings.Split(u.Query().Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if err := (listFilter{Tags: tags}).validate(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// treeList returns the full list from the tree cache, or only the objects carrying one of
+// tags, fetched with a tag filter. Tagged lists are not kept in the tree cache.
+func treeList[T any](ctx context.Context, c *ttlCache, key string, tags []string, fetch func(context.Context, listFilter) (*T, error)) (*T, error) {
+	if len(tags) == 0 {
+		return cachedTree(ctx, c, key, fetch)
+	}
+	return fetch(ctx, listFilter{Tags: tags})
+}
+
+// apply adds the PRTG parameters of the filter to params.
+func (f listFilter) apply(params map[string]string) {
+	for key, value := range map[string]string{
+		"id":            f.ParentId,
+		"filter_objid":  f.ObjectId,
+		"filter_group":  f.Group,
+		"filter_device": f.Device,
+		"filter_sensor": f.Sensor,
+		"filter_tags":   f.tagFilter(),
+	} {
+		if value = strings.TrimSpace(value); value != "" {
+			params[key] = value
+		}
+	}
+}
+
+// pagedTable fetches a table.json list page by page using start and count, until the tree
+// size is reached or a page comes back short. items returns the list and the tree size of a
+// page response; the lists of all pages are concatenated into the first response.
+//
+// The pages are always fetched from the server and the assembled list is cached as a whole,
+// so a list never mixes pages fetched at different times. The result is a shallow copy of the
+// cached list.
+func pagedTable[R any, T any](ctx context.Context, a *Api, params map[string]string, items func(*R) (*objectList[T], int64)) (*R, error) {
+	cache := a.responses
+	if responseCacheDisabled(ctx) {
+		cache = nil
+	}
+	key := responseCacheKey("table.json", params)
+	if value, ok := cache.get(key); ok {
+		cached := *value.(*R)
+		return &cached, nil
+	}
+
+	var first *R
+	var all objectList[T]
+	for start := 0; start < maxTableObjects; {
+		pageParams := make(map[string]string, len(params)+2)
+		for key, value := range params {
+			pageParams[key] = value
+		}
+		pageParams["start"] = strconv.Itoa(start)
+		pageParams["count"] = strconv.Itoa(tablePageSize)
+
+		body, err := a.baseExecuteRequest(ctx, "table.json", pageParams)
+		if err != nil {
+			return nil, err
+		}
+		var page R
+		if err := decodeResponse(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if first == nil {
+			first = &page
+		}
+
+		list, treeSize := items(&page)
+		all = append(all, *list...)
+		start += len(*list)
+		if len(*list) < tablePageSize || (treeSize > 0 && int64(start) >= treeSize) {
+			break
+		}
+	}
+
+	list, _ := items(first)
+	*list = all
+	cache.set(key, first)
+
+	result := *first
+	return &result, nil
+}
@@ -0,0 +1,389 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ objectList test: Single object and array shapes for every list type
+func TestObjectListUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []string
+	}{
+		{"Array", `[{"objid": 1, "name": "A"}, {"objid": 2, "name": "B"}]`, []string{"A", "B"}},
+		{"Single object", `{"objid": 1, "name": "A"}`, []string{"A"}},
+		{"Empty array", `[]`, []string{}},
+		{"Empty object", `{ }`, []string{}},
+		{"Null", `null`, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list objectList[PrtgChannelListItemStruct]
+			if err := json.Unmarshal([]byte(tt.json), &list); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(list) != len(tt.expected) {
+				t.Fatalf("Expected %d items, got %d", len(tt.expected), len(list))
+			}
+			for i, name := range tt.expected {
+				if list[i].Name != name {
+					t.Errorf("Expected item %d to be %s, got %s", i, name, list[i].Name)
+				}
+			}
+		})
+	}
+
+	var list objectList[PrtgChannelListItemStruct]
+	if err := json.Unmarshal([]byte(`"invalid"`), &list); err == nil {
+		t.Errorf("Expected an error for a string")
+	}
+}
+
+// ✅ Group, device and sensor lists accept a single object
+func TestListResponsesSingleObject(t *testing.T) {
+	shapes := map[string]string{
+		"array":  `[{"objid": 42, "group": "G", "device": "D", "sensor": "S"}]`,
+		"object": `{"objid": 42, "group": "G", "device": "D", "sensor": "S"}`,
+	}
+
+	for shape, items := range shapes {
+		t.Run(shape, func(t *testing.T) {
+			var groups PrtgGroupListResponse
+			if err := json.Unmarshal([]byte(`{"treesize": 1, "groups": `+items+`}`), &groups); err != nil {
+				t.Fatalf("Groups: %v", err)
+			}
+			if len(groups.Groups) != 1 || groups.Groups[0].ObjectId != 42 || groups.Groups[0].Group != "G" {
+				t.Errorf("Unexpected groups: %+v", groups.Groups)
+			}
+
+			var devices PrtgDevicesListResponse
+			if err := json.Unmarshal([]byte(`{"treesize": 1, "devices": `+items+`}`), &devices); err != nil {
+				t.Fatalf("Devices: %v", err)
+			}
+			if len(devices.Devices) != 1 || devices.Devices[0].ObjectId != 42 || devices.Devices[0].Device != "D" {
+				t.Errorf("Unexpected devices: %+v", devices.Devices)
+			}
+
+			var sensors PrtgSensorsListResponse
+			if err := json.Unmarshal([]byte(`{"treesize": 1, "sensors": `+items+`}`), &sensors); err != nil {
+				t.Fatalf("Sensors: %v", err)
+			}
+			if len(sensors.Sensors) != 1 || sensors.Sensors[0].ObjectId != 42 || sensors.Sensors[0].Sensor != "S" {
+				t.Errorf("Unexpected sensors: %+v", sensors.Sensors)
+			}
+		})
+	}
+}
+
+// ✅ GetSensors with a single object response
+func TestGetSensorsSingleObject(t *testing.T) {
+	server, api := setupMockServer(`{"treesize": 1, "sensors": {"objid": 1001, "sensor": "Ping"}}`, http.StatusOK)
+	defer server.Close()
+
+	sensors, err := api.GetSensors(context.Background(), listFilter{})
+	if err != nil {
+		t.Fatalf("GetSensors() failed: %v", err)
+	}
+	if len(sensors.Sensors) != 1 || sensors.Sensors[0].Sensor != "Ping" {
+		t.Errorf("Expected the single sensor, got %+v", sensors.Sensors)
+	}
+}
+
+// ✅ pagedTable test: Lists larger than a page are fetched with start and count
+func TestGetSensorsPaging(t *testing.T) {
+	const total = 1203
+	var starts []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		starts = append(starts, query.Get("start"))
+		start, _ := strconv.Atoi(query.Get("start"))
+		count, _ := strconv.Atoi(query.Get("count"))
+
+		sensors := []map[string]interface{}{}
+		for i := start; i < start+count && i < total; i++ {
+			sensors = append(sensors, map[string]interface{}{"objid": i + 1, "sensor": fmt.Sprintf("Sensor %d", i+1)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"treesize": total, "sensors": sensors})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 0, 10*time.Second)
+	response, err := api.GetSensors(context.Background(), listFilter{})
+	if err != nil {
+		t.Fatalf("GetSensors failed: %v", err)
+	}
+	if len(response.Sensors) != total {
+		t.Fatalf("Expected %d sensors, got %d", total, len(response.Sensors))
+	}
+	for i, sensor := range response.Sensors {
+		if sensor.ObjectId != int64(i+1) {
+			t.Fatalf("Expected objid %d at %d, got %d", i+1, i, sensor.ObjectId)
+		}
+	}
+	if expected := []string{"0", "500", "1000"}; !reflect.DeepEqual(starts, expected) {
+		t.Errorf("Expected pages starting at %v, got %v", expected, starts)
+	}
+
+	// The assembled list is cached as a whole, not page by page
+	cached := NewApi(server.URL, "test-api-key", 30*time.Second, 10*time.Second)
+	for i := 0; i < 2; i++ {
+		starts = nil
+		if response, err := cached.GetSensors(context.Background(), listFilter{}); err != nil || len(response.Sensors) != total {
+			t.Fatalf("GetSensors failed: %v", err)
+		}
+		if expected := 3 - 3*i; len(starts) != expected {
+			t.Errorf("Expected %d requests on call %d, got %d", expected, i+1, len(starts))
+		}
+	}
+
+	// Alarms are paged too and bypass the cache
+	starts = nil
+	alarms, err := cached.GetAlarms(context.Background())
+	if err != nil {
+		t.Fatalf("GetAlarms failed: %v", err)
+	}
+	if len(alarms.Alarms) != total || len(starts) != 3 {
+		t.Errorf("Expected %d alarms from 3 pages, got %d from %d", total, len(alarms.Alarms), len(starts))
+	}
+
+	// A short page ends the list even without a tree size
+	starts = nil
+	mux2 := http.NewServeMux()
+	mux2.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		starts = append(starts, r.URL.Query().Get("start"))
+		fmt.Fprint(w, `{"groups": [{"objid": 1, "group": "Root"}]}`)
+	})
+	server2 := httptest.NewServer(mux2)
+	defer server2.Close()
+
+	groups, err := NewApi(server2.URL, "test-api-key", 0, 10*time.Second).GetGroups(context.Background(), listFilter{})
+	if err != nil {
+		t.Fatalf("GetGroups failed: %v", err)
+	}
+	if len(groups.Groups) != 1 || len(starts) != 1 {
+		t.Errorf("Expected 1 group from 1 request, got %d from %d", len(groups.Groups), len(starts))
+	}
+}
+
+// ✅ listFilter test: Lists are scoped on the server with id and filter parameters
+func TestListFilter(t *testing.T) {
+	var queries []url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		fmt.Fprint(w, `{"treesize": 1, "sensors": [{"objid": 1234, "sensor": "CPU Load", "datetime": "2025-02-15T12:00:00Z", "status": "Up"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 0, 10*time.Second)
+	if _, err := api.GetDevices(context.Background(), listFilter{ParentId: "42", Device: " Switch "}); err != nil {
+		t.Fatalf("GetDevices failed: %v", err)
+	}
+	if q := queries[0]; q.Get("id") != "42" || q.Get("filter_device") != "Switch" || q.Has("filter_group") {
+		t.Errorf("Unexpected parameters %v", q)
+	}
+
+	// Property queries scope the list by the objid of the selected object
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"text","property":"sensor","objid":"1234","sensor":"CPU Load","filterProperty":"status"}`),
+	})
+	if resp.Error != nil || resp.Frames[0].Fields[1].Len() != 1 {
+		t.Fatalf("Expected one value, got %v", resp.Error)
+	}
+	if q := queries[1]; q.Get("filter_objid") != "1234" || q.Has("filter_sensor") {
+		t.Errorf("Unexpected parameters %v", q)
+	}
+
+	// The full list has no filter
+	if _, err := api.GetGroups(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetGroups failed: %v", err)
+	}
+	for key := range queries[2] {
+		if key == "id" || strings.HasPrefix(key, "filter_") {
+			t.Errorf("Unexpected filter parameter %s", key)
+		}
+	}
+}
+
+// ✅ listFilter test: Tags are passed as PRTG tag filter and validated
+func TestListFilter_Tags(t *testing.T) {
+	params := map[string]string{}
+	listFilter{Tags: []string{" production ", "", "linux"}}.apply(params)
+	if params["filter_tags"] != "@tag(production,linux)" {
+		t.Errorf("Unexpected tag filter %q", params["filter_tags"])
+	}
+
+	for _, tag := range []string{"prod linux", "a,b", "x)", "@tag", `"quoted"`} {
+		if err := (listFilter{Tags: []string{tag}}).validate(); err == nil {
+			t.Errorf("Expected tag %q to be rejected", tag)
+		}
+	}
+	if err := (listFilter{Tags: []string{"env:prod", "unit_mbps", "web-01"}}).validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		fmt.Fprint(w, `{"treesize": 2, "sensors": [
+			{"objid": 1001, "sensor": "CPU Load", "datetime": "2025-02-15T12:00:00Z", "status": "Up", "tags": "production"},
+			{"objid": 1002, "sensor": "Memory", "datetime": "2025-02-15T12:00:00Z", "status": "Down", "tags": "production"}
+		]}`)
+	}))
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 0, 10*time.Second)
+	if _, err := api.GetSensors(context.Background(), listFilter{Tags: []string{"a b"}}); err == nil || len(queries) != 0 {
+		t.Errorf("Expected an invalid tag to fail before the request, got %v", err)
+	}
+
+	// A property query with tags but without a name returns all tagged objects
+	ds := &Datasource{api: api, timezone: time.UTC}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"text","property":"sensor","tags":["production"],"filterProperty":"status"}`),
+	})
+	if resp.Error != nil || resp.Frames[0].Fields[1].Len() != 2 {
+		t.Fatalf("Expected two values, got %v", resp.Error)
+	}
+	if q := queries[0]; q.Get("filter_tags") != "@tag(production)" || q.Has("filter_sensor") {
+		t.Errorf("Unexpected parameters %v", q)
+	}
+
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"text","property":"sensor","tags":["prod(uction)"],"filterProperty":"status"}`),
+	})
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "invalid query") {
+		t.Errorf("Expected an invalid query error, got %v", resp.Error)
+	}
+}
+
+// ✅ listFilter test: An objid lacking the tags matches nothing, list routes accept tags
+func TestListFilter_TagsObjectId(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, q)
+		if q.Has("filter_tags") {
+			fmt.Fprint(w, `{"treesize": 1, "sensors": [{"objid": 1001, "sensor": "Disk", "status": "Up", "tags": "production"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"treesize": 2, "sensors": [
+			{"objid": 1001, "sensor": "Disk", "status": "Up", "tags": "production"},
+			{"objid": 1003, "sensor": "Disk", "status": "Down", "tags": "staging"}
+		]}`)
+	}))
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 0, 10*time.Second)
+	ds := &Datasource{api: api, timezone: time.UTC}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"text","property":"sensor","objid":"1003","sensor":"Disk","tags":["production"],"filterProperty":"status"}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if resp.Frames[0].Fields[1].Len() != 0 {
+		t.Errorf("Expected no value for an object without the tags, got %d", resp.Frames[0].Fields[1].Len())
+	}
+	for _, q := range queries {
+		if q.Has("filter_sensor") {
+			t.Errorf("Expected no fallback to the name, got %v", q)
+		}
+	}
+
+	queries = nil
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "sensors", URL: "sensors?tags=production"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	var sensors PrtgSensorsListResponse
+	if err := json.Unmarshal(respSender.body, &sensors); err != nil || len(sensors.Sensors) != 1 {
+		t.Errorf("Expected one tagged sensor, got %s", respSender.body)
+	}
+	if len(queries) != 1 || queries[0].Get("filter_tags") != "@tag(production)" {
+		t.Errorf("Unexpected requests %v", queries)
+	}
+
+	respSender = &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "groups", URL: "groups?tags=a(b"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid tag, got %v", respSender.status)
+	}
+}
+
+// ✅ listFilter test: Rollups scope by objid, property queries by the objid of the parent
+func TestListFilter_Scoping(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, q)
+		switch q.Get("content") {
+		case "devices":
+			fmt.Fprint(w, `{"devices": [
+				{"objid": 40, "group": "Berlin", "device": "Router"},
+				{"objid": 41, "group": "Munich", "device": "Router"}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"sensors": [{"objid": 1, "sensor": "Ping", "datetime": "2025-02-15T12:00:00Z", "status": "Up", "status_raw": 3}]}`)
+		}
+	}))
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"statusRollup","objids":["3","1"]}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if ids := queries[0]["filter_objid"]; len(ids) != 2 || ids[0] != "1" || ids[1] != "3" {
+		t.Errorf("Expected the sensors to be scoped to objids 1 and 3, got %v", queries[0])
+	}
+	if ids := queries[1]["filter_objid"]; queries[1].Get("content") != "devices" || len(ids) != 1 || ids[0] != "3" {
+		t.Errorf("Expected the devices to be scoped to the missing objid 3, got %v", queries[1])
+	}
+
+	// The device name is ambiguous without the group
+	for _, tt := range []struct {
+		json     string
+		parentId string
+	}{
+		{`{"queryType":"text","property":"sensor","group":"Munich","device":"Router","sensor":"Ping","filterProperty":"status"}`, "41"},
+		{`{"queryType":"text","property":"sensor","device":"Router","sensor":"Ping","filterProperty":"status"}`, ""},
+	} {
+		queries = nil
+		resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{RefID: "A", JSON: []byte(tt.json)})
+		if resp.Error != nil {
+			t.Fatalf("Unexpected error: %v", resp.Error)
+		}
+		last := queries[len(queries)-1]
+		if last.Get("content") != "sensors" || last.Get("id") != tt.parentId {
+			t.Errorf("Expected the sensors to be scoped to parent %q, got %v", tt.parentId, last)
+		}
+	}
+}
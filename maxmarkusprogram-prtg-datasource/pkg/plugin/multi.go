@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// maxMultiSensors bounds the sensors of a multi query, each costs at least one historic request.
+const maxMultiSensors = 100
+
+// multiSensorIds returns the sensor ids of a multi query. Empty and duplicate ids are skipped.
+func multiSensorIds(sensors []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range sensors {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid query: invalid sensor id %q", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("invalid query: missing sensors")
+	}
+	if len(ids) > maxMultiSensors {
+		return nil, fmt.Errorf("invalid query: %d sensors selected, at most %d are supported", len(ids), maxMultiSensors)
+	}
+	return ids, nil
+}
+
+// handleMultiQuery runs the metrics query for every sensor id in Sensors and returns the frames
+// of all sensors, each series named after its sensor. A failing sensor is reported in a notice,
+// the query only fails if every sensor fails.
+func (d *Datasource) handleMultiQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	ids, err := multiSensorIds(qm.Sensors)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	// Sensor names distinguish the series, the id is used if the sensor list is unavailable
+	names := make(map[string]string, len(ids))
+	if sensors, err := cachedTree(ctx, d.treeCache, "sensors", d.api.GetSensors); err != nil {
+		backend.Logger.Warn("Sensor names unavailable, using ids", "error", err)
+	} else {
+		for _, sensor := range sensors.Sensors {
+			names[strconv.FormatInt(sensor.ObjectId, 10)] = sensor.Sensor
+		}
+	}
+
+	// The sensors are queried concurrently, bounded like the bulk channels route
+	responses := make([]backend.DataResponse, len(ids))
+	sem := make(chan struct{}, bulkChannelConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		sensorQm := qm
+		sensorQm.ObjectId = id
+		sensorQm.Sensors = nil
+		sensorQm.Sensor = names[id]
+		if sensorQm.Sensor == "" {
+			sensorQm.Sensor = id
+		}
+		sensorQm.IncludeSensorName = true
+
+		wg.Add(1)
+		go func(i int, sensorQm queryModel) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			responses[i] = d.handleMetricsQuery(ctx, query, sensorQm)
+		}(i, sensorQm)
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, id := range ids {
+		if responses[i].Error != nil {
+			backend.Logger.Warn("Sensor of multi query failed", "objectId", id, "error", responses[i].Error)
+			failed = append(failed, fmt.Sprintf("%s (%v)", id, responses[i].Error))
+			continue
+		}
+		response.Frames = append(response.Frames, responses[i].Frames...)
+	}
+
+	if len(response.Frames) == 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("all sensors failed: %s", strings.Join(failed, "; ")))
+	}
+	if len(failed) > 0 {
+		response.Frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Sensors failed: %s", strings.Join(failed, "; ")),
+		})
+	}
+	return response
+}
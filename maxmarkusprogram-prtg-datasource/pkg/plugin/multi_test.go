@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ multiSensorIds test: Ids are trimmed, deduplicated and validated
+func TestMultiSensorIds(t *testing.T) {
+	ids, err := multiSensorIds([]string{" 1 ", "2", "", "1"})
+	if err != nil || !reflect.DeepEqual(ids, []string{"1", "2"}) {
+		t.Errorf("Expected [1 2], got %v (%v)", ids, err)
+	}
+
+	for _, sensors := range [][]string{nil, {" "}, {"1", "abc"}} {
+		if _, err := multiSensorIds(sensors); err == nil {
+			t.Errorf("Expected an error for %q", sensors)
+		}
+	}
+}
+
+// ✅ Multi query: One series per sensor, a failing sensor is reported in a notice
+func TestQueryData_Multi(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"treesize": 2, "sensors": [
+			{"objid": 1, "sensor": "Web 1"},
+			{"objid": 2, "sensor": "Web 2"}
+		]}`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		load, ok := map[string]int{"1": 10, "2": 30, "3": 5}[r.URL.Query().Get("id")]
+		if !ok {
+			http.Error(w, "unknown sensor", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": %d}]}`, load)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC}
+	request := func(json string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(json),
+			TimeRange: histdataTimeRange,
+		})
+	}
+
+	resp := request(`{"queryType":"multi","sensors":["1","2","3","4"],"channel":"CPU Load"}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	expected := []struct {
+		name  string
+		value float64
+	}{
+		{"Web 1 - CPU Load", 10},
+		{"Web 2 - CPU Load", 30},
+		{"3 - CPU Load", 5},
+	}
+	if len(resp.Frames) != len(expected) {
+		t.Fatalf("Expected %d frames, got %d", len(expected), len(resp.Frames))
+	}
+	for i, e := range expected {
+		field := resp.Frames[i].Fields[1]
+		if field.Config.DisplayName != e.name || field.At(0).(float64) != e.value {
+			t.Errorf("Frame %d: expected %s = %v, got %s = %v", i, e.name, e.value, field.Config.DisplayName, field.At(0))
+		}
+	}
+
+	var warned bool
+	for _, notice := range resp.Frames[0].Meta.Notices {
+		warned = warned || strings.Contains(notice.Text, "Sensors failed: 4")
+	}
+	if !warned {
+		t.Errorf("Expected a notice for sensor 4, got %+v", resp.Frames[0].Meta.Notices)
+	}
+
+	// The query fails only if every sensor fails
+	if resp := request(`{"queryType":"multi","sensors":["4","5"],"channel":"CPU Load"}`); resp.Error == nil {
+		t.Errorf("Expected an error if all sensors fail")
+	}
+}
@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"strings"
+	"time"
+)
+
+// Policies for objects without datetime, e.g. sensors PRTG has never polled.
+const (
+	neverPolledDrop    = "drop"    // skip the object (default)
+	neverPolledInclude = "include" // keep the object with an epoch-zero timestamp and a notice
+)
+
+// neverPolledTime is the timestamp of objects without datetime under the include policy.
+var neverPolledTime = time.Unix(0, 0).UTC()
+
+// normalizeNeverPolledPolicy returns a valid policy, unknown values fall back to drop.
+func normalizeNeverPolledPolicy(policy string) string {
+	if strings.ToLower(strings.TrimSpace(policy)) == neverPolledInclude {
+		return neverPolledInclude
+	}
+	return neverPolledDrop
+}
+
+// isNeverPolled reports whether datetime is PRTG's placeholder for a missing data time.
+func isNeverPolled(datetime string) bool {
+	switch strings.TrimSpace(cleanMessageHTML(datetime)) {
+	case "", "-":
+		return true
+	}
+	return false
+}
+
+// propertyTimestamp parses the datetime of an object of a property query. With the include
+// policy a missing datetime yields neverPolledTime and neverPolled is true. The datetime is read in loc;
+// if its format is unknown, e.g. because of the date format setting of the server, the PRTG float date
+// raw is used instead.
+func (d *Datasource) propertyTimestamp(datetime string, raw float64, loc *time.Location) (timestamp time.Time, neverPolled bool, err error) {
+	if d.neverPolledPolicy == neverPolledInclude && isNeverPolled(datetime) {
+		return neverPolledTime, true, nil
+	}
+	timestamp, _, err = parsePRTGDateTimeIn(datetime, loc)
+	if err != nil {
+		if t, ok := prtgFloatTime(raw, loc); ok {
+			return t, false, nil
+		}
+	}
+	return timestamp, false, err
+}
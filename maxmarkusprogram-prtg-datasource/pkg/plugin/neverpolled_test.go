@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestNormalizeNeverPolledPolicy(t *testing.T) {
+	tests := map[string]string{
+		"":         neverPolledDrop,
+		"drop":     neverPolledDrop,
+		"Include":  neverPolledInclude,
+		"whatever": neverPolledDrop,
+	}
+	for input, expected := range tests {
+		if got := normalizeNeverPolledPolicy(input); got != expected {
+			t.Errorf("normalizeNeverPolledPolicy(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestPropertyQueryNeverPolledSensor(t *testing.T) {
+	mockResponse := `{"sensors": [
+		{"sensor": "New Sensor", "datetime": "", "status": "Unknown"},
+		{"sensor": "New Sensor", "datetime": "2025-02-15T12:00:00Z", "status": "Up"}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"text","property":"sensor","sensor":"New Sensor","filterProperty":"status"}`),
+	}
+
+	t.Run("Default drops the sensor", func(t *testing.T) {
+		ds := &Datasource{api: api}
+		resp := ds.query(context.Background(), backend.PluginContext{}, query)
+		if len(resp.Frames) != 1 {
+			t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+		}
+		if n := resp.Frames[0].Fields[0].Len(); n != 1 {
+			t.Errorf("Expected 1 row, got %d", n)
+		}
+		if resp.Frames[0].Meta != nil && len(resp.Frames[0].Meta.Notices) > 0 {
+			t.Errorf("Expected no notice, got %v", resp.Frames[0].Meta.Notices)
+		}
+	})
+
+	t.Run("Include keeps the sensor at epoch zero", func(t *testing.T) {
+		ds := &Datasource{api: api, neverPolledPolicy: neverPolledInclude}
+		resp := ds.query(context.Background(), backend.PluginContext{}, query)
+		if len(resp.Frames) != 1 {
+			t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+		}
+		frame := resp.Frames[0]
+		if n := frame.Fields[0].Len(); n != 2 {
+			t.Fatalf("Expected 2 rows, got %d", n)
+		}
+		if ts := frame.Fields[0].At(0).(time.Time); !ts.Equal(time.Unix(0, 0)) {
+			t.Errorf("Expected the epoch-zero timestamp, got %v", ts)
+		}
+		if frame.Meta == nil || len(frame.Meta.Notices) != 1 || !strings.Contains(frame.Meta.Notices[0].Text, "never polled") {
+			t.Errorf("Expected a never polled notice, got %+v", frame.Meta)
+		}
+	})
+}
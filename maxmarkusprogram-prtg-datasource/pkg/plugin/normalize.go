@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// normalizeUnitLargest normalizes all series to the largest unit found among them.
+const normalizeUnitLargest = "largest"
+
+// captionUnit is a unit PRTG uses in value captions. factor converts to the base unit of
+// the family, grafana is the matching Grafana unit id.
+type captionUnit struct {
+	name    string
+	family  string
+	factor  float64
+	grafana string
+}
+
+// captionUnits are the units that can be normalized, PRTG uses binary prefixes for bytes.
+var captionUnits = []captionUnit{
+	{"bit/s", "bitrate", 1, "bps"},
+	{"kbit/s", "bitrate", 1e3, "Kbits"},
+	{"Mbit/s", "bitrate", 1e6, "Mbits"},
+	{"Gbit/s", "bitrate", 1e9, "Gbits"},
+	{"Tbit/s", "bitrate", 1e12, "Tbits"},
+	{"Byte/s", "byterate", 1, "binBps"},
+	{"KByte/s", "byterate", 1 << 10, "KiBs"},
+	{"MByte/s", "byterate", 1 << 20, "MiBs"},
+	{"GByte/s", "byterate", 1 << 30, "GiBs"},
+	{"TByte/s", "byterate", 1 << 40, "TiBs"},
+	{"Byte", "bytes", 1, "bytes"},
+	{"KByte", "bytes", 1 << 10, "kbytes"},
+	{"MByte", "bytes", 1 << 20, "mbytes"},
+	{"GByte", "bytes", 1 << 30, "gbytes"},
+	{"TByte", "bytes", 1 << 40, "tbytes"},
+	{"µs", "time", 1e-6, "µs"},
+	{"msec", "time", 1e-3, "ms"},
+	{"ms", "time", 1e-3, "ms"},
+	{"sec", "time", 1, "s"},
+	{"s", "time", 1, "s"},
+}
+
+// lookupCaptionUnit finds a unit by name, case insensitive.
+func lookupCaptionUnit(name string) (captionUnit, bool) {
+	name = strings.TrimSpace(name)
+	for _, unit := range captionUnits {
+		if strings.EqualFold(unit.name, name) {
+			return unit, true
+		}
+	}
+	return captionUnit{}, false
+}
+
+// parseCaption splits a caption like "1.234 kbit/s" into its number and unit.
+func parseCaption(caption string) (float64, captionUnit, bool) {
+	text := strings.TrimSpace(cleanMessageHTML(caption))
+	number, rest, ok := splitFormattedValue(text)
+	if !ok {
+		return 0, captionUnit{}, false
+	}
+	unit, ok := lookupCaptionUnit(rest)
+	return number, unit, ok
+}
+
+// seriesUnit detects the unit of a series from its captions and converts all values to it.
+// The most common unit wins; points with another unit of the same family are converted, points
+// without a unit in their caption keep their value. ok is false if the captions contain no
+// known unit, the values are then returned unchanged.
+func seriesUnit(values []float64, captions []string) ([]float64, captionUnit, bool) {
+	counts := make(map[string]int)
+	var dominant captionUnit
+	for _, caption := range captions {
+		if _, unit, ok := parseCaption(caption); ok {
+			counts[unit.name]++
+			if counts[unit.name] > counts[dominant.name] {
+				dominant = unit
+			}
+		}
+	}
+	if dominant.name == "" || len(captions) != len(values) {
+		return values, captionUnit{}, false
+	}
+
+	result := make([]float64, len(values))
+	for i, value := range values {
+		if number, unit, ok := parseCaption(captions[i]); ok && unit.family == dominant.family {
+			result[i] = number * unit.factor / dominant.factor
+			continue
+		}
+		result[i] = value
+	}
+	return result, dominant, true
+}
+
+// normalizeUnits scales the value fields of all frames that requested unit normalization to a
+// common unit per unit family. The target is the unit requested by the query, or the largest
+// unit among the frames for "largest". It may be called repeatedly, e.g. per query and then
+// across all queries of a request, as the frames record the current unit of every value field.
+func normalizeUnits(frames []*data.Frame) {
+	type normalized struct {
+		field  *data.Field
+		meta   map[string]interface{}
+		unit   captionUnit
+		target string
+	}
+
+	var series []normalized
+	var merged []map[string]interface{}
+	largest := make(map[string]captionUnit)
+	for _, frame := range frames {
+		if frame == nil || frame.Meta == nil || len(frame.Fields) < 2 {
+			continue
+		}
+		custom, ok := frame.Meta.Custom.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		target, _ := custom["normalizeUnit"].(string)
+		if target == "" {
+			continue
+		}
+
+		fields, metas := valueFieldMetas(frame, custom)
+		if fields == nil {
+			continue
+		}
+		if _, ok := custom["channels"]; ok {
+			merged = append(merged, custom)
+		}
+		for i, field := range fields {
+			name, _ := metas[i]["unit"].(string)
+			unit, ok := lookupCaptionUnit(name)
+			if !ok {
+				continue
+			}
+			series = append(series, normalized{field: field, meta: metas[i], unit: unit, target: target})
+			if unit.factor > largest[unit.family].factor {
+				largest[unit.family] = unit
+			}
+		}
+	}
+
+	for _, s := range series {
+		target, ok := lookupCaptionUnit(s.target)
+		if !ok || target.family != s.unit.family {
+			target = largest[s.unit.family]
+		}
+
+		ratio := s.unit.factor / target.factor
+		for i := 0; i < s.field.Len(); i++ {
+			if v, ok := s.field.ConcreteAt(i); ok {
+				if f, ok := v.(float64); ok {
+					s.field.SetConcrete(i, f*ratio)
+				}
+			}
+		}
+		if s.field.Config == nil {
+			s.field.Config = &data.FieldConfig{}
+		}
+		s.field.Config.Unit = target.grafana
+		s.meta["unit"] = target.name
+		if summary, ok := s.meta["summary"].(*seriesSummary); ok {
+			summary.scale(ratio)
+		}
+	}
+
+	// The metadata of a merged frame is that of its first channel
+	for _, custom := range merged {
+		if channels, ok := custom["channels"].([]interface{}); ok && len(channels) > 0 {
+			if first, ok := channels[0].(map[string]interface{}); ok {
+				custom["unit"] = first["unit"]
+			}
+		}
+	}
+}
+
+// valueFieldMetas returns the numeric value fields of a frame with the metadata recording
+// their unit. A channel frame has one value field described by the frame metadata, a frame
+// merged by mergeChannelFrames has one per channel, described by the entries of "channels"
+// in the same order. fields is nil if the fields cannot be matched with their metadata.
+func valueFieldMetas(frame *data.Frame, custom map[string]interface{}) ([]*data.Field, []map[string]interface{}) {
+	var fields []*data.Field
+	for _, field := range frame.Fields[1:] {
+		if field.Type().Numeric() {
+			fields = append(fields, field)
+		}
+	}
+
+	channels, isMerged := custom["channels"].([]interface{})
+	if !isMerged {
+		if len(fields) != 1 {
+			return nil, nil
+		}
+		return fields, []map[string]interface{}{custom}
+	}
+	if len(fields) != len(channels) {
+		return nil, nil
+	}
+	metas := make([]map[string]interface{}, len(channels))
+	for i, channel := range channels {
+		meta, ok := channel.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		metas[i] = meta
+	}
+	return fields, metas
+}
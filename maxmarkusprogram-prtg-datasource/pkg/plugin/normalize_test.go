@@ -0,0 +1,176 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ parseCaption test: Number and unit from PRTG captions
+func TestParseCaption(t *testing.T) {
+	tests := []struct {
+		caption  string
+		number   float64
+		unit     string
+		expected bool
+	}{
+		{"512 kbit/s", 512, "kbit/s", true},
+		{"1,5 Mbit/s", 1.5, "Mbit/s", true},
+		{"12 msec", 12, "msec", true},
+		{"3 GByte", 3, "GByte", true},
+		{"45 %", 45, "", false},
+		{"No data", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		number, unit, ok := parseCaption(tt.caption)
+		if ok != tt.expected || (ok && (number != tt.number || unit.name != tt.unit)) {
+			t.Errorf("parseCaption(%q) = %v %q %v; expected %v %q %v", tt.caption, number, unit.name, ok, tt.number, tt.unit, tt.expected)
+		}
+	}
+}
+
+// ✅ seriesUnit test: Mixed units within a series are converted to the most common one
+func TestSeriesUnit(t *testing.T) {
+	values, unit, ok := seriesUnit([]float64{0, 0, 0}, []string{"500 kbit/s", "1,5 Mbit/s", "800 kbit/s"})
+	if !ok || unit.name != "kbit/s" {
+		t.Fatalf("Expected kbit/s, got %q (%v)", unit.name, ok)
+	}
+	expected := []float64{500, 1500, 800}
+	for i := range expected {
+		if math.Abs(values[i]-expected[i]) > 1e-9 {
+			t.Errorf("Expected %v, got %v", expected, values)
+			break
+		}
+	}
+
+	if _, _, ok := seriesUnit([]float64{1}, []string{"1 %"}); ok {
+		t.Errorf("Expected no unit for percent captions")
+	}
+}
+
+// ✅ Unit normalization across the queries of a panel
+func TestQueryData_NormalizeUnits(t *testing.T) {
+	mux := http.NewServeMux()
+	// PRTG delivers the caption and the raw value in bytes per second
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("id") {
+		case "1":
+			fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Traffic": "500 kbit/s", "Traffic(RAW)": 62500}]}`)
+		default:
+			fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Traffic": "2 Mbit/s", "Traffic(RAW)": 250000}]}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	timeRange := histdataTimeRange
+	request := func(normalizeUnit string) *backend.QueryDataResponse {
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"queryType":"metrics","objid":"1","channel":"Traffic","normalizeUnit":"` + normalizeUnit + `"}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"queryType":"metrics","objid":"2","channel":"Traffic","normalizeUnit":"` + normalizeUnit + `"}`)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("QueryData failed: %v", err)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		normalizeUnit string
+		expectedA     float64
+		expectedB     float64
+		expectedUnit  string
+	}{
+		{"largest", 0.5, 2, "Mbits"},
+		{"kbit/s", 500, 2000, "Kbits"},
+		{"", 62500, 250000, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.normalizeUnit, func(t *testing.T) {
+			resp := request(tt.normalizeUnit)
+			a := resp.Responses["A"].Frames[0].Fields[1]
+			b := resp.Responses["B"].Frames[0].Fields[1]
+			if math.Abs(a.At(0).(float64)-tt.expectedA) > 1e-9 || math.Abs(b.At(0).(float64)-tt.expectedB) > 1e-9 {
+				t.Errorf("Expected %v and %v, got %v and %v", tt.expectedA, tt.expectedB, a.At(0), b.At(0))
+			}
+			if a.Config.Unit != tt.expectedUnit || b.Config.Unit != tt.expectedUnit {
+				t.Errorf("Expected unit %q, got %q and %q", tt.expectedUnit, a.Config.Unit, b.Config.Unit)
+			}
+		})
+	}
+}
+
+// ✅ Cross-query normalization scales every channel of a merged multi-channel frame
+func TestQueryData_NormalizeUnitsMergedFrame(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("id") {
+		case "1":
+			fmt.Fprint(w, `{"histdata": [
+				{"datetime": "2025-02-15T12:00:00Z", "In": "500 kbit/s", "In(RAW)": 62500, "Out": "400 kbit/s", "Out(RAW)": 50000},
+				{"datetime": "2025-02-15T12:01:00Z", "In": "600 kbit/s", "In(RAW)": 75000}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Traffic": "2 Mbit/s", "Traffic(RAW)": 250000}]}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", TimeRange: histdataTimeRange, JSON: []byte(`{"queryType":"metrics","objid":"1","channels":["In","Out"],"normalizeUnit":"largest"}`)},
+			{RefID: "B", TimeRange: histdataTimeRange, JSON: []byte(`{"queryType":"metrics","objid":"2","channel":"Traffic","normalizeUnit":"largest"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("QueryData failed: %v", err)
+	}
+
+	frames := resp.Responses["A"].Frames
+	if len(frames) != 1 || len(frames[0].Fields) != 3 {
+		t.Fatalf("Expected one merged frame with In and Out, got %d frames", len(frames))
+	}
+	// NaN marks a null, Out has no point at the second timestamp
+	expected := map[string][]float64{
+		"In":  {0.5, 0.6},
+		"Out": {0.4, math.NaN()},
+	}
+	for _, field := range frames[0].Fields[1:] {
+		if field.Config.Unit != "Mbits" {
+			t.Errorf("%s: expected unit Mbits, got %q", field.Name, field.Config.Unit)
+		}
+		for i, want := range expected[field.Name] {
+			got, ok := field.ConcreteAt(i)
+			if math.IsNaN(want) {
+				if ok {
+					t.Errorf("%s[%d]: expected null, got %v", field.Name, i, got)
+				}
+				continue
+			}
+			if !ok || math.Abs(got.(float64)-want) > 1e-9 {
+				t.Errorf("%s[%d]: expected %v, got %v", field.Name, i, want, got)
+			}
+		}
+	}
+	if custom := frames[0].Meta.Custom.(map[string]interface{}); custom["unit"] != "Mbit/s" {
+		t.Errorf("Expected the merged frame unit Mbit/s, got %v", custom["unit"])
+	}
+
+	b := resp.Responses["B"].Frames[0].Fields[1]
+	if b.At(0).(float64) != 2 || b.Config.Unit != "Mbits" {
+		t.Errorf("Expected 2 Mbits for B, got %v %s", b.At(0), b.Config.Unit)
+	}
+}
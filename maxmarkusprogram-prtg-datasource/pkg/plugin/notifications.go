@@ -0,0 +1,52 @@
+package plugin
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// notificationCount returns the number of notification triggers PRTG reports for a sensor.
+// The notifiesx column is a display text like "2 Triggers" or "(Inherited) 1 Trigger".
+// ok is false if the column is missing or contains no number.
+func notificationCount(notifiesx string) (int, bool) {
+	text := strings.TrimSpace(cleanMessageHTML(notifiesx))
+	if text == "" {
+		return 0, false
+	}
+
+	start := strings.IndexFunc(text, unicode.IsDigit)
+	if start < 0 {
+		return 0, false
+	}
+	end := start
+	for end < len(text) && text[end] >= '0' && text[end] <= '9' {
+		end++
+	}
+	count, err := strconv.Atoi(text[start:end])
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
+
+// triggeredNotifications returns the alarms that currently trigger notifications, together
+// with their trigger count. Alarms without a readable count are kept with a nil count unless
+// onlyNotified is set, as PRTG may not report the column for every sensor.
+func triggeredNotifications(alarms []PrtgSensorListItemStruct, onlyNotified bool) ([]PrtgSensorListItemStruct, []*int64) {
+	sensors := make([]PrtgSensorListItemStruct, 0, len(alarms))
+	counts := make([]*int64, 0, len(alarms))
+	for _, alarm := range alarms {
+		var count *int64
+		if n, ok := notificationCount(alarm.Notifiesx); ok {
+			v := int64(n)
+			count = &v
+		}
+		if onlyNotified && (count == nil || *count == 0) {
+			continue
+		}
+		sensors = append(sensors, alarm)
+		counts = append(counts, count)
+	}
+	return sensors, counts
+}
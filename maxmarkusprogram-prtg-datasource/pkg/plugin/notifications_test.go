@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ notificationCount test: Trigger count from the notifiesx display text
+func TestNotificationCount(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+		ok       bool
+	}{
+		{"2 Triggers", 2, true},
+		{"(Inherited) 1 Trigger", 1, true},
+		{"<span>12</span>", 12, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"Inherited", 0, false},
+	}
+
+	for _, tt := range tests {
+		count, ok := notificationCount(tt.text)
+		if count != tt.expected || ok != tt.ok {
+			t.Errorf("notificationCount(%q) = %d, %v; expected %d, %v", tt.text, count, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ Notifications query: Only sensors with triggered notifications
+func TestQueryData_Notifications(t *testing.T) {
+	mockResponse := `{"sensors": [
+		{"objid": 1001, "sensor": "Ping", "device": "Router", "status": "Down", "notifiesx": "2 Triggers"},
+		{"objid": 1002, "sensor": "CPU Load", "device": "Server", "status": "Warning", "notifiesx": "0"},
+		{"objid": 1003, "sensor": "Disk Free", "device": "Server", "status": "Down"}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"notifications"}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if rows, _ := resp.Frames[0].RowLen(); rows != 3 {
+		t.Fatalf("Expected 3 alarms, got %d", rows)
+	}
+	if count := resp.Frames[0].Fields[4].At(2).(*int64); count != nil {
+		t.Errorf("Expected no count for a sensor without notifiesx, got %d", *count)
+	}
+
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"notifications","notifiedOnly":true}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 1 {
+		t.Fatalf("Expected 1 notified sensor, got %d", rows)
+	}
+	if frame.Fields[1].At(0) != "Ping" || *frame.Fields[4].At(0).(*int64) != 2 {
+		t.Errorf("Unexpected notified sensor: %v / %v", frame.Fields[1].At(0), *frame.Fields[4].At(0).(*int64))
+	}
+}
+
+// ✅ Notifications query: No active notifications
+func TestQueryData_NoNotifications(t *testing.T) {
+	server, api := setupMockAPI(`{"sensors": []}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"notifications","notifiedOnly":true}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 0 {
+		t.Errorf("Expected an empty frame, got %d rows", rows)
+	}
+	if frame.Meta == nil || len(frame.Meta.Notices) != 1 {
+		t.Errorf("Expected a notice for no active notifications")
+	}
+}
@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pathCandidate is an object matching a name path.
+type pathCandidate struct {
+	ObjectId int64  `json:"objid"`
+	Path     string `json:"path"`
+}
+
+// errPathNotFound is returned if no object matches a name path.
+var errPathNotFound = errors.New("no object matches the path")
+
+// ambiguousPathError is returned if several objects match a name path.
+type ambiguousPathError struct {
+	Path       string
+	Candidates []pathCandidate
+}
+
+func (e *ambiguousPathError) Error() string {
+	ids := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		ids[i] = fmt.Sprintf("%d", c.ObjectId)
+	}
+	return fmt.Sprintf("path %q is ambiguous, candidates: %s", e.Path, strings.Join(ids, ", "))
+}
+
+// splitObjectPath splits a "Group/Device/Sensor" path into its name segments. Groups may
+// be nested ("Group/Subgroup/Device/Sensor") and paths may stop at the group or device level.
+func splitObjectPath(path string) ([]string, error) {
+	segments := strings.Split(strings.Trim(strings.TrimSpace(path), "/"), "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimSpace(segment)
+		if segments[i] == "" {
+			return nil, fmt.Errorf("invalid path %q: empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+// pathChild is an object below a parent in the object tree.
+type pathChild struct {
+	objid int64
+	name  string
+}
+
+// matchObjectPath returns the objects whose names match the path segments. The first
+// segment matches groups at any level, every further segment a subgroup, device or sensor
+// below the objects matched so far, following the parentid of the lists.
+func matchObjectPath(segments []string, groups []PrtgGroupListItemStruct, devices []PrtgDeviceListItemStruct, sensors []PrtgSensorListItemStruct) []pathCandidate {
+	// Object ids are unique across groups, devices and sensors, so one index serves all levels
+	children := make(map[int64][]pathChild)
+	for _, g := range groups {
+		children[g.ParentId] = append(children[g.ParentId], pathChild{objid: g.ObjectId, name: g.Group})
+	}
+	for _, dev := range devices {
+		children[dev.ParentId] = append(children[dev.ParentId], pathChild{objid: dev.ObjectId, name: dev.Device})
+	}
+	for _, s := range sensors {
+		children[s.ParentId] = append(children[s.ParentId], pathChild{objid: s.ObjectId, name: s.Sensor})
+	}
+
+	var candidates []pathCandidate
+	for _, g := range groups {
+		if g.Group == segments[0] {
+			candidates = append(candidates, pathCandidate{ObjectId: g.ObjectId, Path: g.Group})
+		}
+	}
+	for _, segment := range segments[1:] {
+		var next []pathCandidate
+		for _, candidate := range candidates {
+			for _, child := range children[candidate.ObjectId] {
+				if child.name == segment && child.objid != candidate.ObjectId {
+					next = append(next, pathCandidate{ObjectId: child.objid, Path: candidate.Path + "/" + child.name})
+				}
+			}
+		}
+		candidates = next
+	}
+	return candidates
+}
+
+// resolveObjectPath returns the objid of the object with the given name path.
+func (d *Datasource) resolveObjectPath(ctx context.Context, path string) (int64, error) {
+	segments, err := splitObjectPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Devices can only be matched from the second segment on and sensors from the third,
+	// so shorter paths skip the larger lists
+	var (
+		devices []PrtgDeviceListItemStruct
+		sensors []PrtgSensorListItemStruct
+	)
+	groupResponse, err := cachedTree(ctx, d.treeCache, "groups", d.api.GetGroups)
+	if err != nil {
+		return 0, err
+	}
+	if len(segments) > 1 {
+		response, err := cachedTree(ctx, d.treeCache, "devices", d.api.GetDevices)
+		if err != nil {
+			return 0, err
+		}
+		devices = response.Devices
+	}
+	if len(segments) > 2 {
+		response, err := cachedTree(ctx, d.treeCache, "sensors", d.api.GetSensors)
+		if err != nil {
+			return 0, err
+		}
+		sensors = response.Sensors
+	}
+
+	candidates := matchObjectPath(segments, groupResponse.Groups, devices, sensors)
+	switch len(candidates) {
+	case 0:
+		return 0, errPathNotFound
+	case 1:
+		return candidates[0].ObjectId, nil
+	}
+	return 0, &ambiguousPathError{Path: strings.Join(segments, "/"), Candidates: candidates}
+}
@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// setupTreeServer serves fixed group, device and sensor lists.
+func setupTreeServer() (*httptest.Server, *Api) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("content") {
+		case "groups":
+			fmt.Fprint(w, `{"groups": [
+				{"objid": 10, "group": "Network", "parentid": 1},
+				{"objid": 11, "group": "Servers", "parentid": 1},
+				{"objid": 12, "group": "Servers", "parentid": 1},
+				{"objid": 13, "group": "Berlin", "parentid": 11}
+			]}`)
+		case "devices":
+			fmt.Fprint(w, `{"devices": [
+				{"objid": 100, "group": "Network", "device": "Router", "parentid": 10},
+				{"objid": 101, "group": "Servers", "device": "Web", "parentid": 11},
+				{"objid": 102, "group": "Berlin", "device": "DB", "parentid": 13}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"sensors": [
+				{"objid": 1000, "group": "Network", "device": "Router", "sensor": "Ping", "parentid": 100},
+				{"objid": 1001, "group": "Network", "device": "Router", "sensor": "Traffic", "parentid": 100},
+				{"objid": 1002, "group": "Servers", "device": "Web", "sensor": "HTTP", "parentid": 101},
+				{"objid": 1003, "group": "Servers", "device": "Web", "sensor": "HTTP", "parentid": 101},
+				{"objid": 1004, "group": "Berlin", "device": "DB", "sensor": "Ping", "parentid": 102}
+			]}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	return server, NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+}
+
+// ✅ splitObjectPath test
+func TestSplitObjectPath(t *testing.T) {
+	segments, err := splitObjectPath("/Network / Router/Ping/")
+	if err != nil || len(segments) != 3 || segments[1] != "Router" {
+		t.Errorf("Unexpected segments %v, error %v", segments, err)
+	}
+	if segments, err := splitObjectPath("Servers/Berlin/DB/Ping"); err != nil || len(segments) != 4 {
+		t.Errorf("Expected nested groups to be accepted, got %v, error %v", segments, err)
+	}
+	for _, path := range []string{"", "Network//Ping"} {
+		if _, err := splitObjectPath(path); err == nil {
+			t.Errorf("Expected an error for path %q", path)
+		}
+	}
+}
+
+// ✅ resolveObjectPath test: Unique, ambiguous and unknown paths
+func TestResolveObjectPath(t *testing.T) {
+	server, api := setupTreeServer()
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+
+	tests := []struct {
+		path       string
+		expected   int64
+		candidates int
+	}{
+		{"Network", 10, 0},
+		{"Network/Router", 100, 0},
+		{"Network/Router/Traffic", 1001, 0},
+		{"Servers", 0, 2},
+		{"Servers/Web", 101, 0},
+		{"Servers/Web/HTTP", 0, 2},
+		// Nested groups follow the parent chain, only one "Servers" group contains "Berlin"
+		{"Servers/Berlin", 13, 0},
+		{"Servers/Berlin/DB/Ping", 1004, 0},
+		{"Berlin/DB/Ping", 1004, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			objid, err := ds.resolveObjectPath(context.Background(), tt.path)
+			if tt.candidates == 0 {
+				if err != nil || objid != tt.expected {
+					t.Errorf("Expected objid %d, got %d (error %v)", tt.expected, objid, err)
+				}
+				return
+			}
+			ambiguous, ok := err.(*ambiguousPathError)
+			if !ok || len(ambiguous.Candidates) != tt.candidates {
+				t.Errorf("Expected %d candidates, got %v", tt.candidates, err)
+			}
+		})
+	}
+
+	for _, path := range []string{"Network/Switch", "Network/Web", "Servers/DB/Ping"} {
+		if _, err := ds.resolveObjectPath(context.Background(), path); err != errPathNotFound {
+			t.Errorf("Expected errPathNotFound for %q, got %v", path, err)
+		}
+	}
+}
+
+// ✅ CallResource test: resolvepath route
+func TestCallResourceResolvePath(t *testing.T) {
+	server, api := setupTreeServer()
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"Network/Router/Ping", http.StatusOK},
+		{"Servers/Web/HTTP", http.StatusConflict},
+		{"Network/Router/Unknown", http.StatusNotFound},
+		{"", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := &backend.CallResourceRequest{
+				Path: "resolvepath",
+				URL:  "resolvepath?path=" + url.QueryEscape(tt.path),
+			}
+			respSender := &mockResourceResponseSender{}
+			if err := ds.CallResource(context.Background(), req, respSender); err != nil {
+				t.Fatalf("CallResource failed: %v", err)
+			}
+			if respSender.status != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, respSender.status)
+			}
+
+			var body struct {
+				ObjectId   int64           `json:"objid"`
+				Candidates []pathCandidate `json:"candidates"`
+			}
+			if err := json.Unmarshal(respSender.body, &body); err != nil {
+				t.Fatalf("Invalid response body: %v", err)
+			}
+			if tt.expectedStatus == http.StatusOK && body.ObjectId != 1000 {
+				t.Errorf("Expected objid 1000, got %d", body.ObjectId)
+			}
+			if tt.expectedStatus == http.StatusConflict && len(body.Candidates) != 2 {
+				t.Errorf("Expected 2 candidates, got %v", body.Candidates)
+			}
+		})
+	}
+}
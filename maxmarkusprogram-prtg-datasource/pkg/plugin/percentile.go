@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Percentile aggregation
+//
+// PRTG itself only delivers averaged historic data, so percentiles are computed in the
+// plugin from raw (avg=0) historicdata. Raw points are grouped into fixed-width time
+// buckets and the nearest-rank percentile is taken per bucket.
+//
+// Accuracy limits:
+//   - A bucket can only be as precise as the number of raw samples it holds. With a
+//     60 second scanning interval a 5 minute bucket contains five samples, so p95 and
+//     p99 both resolve to the bucket maximum.
+//   - Raw data is subject to the historicdata count ceiling; on long ranges the tail of
+//     the range may be missing and the affected buckets are simply absent.
+//   - Gaps in the raw data (paused sensors, timeouts) shrink the sample count of the
+//     affected buckets instead of being interpolated.
+
+// defaultPercentileBuckets is the number of buckets used when Grafana does not send an interval.
+const defaultPercentileBuckets = 100
+
+// minPercentileBucket is the smallest bucket width used for percentile aggregation.
+const minPercentileBucket = time.Minute
+
+// parsePercentileAggregation parses aggregations like "p95" or "p99" and returns the percentile.
+func parsePercentileAggregation(aggregation string) (float64, bool) {
+	aggregation = strings.ToLower(strings.TrimSpace(aggregation))
+	if !strings.HasPrefix(aggregation, "p") {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(aggregation[1:], 64)
+	if err != nil || p <= 0 || p >= 100 {
+		return 0, false
+	}
+	return p, true
+}
+
+// percentileBucketSize determines the bucket width for the given query interval and time range.
+func percentileBucketSize(interval time.Duration, from, to time.Time) time.Duration {
+	bucket := interval
+	if bucket <= 0 {
+		bucket = to.Sub(from) / defaultPercentileBuckets
+	}
+	if bucket < minPercentileBucket {
+		bucket = minPercentileBucket
+	}
+	return bucket
+}
+
+// percentile returns the nearest-rank percentile p (0 < p < 100) of the given values.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ parsePercentileAggregation test
+func TestParsePercentileAggregation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		ok       bool
+	}{
+		{"p95", 95, true},
+		{"P99", 99, true},
+		{"p50", 50, true},
+		{"avg", 0, false},
+		{"p100", 0, false},
+		{"p", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		p, ok := parsePercentileAggregation(tt.input)
+		if ok != tt.ok || p != tt.expected {
+			t.Errorf("parsePercentileAggregation(%q) = %v, %v; expected %v, %v", tt.input, p, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ percentile test with a known distribution
+func TestPercentile(t *testing.T) {
+	values := make([]float64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+
+	if got := percentile(values, 95); got != 95 {
+		t.Errorf("Expected p95 to be 95, got %v", got)
+	}
+	if got := percentile(values, 99); got != 99 {
+		t.Errorf("Expected p99 to be 99, got %v", got)
+	}
+	if got := percentile([]float64{42}, 95); got != 42 {
+		t.Errorf("Expected p95 of a single value to be 42, got %v", got)
+	}
+}
+
+// ✅ bucketAggregate test: Percentiles per bucket
+func TestBucketAggregate_Percentile(t *testing.T) {
+	start := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	var times []time.Time
+	var values []float64
+	// Two 10 minute buckets with 1..10 and 101..110
+	for i := 0; i < 20; i++ {
+		times = append(times, start.Add(time.Duration(i)*time.Minute))
+		if i < 10 {
+			values = append(values, float64(i+1))
+		} else {
+			values = append(values, float64(i+91))
+		}
+	}
+
+	outTimes, outValues := bucketAggregate(times, values, 10*time.Minute, bucketAggregation{percentile: 95}, time.UTC)
+	if len(outTimes) != 2 || len(outValues) != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(outTimes))
+	}
+	if !outTimes[0].Equal(start) || !outTimes[1].Equal(start.Add(10*time.Minute)) {
+		t.Errorf("Unexpected bucket start times: %v", outTimes)
+	}
+	if outValues[0] != 10 || outValues[1] != 110 {
+		t.Errorf("Expected bucket p95 values [10 110], got %v", outValues)
+	}
+}
+
+// ✅ Metrics query with p95 aggregation requests raw data
+func TestQueryData_PercentileAggregation(t *testing.T) {
+	start := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	var items []string
+	for i := 0; i < 20; i++ {
+		items = append(items, fmt.Sprintf(`{"datetime": "%s", "Latency": %d}`, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), i+1))
+	}
+	mockResponse := fmt.Sprintf(`{"histdata": [%s]}`, strings.Join(items, ","))
+
+	var avg string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		avg = r.URL.Query().Get("avg")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, mockResponse)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	queryJSON, _ := json.Marshal(map[string]string{
+		"queryType":   "metrics",
+		"objid":       "1234",
+		"channel":     "Latency",
+		"aggregation": "p95",
+	})
+	query := backend.DataQuery{
+		RefID:    "A",
+		JSON:     queryJSON,
+		Interval: 10 * time.Minute,
+		TimeRange: backend.TimeRange{
+			From: start.Add(-30 * 24 * time.Hour),
+			To:   start.Add(time.Hour),
+		},
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if avg != "0" {
+		t.Errorf("Expected raw data (avg=0) to be requested, got avg=%s", avg)
+	}
+	if len(resp.Frames) != 1 {
+		t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+	}
+	valueField := resp.Frames[0].Fields[1]
+	if valueField.Len() != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", valueField.Len())
+	}
+	if valueField.At(0).(float64) != 10 || valueField.At(1).(float64) != 20 {
+		t.Errorf("Expected bucket p95 values [10 20], got [%v %v]", valueField.At(0), valueField.At(1))
+	}
+	if !strings.HasSuffix(valueField.Config.DisplayName, "(p95)") {
+		t.Errorf("Expected display name to mention p95, got %q", valueField.Config.DisplayName)
+	}
+}
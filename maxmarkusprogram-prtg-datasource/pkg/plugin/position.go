@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// listSortPosition orders the object lists by the position the objects have in the PRTG
+// tree, as arranged by the users. Without a sort option the order of PRTG is kept.
+const listSortPosition = "position"
+
+// parseListSort reads the sort option of the list routes, e.g. "groups?sort=position".
+func parseListSort(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid request URL: %w", err)
+	}
+	switch order := u.Query().Get("sort"); order {
+	case "", listSortPosition:
+		return order, nil
+	default:
+		return "", fmt.Errorf("invalid sort option %q", order)
+	}
+}
+
+// sortByPosition orders items by their PRTG position within their parent. Positions are only
+// unique among siblings, so the items are grouped by parent first, in the order the parents
+// first appear in the list, and then ordered by position. The sort is stable, so objects with
+// the same parent and position keep the order of PRTG.
+func sortByPosition[T any](items []T, parent func(T) int64, position func(T) int64) {
+	rank := make(map[int64]int)
+	for _, item := range items {
+		if _, ok := rank[parent(item)]; !ok {
+			rank[parent(item)] = len(rank)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if pi, pj := rank[parent(items[i])], rank[parent(items[j])]; pi != pj {
+			return pi < pj
+		}
+		return position(items[i]) < position(items[j])
+	})
+}
@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestParseListSort(t *testing.T) {
+	tests := []struct {
+		url       string
+		expected  string
+		expectErr bool
+	}{
+		{"sensors", "", false},
+		{"sensors?sort=position", listSortPosition, false},
+		{"sensors?since=123&sort=position", listSortPosition, false},
+		{"sensors?sort=name", "", true},
+	}
+	for _, tt := range tests {
+		order, err := parseListSort(tt.url)
+		if (err != nil) != tt.expectErr || order != tt.expected {
+			t.Errorf("parseListSort(%q) = %q, %v", tt.url, order, err)
+		}
+	}
+}
+
+func TestCallResourceSortByPosition(t *testing.T) {
+	server, api := setupMockServer(`{"sensors": [
+		{"objid": 1, "sensor": "Alpha", "position_raw": 30},
+		{"objid": 2, "sensor": "Beta", "position_raw": 10},
+		{"objid": 3, "sensor": "Gamma", "position_raw": 20},
+		{"objid": 4, "sensor": "Delta", "position_raw": 10}
+	]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	sensorIds := func(url string) []int64 {
+		respSender := &mockResourceResponseSender{}
+		if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "sensors", URL: url}, respSender); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+		if respSender.status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %v: %s", respSender.status, respSender.body)
+		}
+		var response PrtgSensorsListResponse
+		if err := json.Unmarshal(respSender.body, &response); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+		var ids []int64
+		for _, s := range response.Sensors {
+			ids = append(ids, s.ObjectId)
+		}
+		return ids
+	}
+
+	if ids := sensorIds("sensors?sort=position"); !equalInt64s(ids, []int64{2, 4, 3, 1}) {
+		t.Errorf("Expected position order [2 4 3 1], got %v", ids)
+	}
+	// Positions are only compared between siblings, parents keep their PRTG order
+	nested, api2 := setupMockServer(`{"sensors": [
+		{"objid": 1, "sensor": "Alpha", "parentid": 200, "position_raw": 20},
+		{"objid": 2, "sensor": "Beta", "parentid": 100, "position_raw": 10},
+		{"objid": 3, "sensor": "Gamma", "parentid": 200, "position_raw": 10},
+		{"objid": 4, "sensor": "Delta", "parentid": 100, "position_raw": 5}
+	]}`, http.StatusOK)
+	defer nested.Close()
+	ds.api = api2
+	if ids := sensorIds("sensors?sort=position"); !equalInt64s(ids, []int64{3, 1, 4, 2}) {
+		t.Errorf("Expected parent and position order [3 1 4 2], got %v", ids)
+	}
+	ds.api = api
+
+	// Without the sort option the order of PRTG is kept
+	if ids := sensorIds("sensors"); !equalInt64s(ids, []int64{1, 2, 3, 4}) {
+		t.Errorf("Expected PRTG order [1 2 3 4], got %v", ids)
+	}
+
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "sensors", URL: "sensors?sort=name"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown sort option, got %v", respSender.status)
+	}
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ✅ Sorting by position works on a copy, the cached tree keeps the PRTG order
+func TestCallResourceSortByPosition_TreeCache(t *testing.T) {
+	server, api := setupMockServer(`{
+		"groups": [{"objid": 1, "position_raw": 30}, {"objid": 2, "position_raw": 10}, {"objid": 3, "position_raw": 20}],
+		"devices": [{"objid": 1, "position_raw": 30}, {"objid": 2, "position_raw": 10}, {"objid": 3, "position_raw": 20}]
+	}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, treeCache: newTTLCache(defaultTreeCacheTime)}
+	objectIds := func(url string) []int64 {
+		respSender := &mockResourceResponseSender{}
+		path := strings.SplitN(url, "?", 2)[0]
+		if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: path, URL: url}, respSender); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+		var response struct {
+			Groups  []PrtgGroupListItemStruct  `json:"groups"`
+			Devices []PrtgDeviceListItemStruct `json:"devices"`
+		}
+		if err := json.Unmarshal(respSender.body, &response); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+		var ids []int64
+		for _, g := range response.Groups {
+			ids = append(ids, g.ObjectId)
+		}
+		for _, dev := range response.Devices {
+			ids = append(ids, dev.ObjectId)
+		}
+		return ids
+	}
+
+	for _, content := range []string{"groups", "devices"} {
+		if ids := objectIds(content + "?sort=position"); !equalInt64s(ids, []int64{2, 3, 1}) {
+			t.Errorf("%s: expected position order [2 3 1], got %v", content, ids)
+		}
+		if ids := objectIds(content); !equalInt64s(ids, []int64{1, 2, 3}) {
+			t.Errorf("%s: expected the cached PRTG order [1 2 3] after a sorted request, got %v", content, ids)
+		}
+	}
+}
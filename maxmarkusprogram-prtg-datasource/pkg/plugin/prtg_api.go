@@ -1,14 +1,22 @@
 package plugin
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -17,33 +25,208 @@ import (
 
 // Api holds API-related configurations.
 type Api struct {
-	baseURL string
-	apiKey  string
-	timeout time.Duration
+	baseURL   string
+	apiKey    string
+	timeout   time.Duration
+	cacheTime time.Duration
+
+	// trustedRedirectHosts are hosts besides the PRTG host that redirects may lead to.
+	trustedRedirectHosts []string
+
+	// historicMaxPoints is the maximum number of points requested per historicdata request.
+	historicMaxPoints int
+
+	// allowedHosts are the only hosts requests are built for, the hosts of the base and failover URLs.
+	allowedHosts map[string]struct{}
+
+	// maxAttempts is the number of attempts per request, attemptTimeout bounds each attempt.
+	// The overall deadline is still given by timeout or the caller's context.
+	maxAttempts    int
+	attemptTimeout time.Duration
+
+	// retryDelay is the base delay of the exponential backoff between attempts, 0 uses retryBackoff.
+	retryDelay time.Duration
+
+	// defaultColumns are the deployment specific table columns per content type, see columns.go.
+	defaultColumns map[string]string
+
+	// truncation logs lists that exceed the fetched count, see truncation.go.
+	truncation *truncationWarner
+
+	// minAvgInterval is the smallest averaging interval in seconds requested from PRTG, 0 means no floor.
+	minAvgInterval int64
+
+	// endpointTimeouts override timeout per endpoint, e.g. "historicdata.json".
+	endpointTimeouts map[string]time.Duration
+
+	// responses caches list, status and object property responses for cacheTime, see cachedRequest.
+	responses *ttlCache
+
+	// dryRun logs requests instead of sending them, see dryrun.go.
+	dryRun bool
+
+	// debugResponses logs raw channel and historicdata responses, see SetDebugResponses.
+	debugResponses bool
+
+	// failoverURLs are the base URLs of further PRTG cluster nodes, tried in order if a node
+	// fails. activeURL is the node that answered last, empty means baseURL. See failover.go.
+	failoverURLs []string
+	activeURL    string
+	nodeMu       sync.Mutex
+
+	// proxyURL is the explicitly configured proxy, nil uses the proxy environment variables.
+	proxyURL *url.URL
+
+	// client is shared by all requests so connections are pooled and kept alive.
+	// Timeouts are applied per request through the context.
+	client *http.Client
 }
 
+// defaultHistoricMaxPoints is the count used for historicdata requests.
+const defaultHistoricMaxPoints = 50000
+
+// retryBackoff is the default base delay between two attempts, it doubles with every attempt.
+const retryBackoff = 200 * time.Millisecond
+
+// maxRetryBackoff caps the delay between two attempts.
+const maxRetryBackoff = 10 * time.Second
+
+// maxHistoricPagingDepth bounds how often a chunk that hit the count ceiling is halved again.
+const maxHistoricPagingDepth = 4
+
+// historicChunkConcurrency bounds the number of concurrent historicdata requests of a single query.
+const historicChunkConcurrency = 4
+
+// debugResponsesEnv enables the logging of raw responses for all datasources if set to true.
+const debugResponsesEnv = "PRTG_DEBUG_RESPONSES"
+
+// defaultRequestTimeout is the timeout of API requests if none is configured.
+const defaultRequestTimeout = 10 * time.Second
+
 // NewApi creates a new Api instance.
 // requestTimeout is used as timeout for API requests.
 func NewApi(baseURL, apiKey string, cacheTime, requestTimeout time.Duration) *Api {
-	return &Api{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		timeout: requestTimeout,
+	api := &Api{
+		baseURL:           baseURL,
+		apiKey:            apiKey,
+		timeout:           requestTimeout,
+		cacheTime:         cacheTime,
+		historicMaxPoints: defaultHistoricMaxPoints,
+		allowedHosts:      make(map[string]struct{}),
+		truncation:        newTruncationWarner(defaultTruncationWarnInterval),
+		responses:         newTTLCache(cacheTime),
+	}
+	api.debugResponses, _ = strconv.ParseBool(os.Getenv(debugResponsesEnv))
+	api.client = &http.Client{
+		Transport:     newDefaultTransport(nil),
+		CheckRedirect: api.checkRedirect,
+	}
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		api.allowedHosts[strings.ToLower(u.Host)] = struct{}{}
 	}
+	return api
 }
 
-// buildApiUrl creates a standardized PRTG API URL with given parameters.
+// newDefaultTransport returns the transport used unless another one is set with SetTransport.
+// Requests go through proxyURL if set, otherwise through HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func newDefaultTransport(proxyURL *url.URL) *http.Transport {
+	return &http.Transport{
+		Proxy: proxyFunc(proxyURL),
+		// Warning: InsecureSkipVerify should be reviewed in production environments!
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// SetTransport legt den http.RoundTripper fest, über den alle Anfragen gesendet werden.
+// It allows wrapping the transport, e.g. for tracing, or replacing it in tests. nil restores
+// the default transport. Host validation, redirect checks, retries and failover still apply.
+func (a *Api) SetTransport(transport http.RoundTripper) {
+	if transport == nil {
+		transport = newDefaultTransport(a.proxyURL)
+	}
+	a.client.Transport = transport
+}
+
+// proxyFunc returns the Proxy function of a transport for the given proxy URL.
+func proxyFunc(proxyURL *url.URL) func(*http.Request) (*url.URL, error) {
+	if proxyURL == nil {
+		return http.ProxyFromEnvironment
+	}
+	return http.ProxyURL(proxyURL)
+}
+
+// SetProxyURL legt einen Proxy fest, über den alle Anfragen gesendet werden. It overrides the
+// proxy environment variables, an empty URL restores them. Supported schemes are http, https
+// and socks5. A transport set with SetTransport is left unchanged unless it is an *http.Transport.
+func (a *Api) SetProxyURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	var proxyURL *url.URL
+	if raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", redactURL(raw), err)
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", redactURL(raw))
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: missing host", redactURL(raw))
+		}
+		proxyURL = u
+	}
+
+	a.proxyURL = proxyURL
+	if transport, ok := a.client.Transport.(*http.Transport); ok {
+		// Cloned, the transport may be shared with other Api instances
+		transport = transport.Clone()
+		transport.Proxy = proxyFunc(proxyURL)
+		a.client.Transport = transport
+	}
+	return nil
+}
+
+// Transport liefert den aktuellen http.RoundTripper, z. B. um ihn zu umhüllen.
+func (a *Api) Transport() http.RoundTripper {
+	return a.client.Transport
+}
+
+// buildApiUrl creates a standardized PRTG API URL with given parameters for the active node.
 func (a *Api) buildApiUrl(method string, params map[string]string) (string, error) {
-	baseUrl := fmt.Sprintf("%s/api/%s", a.baseURL, method)
+	return a.buildNodeUrl(a.ActiveBaseURL(), method, params)
+}
+
+// buildNodeUrl creates the PRTG API URL of a method on the node with the given base URL.
+func (a *Api) buildNodeUrl(nodeURL, method string, params map[string]string) (string, error) {
+	baseUrl := fmt.Sprintf("%s/api/%s", nodeURL, method)
 	u, err := url.Parse(baseUrl)
 	if err != nil {
 		return "", fmt.Errorf("invalid URL: %w", err)
 	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL: missing host in %q", nodeURL)
+	}
+	// Object ids and other parameters may come from dashboard variables, the API token is
+	// only ever sent to the configured PRTG nodes
+	if _, ok := a.allowedHosts[strings.ToLower(u.Host)]; !ok {
+		backend.Logger.Error("Rejected request to foreign host", "host", u.Host)
+		return "", fmt.Errorf("request host %q is not a configured PRTG host", u.Host)
+	}
 
 	q := url.Values{}
 	q.Set("apitoken", a.apiKey)
 
 	for key, value := range params {
+		// PRTG expects filter_status and filter_objid once per value, e.g. filter_status=5&filter_status=4
+		if key == "filter_status" || key == "filter_objid" {
+			for _, status := range strings.Split(value, ",") {
+				q.Add(key, strings.TrimSpace(status))
+			}
+			continue
+		}
 		q.Set(key, value)
 	}
 
@@ -51,6 +234,43 @@ func (a *Api) buildApiUrl(method string, params map[string]string) (string, erro
 	return u.String(), nil
 }
 
+// redactedApiUrl liefert die URL eines API-Endpunkts ohne Zugangsdaten.
+// It is meant for diagnostics, e.g. in health check messages.
+func (a *Api) redactedApiUrl(method string, params map[string]string) string {
+	apiUrl, err := a.buildApiUrl(method, params)
+	if err != nil {
+		return fmt.Sprintf("%s/api/%s", a.ActiveBaseURL(), method)
+	}
+	return redactURL(apiUrl)
+}
+
+// redactURL replaces the apitoken and passhash values of a URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	for _, key := range []string{"apitoken", "passhash"} {
+		if q.Has(key) {
+			q.Set(key, "REDACTED")
+		}
+	}
+	u.RawQuery = q.Encode()
+	u.User = nil
+	return u.String()
+}
+
+// redactSecrets removes the API token from a message, e.g. from a transport error
+// that embeds the full request URL.
+func (a *Api) redactSecrets(message string) string {
+	if a.apiKey == "" {
+		return message
+	}
+	message = strings.ReplaceAll(message, a.apiKey, "REDACTED")
+	return strings.ReplaceAll(message, url.QueryEscape(a.apiKey), "REDACTED")
+}
+
 // SetTimeout aktualisiert das Timeout für API-Anfragen.
 func (a *Api) SetTimeout(timeout time.Duration) {
 	if timeout > 0 {
@@ -58,133 +278,710 @@ func (a *Api) SetTimeout(timeout time.Duration) {
 	}
 }
 
-// baseExecuteRequest führt die HTTP-Anfrage durch und liefert den Response-Body.
-func (a *Api) baseExecuteRequest(endpoint string, params map[string]string) ([]byte, error) {
-	apiUrl, err := a.buildApiUrl(endpoint, params)
+// SetRetries legt die Anzahl der Versuche und das Timeout pro Versuch fest.
+// An attemptTimeout of zero lets every attempt use the remaining overall deadline.
+func (a *Api) SetRetries(maxAttempts int, attemptTimeout time.Duration) {
+	if maxAttempts > 0 {
+		a.maxAttempts = maxAttempts
+	}
+	if attemptTimeout >= 0 {
+		a.attemptTimeout = attemptTimeout
+	}
+}
+
+// SetDebugResponses schaltet das Protokollieren der Rohantworten ein oder aus.
+func (a *Api) SetDebugResponses(enabled bool) {
+	a.debugResponses = enabled
+}
+
+// debugResponse logs a raw response body at debug level if enabled.
+func (a *Api) debugResponse(name string, body []byte) {
+	if a.debugResponses {
+		backend.Logger.Debug("Raw response", "response", name, "body", string(body))
+	}
+}
+
+// SetDryRun schaltet den Dry-Run-Modus ein oder aus, siehe dryrun.go.
+func (a *Api) SetDryRun(dryRun bool) {
+	a.dryRun = dryRun
+}
+
+// SetRetryDelay legt die Basisverzögerung zwischen zwei Versuchen fest.
+// A delay of zero restores the default.
+func (a *Api) SetRetryDelay(delay time.Duration) {
+	if delay >= 0 {
+		a.retryDelay = delay
+	}
+}
+
+// backoffBase returns the configured base delay or the default.
+func (a *Api) backoffBase() time.Duration {
+	if a.retryDelay > 0 {
+		return a.retryDelay
+	}
+	return retryBackoff
+}
+
+// backoff returns the delay after the given failed attempt: the base delay doubled per
+// attempt and capped, with jitter so concurrent queries do not retry in lockstep.
+func (a *Api) backoff(attempt int) time.Duration {
+	delay := a.backoffBase()
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	// Equal jitter: half the delay is fixed, the other half random
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableStatus reports whether a response status indicates a transient failure of PRTG
+// or a proxy in front of it. Client errors like 400 or 403 are never retried.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// SetTruncationWarnInterval legt den Mindestabstand zwischen zwei Warnungen über abgeschnittene Listen fest.
+func (a *Api) SetTruncationWarnInterval(interval time.Duration) {
+	a.truncation = newTruncationWarner(interval)
+}
+
+// SetEndpointTimeout legt das Timeout für einen Endpunkt fest, z.B. "status.json".
+// A timeout of zero removes the override, the global timeout is used then.
+func (a *Api) SetEndpointTimeout(endpoint string, timeout time.Duration) {
+	if timeout <= 0 {
+		delete(a.endpointTimeouts, endpoint)
+		return
+	}
+	if a.endpointTimeouts == nil {
+		a.endpointTimeouts = make(map[string]time.Duration)
+	}
+	a.endpointTimeouts[endpoint] = timeout
+}
+
+// timeoutFor returns the timeout of an endpoint, the global timeout unless overridden.
+func (a *Api) timeoutFor(endpoint string) time.Duration {
+	if timeout, ok := a.endpointTimeouts[endpoint]; ok {
+		return timeout
+	}
+	return a.timeout
+}
+
+// SetHistoricMaxPoints legt die Anzahl der Punkte pro historicdata-Anfrage fest (count).
+// A value of zero or less restores the default.
+func (a *Api) SetHistoricMaxPoints(points int) {
+	if points <= 0 {
+		points = defaultHistoricMaxPoints
+	}
+	a.historicMaxPoints = points
+}
+
+// SetMinAvgInterval legt das kleinste avg-Intervall in Sekunden fest, 0 deaktiviert die Untergrenze.
+// The value is rounded up to the next valid PRTG interval.
+func (a *Api) SetMinAvgInterval(seconds int64) {
+	a.minAvgInterval = ceilAvgInterval(seconds)
+}
+
+// SetDefaultColumns legt die Standardspalten pro Inhaltstyp (groups, devices, sensors) fest.
+// Columns are validated against the whitelist, the columns the plugin needs are always added.
+// Content types with invalid columns keep the built-in columns, their errors are returned.
+func (a *Api) SetDefaultColumns(columns map[string][]string) error {
+	defaults := make(map[string]string, len(columns))
+	var errs []error
+	for content, list := range columns {
+		normalized, err := normalizeTableColumns(content, list)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		defaults[content] = normalized
+	}
+	a.defaultColumns = defaults
+	return errors.Join(errs...)
+}
+
+// SetTrustedRedirectHosts legt fest, zu welchen weiteren Hosts Redirects samt API-Token gefolgt wird.
+// Entries may be a host name ("proxy.example.com") or host and port ("proxy.example.com:8443").
+func (a *Api) SetTrustedRedirectHosts(hosts []string) {
+	a.trustedRedirectHosts = nil
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			a.trustedRedirectHosts = append(a.trustedRedirectHosts, host)
+		}
+	}
+}
+
+// isTrustedRedirect reports whether a redirect from the original URL to target may be followed with the API token.
+// Redirects on the same host are trusted unless they downgrade https to plain http.
+func (a *Api) isTrustedRedirect(original, target *url.URL) bool {
+	if strings.EqualFold(original.Scheme, "https") && !strings.EqualFold(target.Scheme, "https") {
+		return false
+	}
+	if strings.EqualFold(original.Host, target.Host) {
+		return true
+	}
+	for _, host := range a.trustedRedirectHosts {
+		if host == strings.ToLower(target.Host) || host == strings.ToLower(target.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRedirect follows redirects to trusted hosts and refuses all others.
+func (a *Api) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+
+	if !a.isTrustedRedirect(via[0].URL, req.URL) {
+		backend.Logger.Warn("Refused redirect to untrusted host", "host", req.URL.Host)
+		return fmt.Errorf("redirect to untrusted host %q refused", req.URL.Host)
+	}
+	if q := req.URL.Query(); q.Get("apitoken") == "" {
+		q.Set("apitoken", a.apiKey)
+		req.URL.RawQuery = q.Encode()
+	}
+	return nil
+}
+
+// baseExecuteRequest führt die HTTP-Anfrage im Kontext ctx durch, Abbrechen von ctx bricht die Anfrage ab.
+// The deadline is the timeout of the endpoint, see SetEndpointTimeout.
+func (a *Api) baseExecuteRequest(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
+	if a.dryRun {
+		return a.dryRunRequest(endpoint, params)
+	}
+	return a.executeRequest(ctx, endpoint, params)
+}
+
+// cachedRequest führt die Anfrage durch und speichert den Response-Body für cacheTime.
+// Expired entries are refreshed on the next request. Contexts from withoutResponseCache
+// skip the cache.
+func (a *Api) cachedRequest(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
+	if responseCacheDisabled(ctx) {
+		return a.baseExecuteRequest(ctx, endpoint, params)
+	}
+
+	key := responseCacheKey(endpoint, params)
+	if body, ok := a.responses.get(key); ok {
+		return body.([]byte), nil
+	}
+	body, err := a.baseExecuteRequest(ctx, endpoint, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build URL: %w", err)
+		return nil, err
+	}
+	a.responses.set(key, body)
+	return body, nil
+}
+
+// responseCacheKey returns the response cache key of a request, the endpoint with its encoded parameters.
+func responseCacheKey(endpoint string, params map[string]string) string {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	return endpoint + "?" + values.Encode()
+}
+
+// ClearCache verwirft alle zwischengespeicherten Antworten.
+func (a *Api) ClearCache() {
+	a.responses.clear()
+}
+
+// CacheStats liefert die Treffer, Fehlzugriffe und Verdrängungen des Antwort-Caches je Endpoint.
+func (a *Api) CacheStats() map[string]cacheStats {
+	return a.responses.stats()
+}
+
+// executeRequest führt die HTTP-Anfrage mit Wiederholungen durch und liefert den Response-Body.
+// The overall deadline is taken from ctx, or the client timeout if ctx has none. Every attempt
+// is additionally bounded by the per-attempt timeout, so a single slow attempt cannot use up
+// the whole budget. If a cluster node fails, the next node is tried within the same deadline,
+// see failover.go.
+func (a *Api) executeRequest(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok && a.timeoutFor(endpoint) > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeoutFor(endpoint))
+		defer cancel()
+	}
+
+	nodes := a.nodeOrder()
+	var lastErr error
+	for i, node := range nodes {
+		apiUrl, err := a.buildNodeUrl(node, endpoint, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build URL: %w", err)
+		}
+		nodeCtx, cancel := nodeContext(ctx, len(nodes)-i)
+		body, err := a.executeWithRetries(nodeCtx, apiUrl)
+		cancel()
+		if err == nil {
+			a.setActiveNode(node)
+			return body, nil
+		}
+		if !isFailoverError(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+		if i+1 < len(nodes) {
+			backend.Logger.Warn("PRTG node failed, trying the next cluster node",
+				"node", redactURL(node), "next", redactURL(nodes[i+1]), "error", a.redactSecrets(err.Error()))
+		}
+	}
+	return nil, lastErr
+}
+
+// executeWithRetries sendet die Anfrage an eine URL und wiederholt sie bei vorübergehenden Fehlern.
+func (a *Api) executeWithRetries(ctx context.Context, apiUrl string) ([]byte, error) {
+	maxAttempts := a.maxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, retryable, err := a.doRequest(ctx, a.client, apiUrl)
+		if err == nil {
+			return body, nil
+		}
+		if !retryable || attempt >= maxAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := a.backoff(attempt)
+		// Waiting past the deadline is pointless, fail right away instead
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+
+		backend.Logger.Warn("Request attempt failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request failed: %w", ctx.Err())
+		case <-time.After(delay):
+		}
 	}
+}
 
-	client := &http.Client{
-		Timeout: a.timeout,
-		Transport: &http.Transport{
-			// Warning: InsecureSkipVerify should be reviewed in production environments!
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+// doRequest führt einen einzelnen Versuch durch. retryable reports whether the failure
+// may succeed on a later attempt.
+func (a *Api) doRequest(ctx context.Context, client *http.Client, apiUrl string) (body []byte, retryable bool, err error) {
+	if a.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.attemptTimeout)
+		defer cancel()
 	}
 
-	req, err := http.NewRequest("GET", apiUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiUrl, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	// Setting Accept-Encoding explicitly disables the transparent decompression
+	// of net/http, gzip bodies are therefore decoded in readResponseBody.
+	req.Header.Set("Accept-Encoding", "gzip")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, true, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusForbidden {
-		log.DefaultLogger.Error("Access denied: please verify API token and permissions")
-		return nil, fmt.Errorf("access denied: please verify API token and permissions")
-	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		// PRTG explains rejected requests in the body, e.g. unknown ids or columns
+		resp.Body = io.NopCloser(io.LimitReader(resp.Body, maxErrorBodySize))
+		errorBody, _ := readResponseBody(resp)
+		apiErr := &PRTGAPIError{
+			StatusCode: resp.StatusCode,
+			Message:    a.redactSecrets(prtgErrorMessage(errorBody)),
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			log.DefaultLogger.Error("Authentication failed: please verify the API token")
+		case http.StatusForbidden:
+			log.DefaultLogger.Error("Access denied: the API token lacks the permissions for this request")
+		}
+		return nil, isRetryableStatus(resp.StatusCode), apiErr
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = readResponseBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, true, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	backend.Logger.Debug("Raw response body", "body", string(body))
-	return body, nil
+	return body, false, nil
+}
+
+// errXMLNotSupported is returned for XML bodies of endpoints that are only decoded as JSON.
+// The XML layout of tables and historic data (<item> rows, totalcount) differs from the JSON
+// structs, decoding it would silently yield empty lists.
+var errXMLNotSupported = errors.New("XML response not supported for this endpoint, expected JSON")
+
+// decodeResponse dekodiert einen JSON-Response-Body in v. XML bodies are rejected with
+// errXMLNotSupported, only the status list has an XML fallback, see decodeStatusResponse.
+func decodeResponse(body []byte, v interface{}) error {
+	if isXMLBody(body) {
+		return errXMLNotSupported
+	}
+	return json.Unmarshal(body, v)
+}
+
+// decodeStatusResponse dekodiert die Statusliste. Some PRTG versions answer status.json with
+// XML; such bodies are recognized by their leading "<" and decoded with the xml tags of
+// PrtgStatusListResponse, whose flat layout is the same in both formats.
+func decodeStatusResponse(body []byte, response *PrtgStatusListResponse) error {
+	if isXMLBody(body) {
+		return xml.Unmarshal(body, response)
+	}
+	return json.Unmarshal(body, response)
+}
+
+// isXMLBody reports whether a body is XML, ignoring a byte order mark and leading whitespace.
+func isXMLBody(body []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")), " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '<'
+}
+
+// readResponseBody liest den Response-Body und entpackt ihn bei Content-Encoding gzip.
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return io.ReadAll(resp.Body)
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
 }
 
 // GetStatusList ruft die Statusliste der PRTG-API ab.
-func (a *Api) GetStatusList() (*PrtgStatusListResponse, error) {
-	body, err := a.baseExecuteRequest("status.json", nil)
+func (a *Api) GetStatusList(ctx context.Context) (*PrtgStatusListResponse, error) {
+	body, err := a.cachedRequest(ctx, "status.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response PrtgStatusListResponse
+	if err := decodeStatusResponse(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &response, nil
+}
+
+// GetLiveStatusList ruft den Status ohne Cache ab, z.B. für den Health-Check.
+func (a *Api) GetLiveStatusList(ctx context.Context) (*PrtgStatusListResponse, error) {
+	body, err := a.baseExecuteRequest(ctx, "status.json", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var response PrtgStatusListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := decodeStatusResponse(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &response, nil
+}
+
+// Ping prüft Erreichbarkeit und Authentifizierung mit einer minimalen Anfrage ohne Cache und
+// liefert die Dauer der Anfrage. A single sensor objid is requested, unlike status.json.
+func (a *Api) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	body, err := a.baseExecuteRequest(ctx, "table.json", map[string]string{
+		"content": "sensors",
+		"columns": "objid",
+		"count":   "1",
+	})
+	latency := time.Since(start)
+	if err != nil {
+		return latency, err
+	}
+
+	var response struct {
+		TreeSize int64 `json:"treesize"`
+	}
+	if err := decodeResponse(body, &response); err != nil {
+		return latency, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return latency, nil
+}
+
+// GetSensorDetails ruft die Detailinformationen eines Sensors ab.
+func (a *Api) GetSensorDetails(ctx context.Context, objid string) (*PrtgSensorDetailsResponse, error) {
+	if objid == "" {
+		return nil, fmt.Errorf("invalid query: missing object ID")
+	}
+
+	body, err := a.baseExecuteRequest(ctx, "sensordetails.json", map[string]string{"id": objid})
+	if err != nil {
+		return nil, err
+	}
+
+	var response PrtgSensorDetailsResponse
+	if err := decodeResponse(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 	return &response, nil
 }
 
-// GetGroups ruft die Gruppenliste ab.
-func (a *Api) GetGroups() (*PrtgGroupListResponse, error) {
+// GetGroups ruft die Gruppenliste ab, eingeschränkt durch filter.
+func (a *Api) GetGroups(ctx context.Context, filter listFilter) (*PrtgGroupListResponse, error) {
 	params := map[string]string{
 		"content": "groups",
-		"columns": "active,channel,datetime,device,group,message,objid,priority,sensor,status,tags",
+		"columns": a.tableColumns("groups"),
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	filter.apply(params)
+
+	response, err := pagedTable(ctx, a, params, func(r *PrtgGroupListResponse) (*objectList[PrtgGroupListItemStruct], int64) {
+		return &r.Groups, r.TreeSize
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.truncation.check("groups", len(response.Groups), response.TreeSize)
+
+	return response, nil
+}
+
+// GetDevices ruft die Geräte-Liste ab, eingeschränkt durch filter.
+func (a *Api) GetDevices(ctx context.Context, filter listFilter) (*PrtgDevicesListResponse, error) {
+	params := map[string]string{
+		"content": "devices",
+		"columns": a.tableColumns("devices"),
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	filter.apply(params)
+
+	response, err := pagedTable(ctx, a, params, func(r *PrtgDevicesListResponse) (*objectList[PrtgDeviceListItemStruct], int64) {
+		return &r.Devices, r.TreeSize
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.truncation.check("devices", len(response.Devices), response.TreeSize)
+
+	return response, nil
+}
+
+// GetSensors ruft die Sensoren-Liste ab, eingeschränkt durch filter.
+func (a *Api) GetSensors(ctx context.Context, filter listFilter) (*PrtgSensorsListResponse, error) {
+	params := map[string]string{
+		"content": "sensors",
+		"columns": a.tableColumns("sensors"),
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	filter.apply(params)
+
+	response, err := pagedTable(ctx, a, params, func(r *PrtgSensorsListResponse) (*objectList[PrtgSensorListItemStruct], int64) {
+		return &r.Sensors, r.TreeSize
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.truncation.check("sensors", len(response.Sensors), response.TreeSize)
+
+	return response, nil
+}
+
+// GetDeviceSensors ruft die Sensoren eines Geräts ab.
+func (a *Api) GetDeviceSensors(ctx context.Context, deviceID string) (*PrtgSensorsListResponse, error) {
+	if deviceID == "" {
+		return nil, fmt.Errorf("invalid query: missing object ID")
+	}
+
+	params := map[string]string{
+		"content": "sensors",
+		"id":      deviceID,
+		"columns": a.tableColumns("sensors"),
 		"count":   "50000",
 	}
 
-	body, err := a.baseExecuteRequest("table.json", params)
+	body, err := a.baseExecuteRequest(ctx, "table.json", params)
 	if err != nil {
 		return nil, err
 	}
 
-	var response PrtgGroupListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	var response PrtgSensorsListResponse
+	if err := decodeResponse(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	return &response, nil
 }
 
-// GetDevices ruft die Geräte-Liste ab.
-func (a *Api) GetDevices() (*PrtgDevicesListResponse, error) {
+// GetAlarms ruft alle Sensoren in einem Alarmzustand ab (Down, Warning, Unusual, Down acknowledged, Down partial).
+// Alarms are live data, so the pages bypass the response cache.
+func (a *Api) GetAlarms(ctx context.Context) (*PrtgAlarmListResponse, error) {
 	params := map[string]string{
-		"content": "devices",
-		"columns": "active,channel,datetime,device,group,message,objid,priority,sensor,status,tags",
-		"count":   "50000",
+		"content":       "sensors",
+		"columns":       a.tableColumnsWith("sensors", "objid", "datetime", "sensor", "device", "group", "status", "priority", "message"),
+		"filter_status": "5,4,10,13,14",
 	}
 
-	body, err := a.baseExecuteRequest("table.json", params)
+	return pagedTable(withoutResponseCache(ctx), a, params, func(r *PrtgAlarmListResponse) (*objectList[PrtgSensorListItemStruct], int64) {
+		return &r.Alarms, r.TreeSize
+	})
+}
+
+// GetSensorValues ruft alle Sensoren mit ihrem letzten Wert ab, ohne Antwort-Cache.
+func (a *Api) GetSensorValues(ctx context.Context) (*PrtgSensorsListResponse, error) {
+	params := map[string]string{
+		"content": "sensors",
+		"columns": a.tableColumnsWith("sensors", "device", "group", "lastvalue"),
+	}
+
+	response, err := pagedTable(withoutResponseCache(ctx), a, params, func(r *PrtgSensorsListResponse) (*objectList[PrtgSensorListItemStruct], int64) {
+		return &r.Sensors, r.TreeSize
+	})
 	if err != nil {
 		return nil, err
 	}
+	a.truncation.check("sensors", len(response.Sensors), response.TreeSize)
+
+	return response, nil
+}
+
+// GetNotificationAlarms ruft die aktuellen Alarme inklusive der Benachrichtigungs-Trigger ab, ohne Antwort-Cache.
+func (a *Api) GetNotificationAlarms(ctx context.Context) (*PrtgSensorsListResponse, error) {
+	params := map[string]string{
+		"content":       "sensors",
+		"columns":       "objid,sensor,device,group,status,priority,notifiesx",
+		"filter_status": "5,4,10,13,14",
+	}
+
+	return pagedTable(withoutResponseCache(ctx), a, params, func(r *PrtgSensorsListResponse) (*objectList[PrtgSensorListItemStruct], int64) {
+		return &r.Sensors, r.TreeSize
+	})
+}
+
+// GetMessages ruft die Log-Einträge des angegebenen Objekts im Zeitraum ab.
+func (a *Api) GetMessages(ctx context.Context, objid string, startDate, endDate int64) (*PrtgMessageListResponse, error) {
+	if objid == "" {
+		return nil, fmt.Errorf("invalid query: missing object ID")
+	}
 
-	var response PrtgDevicesListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	const format = "2006-01-02-15-04-05"
+	params := map[string]string{
+		"content":       "messages",
+		"id":            objid,
+		"columns":       "objid,datetime,parent,type,name,status,message",
+		"filter_dstart": time.UnixMilli(startDate).Format(format),
+		"filter_dend":   time.UnixMilli(endDate).Format(format),
+		"count":         "50000",
+	}
+
+	body, err := a.baseExecuteRequest(ctx, "table.json", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response PrtgMessageListResponse
+	if err := decodeResponse(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	return &response, nil
 }
 
-// GetSensors ruft die Sensoren-Liste ab.
-func (a *Api) GetSensors() (*PrtgSensorsListResponse, error) {
+// GetChannelList ruft die Kanäle eines Sensors mit ihrem letzten Wert ab.
+func (a *Api) GetChannelList(ctx context.Context, objid string) (*PrtgChannelListResponse, error) {
+	if objid == "" {
+		return nil, fmt.Errorf("invalid query: missing object ID")
+	}
+
 	params := map[string]string{
-		"content": "sensors",
-		"columns": "active,channel,datetime,device,group,message,objid,priority,sensor,status,tags",
+		"content": "channels",
+		"id":      objid,
+		"columns": "objid,name,lastvalue",
 		"count":   "50000",
 	}
 
-	body, err := a.baseExecuteRequest("table.json", params)
+	body, err := a.baseExecuteRequest(ctx, "table.json", params)
 	if err != nil {
 		return nil, err
 	}
 
-	backend.Logger.Debug("Sensor Response", "body", string(body))
-
-	var response PrtgSensorsListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	var response PrtgChannelListResponse
+	if err := decodeResponse(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	return &response, nil
 }
 
+// GetPrimaryChannel ruft die Einstellung "primarychannel" eines Sensors ab.
+// PRTG liefert sie als "<id>|<name>", z.B. "2|Total".
+func (a *Api) GetPrimaryChannel(ctx context.Context, objid string) (string, error) {
+	if objid == "" {
+		return "", fmt.Errorf("invalid query: missing object ID")
+	}
+
+	params := map[string]string{
+		"id":   objid,
+		"name": "primarychannel",
+		"show": "nohtmlencode",
+	}
+
+	body, err := a.cachedRequest(ctx, "getobjectproperty.htm", params)
+	if err != nil {
+		return "", err
+	}
+
+	var response PrtgObjectPropertyResponse
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return strings.TrimSpace(response.Result), nil
+}
+
+// GetChannelProperty ruft eine Einstellung eines Kanals ab, z.B. "color" oder "colmode".
+func (a *Api) GetChannelProperty(ctx context.Context, objid string, channelID int64, name string) (string, error) {
+	if objid == "" {
+		return "", fmt.Errorf("invalid query: missing object ID")
+	}
+
+	params := map[string]string{
+		"id":      objid,
+		"subtype": "channel",
+		"subid":   strconv.FormatInt(channelID, 10),
+		"name":    name,
+		"show":    "nohtmlencode",
+	}
+
+	body, err := a.cachedRequest(ctx, "getobjectproperty.htm", params)
+	if err != nil {
+		return "", err
+	}
+
+	var response PrtgObjectPropertyResponse
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return strings.TrimSpace(response.Result), nil
+}
+
 // GetChannels ruft die Channel-Werte für die angegebene objid ab.
-func (a *Api) GetChannels(objid string) (*PrtgChannelValueStruct, error) {
+func (a *Api) GetChannels(ctx context.Context, objid string) (*PrtgChannelValueStruct, error) {
 	params := map[string]string{
 		"content":    "values",
 		"id":         objid,
@@ -193,18 +990,15 @@ func (a *Api) GetChannels(objid string) (*PrtgChannelValueStruct, error) {
 		"count":      "50000",
 	}
 
-	body, err := a.baseExecuteRequest("historicdata.json", params)
+	body, err := a.baseExecuteRequest(ctx, "historicdata.json", params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Optional: Rohantwort in eine Datei schreiben (für Debugging)
-	if err := os.WriteFile("channel_response.txt", body, 0644); err != nil {
-		backend.Logger.Warn("Could not save channel response to file", "error", err)
-	}
+	a.debugResponse("channels", body)
 
 	var response PrtgChannelValueStruct
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := decodeResponse(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
@@ -212,90 +1006,340 @@ func (a *Api) GetChannels(objid string) (*PrtgChannelValueStruct, error) {
 }
 
 // GetHistoricalData ruft historische Daten für den angegebenen Sensor und Zeitraum ab.
-func (a *Api) GetHistoricalData(sensorID string, startDate, endDate int64) (*PrtgHistoricalDataResponse, error) {
-	backend.Logger.Info("GetHistoricalData called", "sensorID", sensorID, "startDate", startDate, "endDate", endDate)
-
-	if sensorID == "" {
-		return nil, fmt.Errorf("invalid query: missing sensor ID")
-	}
+// The averaging interval is selected automatically based on the length of the time range.
+func (a *Api) GetHistoricalData(ctx context.Context, sensorID string, startDate, endDate int64) (*PrtgHistoricalDataResponse, error) {
+	hours := time.UnixMilli(endDate).Sub(time.UnixMilli(startDate)).Hours()
+	return a.getHistoricalData(ctx, sensorID, startDate, endDate, selectAvgInterval(hours), avgSourceAuto)
+}
 
-	startTime := time.UnixMilli(startDate)
-	endTime := time.UnixMilli(endDate)
+// GetRawHistoricalData ruft die ungemittelten Rohdaten (avg=0) für den angegebenen Sensor ab.
+// Raw data is limited by the same count ceiling as averaged data, so very long ranges may be truncated.
+func (a *Api) GetRawHistoricalData(ctx context.Context, sensorID string, startDate, endDate int64) (*PrtgHistoricalDataResponse, error) {
+	return a.getHistoricalData(ctx, sensorID, startDate, endDate, "0", avgSourceOverride)
+}
 
-	const format = "2006-01-02-15-04-05"
-	sdate := startTime.Format(format)
-	edate := endTime.Format(format)
+// GetHistoricalDataWithAvg ruft historische Daten mit einem vom Benutzer angegebenen avg-Intervall ab.
+// The interval must be one of validAvgIntervals, see snapAvgInterval.
+func (a *Api) GetHistoricalDataWithAvg(ctx context.Context, sensorID string, startDate, endDate, avg int64) (*PrtgHistoricalDataResponse, error) {
+	return a.getHistoricalData(ctx, sensorID, startDate, endDate, strconv.FormatInt(avg, 10), avgSourceOverride)
+}
 
-	hours := endTime.Sub(startTime).Hours()
-	if hours <= 0 {
-		backend.Logger.Error("Invalid time range", "startDate", sdate, "endDate", edate)
-		return nil, fmt.Errorf("invalid time range: start date %v must be before end date %v", startTime, endTime)
-	}
+// Sources of the averaging interval reported in the frame metadata.
+const (
+	avgSourceAuto     = "auto"     // selected from the length of the time range
+	avgSourceOverride = "override" // requested explicitly by the query
+	avgSourceFallback = "fallback" // coarsened because the requested interval was not usable
+)
 
-	var avg string
+// selectAvgInterval returns the PRTG averaging interval in seconds for a time range of the given length.
+func selectAvgInterval(hours float64) string {
 	switch {
 	case hours <= 24:
-		avg = "0"
+		return "0"
 	case hours <= 48:
-		avg = "60"
+		return "60"
 	case hours <= 72:
-		avg = "300"
+		return "300"
 	case hours <= 168:
-		avg = "900"
+		return "900"
 	case hours <= 336:
-		avg = "1800"
+		return "1800"
 	case hours <= 720:
-		avg = "3600"
+		return "3600"
 	case hours <= 1440:
-		avg = "7200"
+		return "7200"
 	case hours <= 2160:
-		avg = "14400"
+		return "14400"
 	default:
-		avg = "86400"
+		return "86400"
+	}
+}
+
+// validAvgIntervals are the averaging intervals in seconds PRTG accepts, in ascending order.
+var validAvgIntervals = []int64{60, 300, 900, 1800, 3600, 7200, 14400, 86400}
+
+// snapAvgInterval maps a positive number of seconds to the closest valid PRTG averaging
+// interval. On a tie the coarser interval is used, as it returns fewer points.
+func snapAvgInterval(seconds int64) int64 {
+	best := validAvgIntervals[0]
+	for _, interval := range validAvgIntervals[1:] {
+		if absInt64(interval-seconds) <= absInt64(best-seconds) {
+			best = interval
+		}
+	}
+	return best
+}
+
+// ceilAvgInterval returns the smallest valid PRTG averaging interval of at least seconds,
+// or 0 if seconds is not positive.
+func ceilAvgInterval(seconds int64) int64 {
+	if seconds <= 0 {
+		return 0
+	}
+	for _, interval := range validAvgIntervals {
+		if interval >= seconds {
+			return interval
+		}
+	}
+	return validAvgIntervals[len(validAvgIntervals)-1]
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// errNoHistoricData is returned if PRTG has no historic data for the time range, e.g. for an
+// idle or paused sensor. It is not a failure, queries answer it with an empty frame.
+var errNoHistoricData = errors.New("no data found for the given time range")
+
+// getHistoricalData führt die historicdata-Anfrage mit dem angegebenen avg-Intervall aus.
+// Ranges that would exceed the per-request point limit are split into chunks which are
+// fetched concurrently and concatenated in order.
+func (a *Api) getHistoricalData(ctx context.Context, sensorID string, startDate, endDate int64, avg, avgSource string) (*PrtgHistoricalDataResponse, error) {
+	backend.Logger.Info("GetHistoricalData called", "sensorID", sensorID, "startDate", startDate, "endDate", endDate)
+
+	if sensorID == "" {
+		return nil, fmt.Errorf("invalid query: missing sensor ID")
+	}
+
+	startTime := time.UnixMilli(startDate)
+	endTime := time.UnixMilli(endDate)
+
+	hours := endTime.Sub(startTime).Hours()
+	if hours <= 0 {
+		backend.Logger.Error("Invalid time range", "startDate", startTime, "endDate", endTime)
+		return nil, fmt.Errorf("invalid time range: start date %v must be before end date %v", startTime, endTime)
+	}
+
+	// The configured floor protects PRTG from raw or fine grained requests over long ranges
+	if requested, _ := strconv.ParseInt(avg, 10, 64); a.minAvgInterval > 0 && requested < a.minAvgInterval {
+		backend.Logger.Debug("Averaging interval raised to the configured minimum", "avg", avg, "minAvg", a.minAvgInterval)
+		avg = strconv.FormatInt(a.minAvgInterval, 10)
+		avgSource = avgSourceFallback
 	}
 
+	// Raw data has one point per scan, so the scanning interval of the sensor sizes the chunks
+	intervalSeconds := mustParseInt(avg, 1)
+	if avg == "0" {
+		intervalSeconds = a.rawScanInterval(ctx, sensorID)
+	}
+	chunks := splitHistoricRange(startTime, endTime, intervalSeconds, a.historicMaxPoints)
+
 	backend.Logger.Info("Historical data parameters",
 		"sensorID", sensorID,
-		"startDate", sdate,
-		"endDate", edate,
+		"startDate", startTime,
+		"endDate", endTime,
 		"hours", hours,
 		"avg", avg,
-		"expectedDataPoints", hours*3600/float64(mustParseInt(avg, 1)))
+		"expectedDataPoints", hours*3600/float64(intervalSeconds),
+		"chunks", len(chunks))
+
+	responses := make([]*PrtgHistoricalDataResponse, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, historicChunkConcurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk historicRange) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			responses[i], errs[i] = a.fetchHistoricalRange(ctx, sensorID, chunk, avg, 0)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := mergeHistoricalChunks(responses)
+	response.AvgInterval, _ = strconv.ParseInt(avg, 10, 64)
+	response.AvgSource = avgSource
+	if len(response.HistData) == 0 {
+		// The canned dry-run response is empty on purpose
+		if a.dryRun {
+			return response, nil
+		}
+		return nil, errNoHistoricData
+	}
+	if response.Truncated {
+		backend.Logger.Warn("Historic data hit the count ceiling, points may be missing",
+			"sensorID", sensorID, "count", a.historicMaxPoints, "avg", avg)
+	}
+	backend.Logger.Info("First datetime in response", "datetime", response.HistData[0].Datetime)
+
+	return response, nil
+	// 14.02.2025 13:49:00
+}
+
+// fetchHistoricalRange fetches a chunk and pages through it if PRTG returned as many points as
+// requested: the chunk is halved and both halves are fetched again, at most
+// maxHistoricPagingDepth times. A chunk still hitting the ceiling is marked as truncated.
+func (a *Api) fetchHistoricalRange(ctx context.Context, sensorID string, chunk historicRange, avg string, depth int) (*PrtgHistoricalDataResponse, error) {
+	response, err := a.fetchHistoricalChunk(ctx, sensorID, chunk, avg)
+	if err != nil || a.historicMaxPoints <= 0 || len(response.HistData) < a.historicMaxPoints {
+		return response, err
+	}
+
+	half := chunk.end.Sub(chunk.start) / 2
+	if depth >= maxHistoricPagingDepth || half < time.Second {
+		response.Truncated = true
+		return response, nil
+	}
+
+	backend.Logger.Debug("Historic chunk hit the count ceiling, paging", "start", chunk.start, "end", chunk.end, "depth", depth)
+	middle := chunk.start.Add(half)
+	var pages []*PrtgHistoricalDataResponse
+	for _, page := range []historicRange{{start: chunk.start, end: middle}, {start: middle, end: chunk.end}} {
+		pageResponse, err := a.fetchHistoricalRange(ctx, sensorID, page, avg, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, pageResponse)
+	}
+	return mergeHistoricalChunks(pages), nil
+}
 
+// fetchHistoricalChunk führt eine einzelne historicdata-Anfrage für den Teilbereich aus.
+func (a *Api) fetchHistoricalChunk(ctx context.Context, sensorID string, chunk historicRange, avg string) (*PrtgHistoricalDataResponse, error) {
+	const format = "2006-01-02-15-04-05"
 	params := map[string]string{
 		"id":         sensorID,
 		"columns":    "datetime,value_",
 		"avg":        avg,
-		"sdate":      sdate,
-		"edate":      edate,
-		"count":      "50000",
+		"sdate":      chunk.start.Format(format),
+		"edate":      chunk.end.Format(format),
+		"count":      strconv.Itoa(a.historicMaxPoints),
 		"usecaption": "1",
 	}
 
-	body, err := a.baseExecuteRequest("historicdata.json", params)
+	body, err := a.baseExecuteRequest(ctx, "historicdata.json", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch historical data: %w", err)
 	}
 
 	var response PrtgHistoricalDataResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := decodeResponse(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	backend.Logger.Info("Historical data response received successfully")
 
-	// Optional: Rohantwort in eine Datei schreiben (für Debugging)
-	if err := os.WriteFile("historical_data_response.txt", body, 0644); err != nil {
-		backend.Logger.Warn("Could not save historical data response to file", "error", err)
+	a.debugResponse("historicdata", body)
+
+	return &response, nil
+}
+
+// historicRange is a sub-range of a historicdata request.
+type historicRange struct {
+	start time.Time
+	end   time.Time
+}
+
+// defaultScanInterval is the scanning interval in seconds assumed when a sensor does not report one.
+const defaultScanInterval = 60
+
+// rawScanInterval returns the scanning interval of a sensor in seconds from sensordetails.json,
+// or defaultScanInterval if it cannot be read.
+func (a *Api) rawScanInterval(ctx context.Context, sensorID string) int64 {
+	body, err := a.cachedRequest(ctx, "sensordetails.json", map[string]string{"id": sensorID})
+	if err == nil {
+		var response PrtgSensorDetailsResponse
+		if err = decodeResponse(body, &response); err == nil {
+			if seconds, ok := parseScanInterval(response.SensorData.Interval); ok {
+				return seconds
+			}
+		}
 	}
+	backend.Logger.Debug("Scanning interval not available, assuming the default", "sensorID", sensorID, "default", defaultScanInterval, "error", err)
+	return defaultScanInterval
+}
 
-	if len(response.HistData) == 0 {
-		return nil, fmt.Errorf("no data found for the given time range")
+// parseScanInterval parses the scanning interval of sensordetails.json like "60 seconds",
+// "5 minutes", "1 hour" or "1 day". A number without unit counts as seconds.
+func parseScanInterval(interval string) (int64, bool) {
+	fields := strings.Fields(strings.ToLower(cleanMessageHTML(interval)))
+	for i, field := range fields {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		if i+1 == len(fields) {
+			return value, true
+		}
+		switch unit := fields[i+1]; {
+		case strings.HasPrefix(unit, "s"):
+			return value, true
+		case strings.HasPrefix(unit, "m"):
+			return value * 60, true
+		case strings.HasPrefix(unit, "h"):
+			return value * 3600, true
+		case strings.HasPrefix(unit, "d"):
+			return value * 86400, true
+		}
+		return 0, false
 	}
-	backend.Logger.Info("First datetime in response", "datetime", response.HistData[0].Datetime)
+	return 0, false
+}
 
-	return &response, nil
-	// 14.02.2025 13:49:00
+// splitHistoricRange splits the range into consecutive chunks that each stay below maxPoints
+// expected data points for the given averaging interval in seconds.
+func splitHistoricRange(start, end time.Time, intervalSeconds int64, maxPoints int) []historicRange {
+	if intervalSeconds <= 0 {
+		intervalSeconds = 60
+	}
+	if maxPoints <= 0 {
+		return []historicRange{{start: start, end: end}}
+	}
+
+	chunkSize := time.Duration(int64(maxPoints)*intervalSeconds) * time.Second
+	var chunks []historicRange
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(chunkSize) {
+		chunkEnd := chunkStart.Add(chunkSize)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		chunks = append(chunks, historicRange{start: chunkStart, end: chunkEnd})
+	}
+	return chunks
+}
+
+// mergeHistoricalChunks concatenates the chunk responses in order. Points on chunk boundaries
+// are delivered by both neighbouring chunks and are only kept once.
+func mergeHistoricalChunks(responses []*PrtgHistoricalDataResponse) *PrtgHistoricalDataResponse {
+	merged := &PrtgHistoricalDataResponse{}
+	seen := make(map[string]struct{})
+	for _, response := range responses {
+		if response == nil {
+			continue
+		}
+		if merged.PrtgVersion == "" {
+			merged.PrtgVersion = response.PrtgVersion
+		}
+		merged.TreeSize += response.TreeSize
+		merged.Truncated = merged.Truncated || response.Truncated
+		if response.ReturnedCount > 0 {
+			merged.ReturnedCount += response.ReturnedCount
+			merged.DuplicateCount += response.DuplicateCount
+		} else {
+			merged.ReturnedCount += len(response.HistData)
+		}
+		for _, item := range response.HistData {
+			if _, ok := seen[item.Datetime]; ok {
+				merged.DuplicateCount++
+				continue
+			}
+			seen[item.Datetime] = struct{}{}
+			merged.HistData = append(merged.HistData, item)
+		}
+	}
+	return merged
 }
 
 // Yardımcı fonksiyon: string'i int'e çevirir, hata durumunda varsayılan değeri döner
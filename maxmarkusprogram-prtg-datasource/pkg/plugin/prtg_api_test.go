@@ -1,13 +1,22 @@
 package plugin
 
 import (
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 // ✅ Mock HTTP Server ile API testlerini çalıştırıyoruz
@@ -48,7 +57,7 @@ func TestGetStatusList(t *testing.T) {
 	server, api := setupMockServer(`{"prtgversion": "21.2.68.1492"}`, http.StatusOK)
 	defer server.Close()
 
-	status, err := api.GetStatusList()
+	status, err := api.GetStatusList(context.Background())
 	if err != nil {
 		t.Fatalf("GetStatusList() failed: %v", err)
 	}
@@ -57,13 +66,62 @@ func TestGetStatusList(t *testing.T) {
 	}
 }
 
+// ✅ Status list test: XML responses to .json requests are decoded with the xml tags
+func TestGetStatusListXML(t *testing.T) {
+	server, api := setupMockServer("\ufeff\n"+`<?xml version="1.0" encoding="UTF-8"?>
+<status>
+	<prtg-version>24.1.90.1306</prtg-version>
+	<version>24.1.90.1306+</version>
+	<clock>15.02.2025 12:00:00</clock>
+	<totalsens>120</totalsens>
+	<maxsensorcount>500</maxsensorcount>
+	<readonlyuser>false</readonlyuser>
+	<prtgupdateavailable>true</prtgupdateavailable>
+</status>`, http.StatusOK)
+	defer server.Close()
+
+	status, err := api.GetStatusList(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatusList() failed: %v", err)
+	}
+	if status.PrtgVersion != "24.1.90.1306" || status.Version != "24.1.90.1306+" || status.Clock != "15.02.2025 12:00:00" {
+		t.Errorf("Unexpected version or clock: %+v", status)
+	}
+	if status.TotalSens != 120 || status.MaxSensorCount != "500" || !status.PRTGUpdateAvailable {
+		t.Errorf("Unexpected sensor counts: %+v", status)
+	}
+
+	// Malformed XML is reported as parse error
+	server, api = setupMockServer(`<status><totalsens>many</totalsens></status>`, http.StatusOK)
+	defer server.Close()
+	if _, err := api.GetStatusList(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to parse response") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+// ✅ Table XML is rejected instead of being decoded as an empty list
+func TestGetSensorsXMLNotSupported(t *testing.T) {
+	server, api := setupMockServer(`<?xml version="1.0" encoding="UTF-8"?>
+<sensors totalcount="2" listend="1">
+	<prtg-version>24.1.90.1306</prtg-version>
+	<item><objid>1001</objid><sensor>Ping</sensor></item>
+	<item><objid>1002</objid><sensor>CPU Load</sensor></item>
+</sensors>`, http.StatusOK)
+	defer server.Close()
+
+	sensors, err := api.GetSensors(context.Background(), listFilter{})
+	if !errors.Is(err, errXMLNotSupported) || sensors != nil {
+		t.Errorf("Expected errXMLNotSupported, got %v, %+v", err, sensors)
+	}
+}
+
 // ✅ Grupları çekme testi
 func TestGetGroups(t *testing.T) {
 	mockResponse := `{"groups": [{"group": "Network Devices"}]}`
 	server, api := setupMockServer(mockResponse, http.StatusOK)
 	defer server.Close()
 
-	groups, err := api.GetGroups()
+	groups, err := api.GetGroups(context.Background(), listFilter{})
 	if err != nil {
 		t.Fatalf("GetGroups() failed: %v", err)
 	}
@@ -78,7 +136,7 @@ func TestGetDevices(t *testing.T) {
 	server, api := setupMockServer(mockResponse, http.StatusOK)
 	defer server.Close()
 
-	devices, err := api.GetDevices()
+	devices, err := api.GetDevices(context.Background(), listFilter{})
 	if err != nil {
 		t.Fatalf("GetDevices() failed: %v", err)
 	}
@@ -93,7 +151,7 @@ func TestGetSensors(t *testing.T) {
 	server, api := setupMockServer(mockResponse, http.StatusOK)
 	defer server.Close()
 
-	sensors, err := api.GetSensors()
+	sensors, err := api.GetSensors(context.Background(), listFilter{})
 	if err != nil {
 		t.Fatalf("GetSensors() failed: %v", err)
 	}
@@ -102,6 +160,233 @@ func TestGetSensors(t *testing.T) {
 	}
 }
 
+// ✅ Per-attempt timeout: a slow first attempt is retried within the overall deadline
+func TestRetryAttemptTimeout(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, `{"sensors": [{"sensor": "CPU Load"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 5*time.Second)
+	api.SetRetries(3, 100*time.Millisecond)
+
+	sensors, err := api.GetSensors(context.Background(), listFilter{})
+	if err != nil {
+		t.Fatalf("GetSensors() failed: %v", err)
+	}
+	if len(sensors.Sensors) != 1 {
+		t.Errorf("Expected 1 sensor, got %d", len(sensors.Sensors))
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
+
+// ✅ Overall deadline from the context bounds all attempts together
+func TestRetryOverallDeadline(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetRetries(10, 150*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.executeRequest(ctx, "table.json", nil); err == nil {
+		t.Fatalf("Expected an error when the deadline is exceeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the overall deadline to stop retries, took %v", elapsed)
+	}
+	if n := atomic.LoadInt32(&calls); n < 2 || n >= 10 {
+		t.Errorf("Expected a few attempts within the deadline, got %d", n)
+	}
+}
+
+// ✅ Client errors are not retried
+func TestRetryNotOnClientError(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetRetries(3, 0)
+
+	if _, err := api.GetSensors(context.Background(), listFilter{}); err == nil {
+		t.Fatalf("Expected an error for status 400")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected a single attempt, got %d", calls)
+	}
+}
+
+// ✅ Transient gateway errors are retried, other server errors are not
+func TestRetryOnTransientStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		expectedCalls int32
+	}{
+		{"Bad Gateway", http.StatusBadGateway, 2},
+		{"Service Unavailable", http.StatusServiceUnavailable, 2},
+		{"Gateway Timeout", http.StatusGatewayTimeout, 2},
+		{"Internal Server Error", http.StatusInternalServerError, 1},
+		{"Forbidden", http.StatusForbidden, 1},
+		{"Unauthorized", http.StatusUnauthorized, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.WriteHeader(tt.statusCode)
+					return
+				}
+				fmt.Fprint(w, `{"sensors": []}`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+			api.SetRetries(3, 0)
+			api.SetRetryDelay(time.Millisecond)
+
+			api.GetSensors(context.Background(), listFilter{})
+			if n := atomic.LoadInt32(&calls); n != tt.expectedCalls {
+				t.Errorf("Expected %d attempts, got %d", tt.expectedCalls, n)
+			}
+		})
+	}
+}
+
+// ✅ The backoff doubles per attempt, is capped and jittered within the upper half
+func TestRetryBackoff(t *testing.T) {
+	api := NewApi("http://localhost", "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetRetryDelay(100 * time.Millisecond)
+
+	for attempt, expected := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		20: maxRetryBackoff,
+	} {
+		for i := 0; i < 20; i++ {
+			if delay := api.backoff(attempt); delay < expected/2 || delay > expected {
+				t.Errorf("Attempt %d: expected a delay between %v and %v, got %v", attempt, expected/2, expected, delay)
+			}
+		}
+	}
+}
+
+// ✅ No retry is started when the backoff would exceed the deadline
+func TestRetryBackoffRespectsDeadline(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetRetries(5, 0)
+	api.SetRetryDelay(2 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.executeRequest(ctx, "table.json", nil); err == nil {
+		t.Fatalf("Expected an error for status 503")
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected to fail without waiting for the backoff, took %v", elapsed)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected a single attempt, got %d", n)
+	}
+}
+
+// ✅ Gzip-encoded responses are decompressed
+func TestGzipResponse(t *testing.T) {
+	var acceptEncoding string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		fmt.Fprint(gz, `{"sensors": [{"sensor": "CPU Load"}]}`)
+		gz.Close()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	sensors, err := api.GetSensors(context.Background(), listFilter{})
+	if err != nil {
+		t.Fatalf("GetSensors() failed: %v", err)
+	}
+	if len(sensors.Sensors) != 1 || sensors.Sensors[0].Sensor != "CPU Load" {
+		t.Errorf("Expected decompressed sensor list, got %+v", sensors.Sensors)
+	}
+	if acceptEncoding != "gzip" {
+		t.Errorf("Expected Accept-Encoding gzip, got %q", acceptEncoding)
+	}
+}
+
+// ✅ Icon and type metadata of list items
+func TestGetSensors_IconAndType(t *testing.T) {
+	mockResponse := `{"sensors": [
+		{"sensor": "Ping", "icon": "/icons/sensors/ping.png", "type": "Ping", "type_raw": "ping"},
+		{"sensor": "Legacy"}
+	]}`
+	server, api := setupMockServer(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	sensors, err := api.GetSensors(context.Background(), listFilter{})
+	if err != nil {
+		t.Fatalf("GetSensors() failed: %v", err)
+	}
+	ping := sensors.Sensors[0]
+	if ping.Icon != "/icons/sensors/ping.png" || ping.Type != "Ping" || ping.TypeRAW != "ping" {
+		t.Errorf("Expected icon and type metadata, got %q/%q/%q", ping.Icon, ping.Type, ping.TypeRAW)
+	}
+	// Objects without icon info stay empty
+	if legacy := sensors.Sensors[1]; legacy.Icon != "" || legacy.TypeRAW != "" {
+		t.Errorf("Expected empty metadata, got %q/%q", legacy.Icon, legacy.TypeRAW)
+	}
+}
+
 
 // ✅ Tarihsel veri çekme testi
 func TestGetHistoricalData(t *testing.T) {
@@ -112,7 +397,7 @@ func TestGetHistoricalData(t *testing.T) {
 	startDate := time.Now().Add(-24 * time.Hour).UnixMilli()
 	endDate := time.Now().UnixMilli()
 
-	histData, err := api.GetHistoricalData("1234", startDate, endDate)
+	histData, err := api.GetHistoricalData(context.Background(), "1234", startDate, endDate)
 	if err != nil {
 		t.Fatalf("GetHistoricalData() failed: %v", err)
 	}
@@ -139,7 +424,7 @@ func TestApiErrorHandling(t *testing.T) {
 			server, api := setupMockServer(`{"status": "ok"}`, tt.statusCode)
 			defer server.Close()
 
-			_, err := api.GetStatusList()
+			_, err := api.GetStatusList(context.Background())
 			if tt.expectErr && err == nil {
 				t.Errorf("Expected error but got none")
 			} else if !tt.expectErr && err != nil {
@@ -157,3 +442,715 @@ func loadFixture(filePath string) string {
 	}
 	return string(data)
 }
+
+// ✅ Redirects are followed only to trusted hosts
+func TestRedirectTrustedHosts(t *testing.T) {
+	var receivedToken string
+	var contacted bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contacted = true
+		receivedToken = r.URL.Query().Get("apitoken")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"prtgversion": "21.2.68.1492"}`)
+	}))
+	defer target.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.RequestURI(), http.StatusFound)
+	}))
+	defer origin.Close()
+
+	targetURL, _ := url.Parse(target.URL)
+
+	tests := []struct {
+		name          string
+		trustedHosts  []string
+		expectedToken string
+		expectError   bool
+	}{
+		{"Default same host only", nil, "", true},
+		{"Untrusted host", []string{"proxy.example.com"}, "", true},
+		{"Trusted host and port", []string{targetURL.Host}, "test-api-key", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receivedToken = ""
+			contacted = false
+			api := NewApi(origin.URL, "test-api-key", 10*time.Second, 10*time.Second)
+			api.SetTrustedRedirectHosts(tt.trustedHosts)
+
+			_, err := api.GetStatusList(context.Background())
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected redirect to untrusted host to be refused")
+				}
+				if contacted {
+					t.Error("Expected untrusted redirect target not to be contacted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetStatusList() failed: %v", err)
+			}
+			if receivedToken != tt.expectedToken {
+				t.Errorf("Expected token %q at redirect target, got %q", tt.expectedToken, receivedToken)
+			}
+		})
+	}
+}
+
+// ✅ Redirects on the same host keep the API token
+func TestRedirectSameHost(t *testing.T) {
+	var receivedToken string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status.json", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/prtg/api/status.json", http.StatusFound)
+	})
+	mux.HandleFunc("/prtg/api/status.json", func(w http.ResponseWriter, r *http.Request) {
+		receivedToken = r.URL.Query().Get("apitoken")
+		fmt.Fprint(w, `{"prtgversion": "21.2.68.1492"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	if _, err := api.GetStatusList(context.Background()); err != nil {
+		t.Fatalf("GetStatusList() failed: %v", err)
+	}
+	if receivedToken != "test-api-key" {
+		t.Errorf("Expected token to be preserved on same-host redirect, got %q", receivedToken)
+	}
+}
+
+// ✅ Redirects from https to plain http are never trusted
+func TestIsTrustedRedirect_Scheme(t *testing.T) {
+	api := NewApi("https://prtg.example.com", "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetTrustedRedirectHosts([]string{"proxy.example.com"})
+
+	tests := []struct {
+		original string
+		target   string
+		expected bool
+	}{
+		{"https://prtg.example.com/api/table.json", "https://prtg.example.com/prtg/api/table.json", true},
+		{"https://prtg.example.com/api/table.json", "http://prtg.example.com/api/table.json", false},
+		{"https://prtg.example.com/api/table.json", "https://proxy.example.com/api/table.json", true},
+		{"https://prtg.example.com/api/table.json", "http://proxy.example.com/api/table.json", false},
+		{"http://prtg.example.com/api/table.json", "https://prtg.example.com/api/table.json", true},
+		{"http://prtg.example.com/api/table.json", "http://prtg.example.com/prtg/api/table.json", true},
+	}
+
+	for _, tt := range tests {
+		original, _ := url.Parse(tt.original)
+		target, _ := url.Parse(tt.target)
+		if got := api.isTrustedRedirect(original, target); got != tt.expected {
+			t.Errorf("isTrustedRedirect(%q, %q) = %v, expected %v", tt.original, tt.target, got, tt.expected)
+		}
+	}
+
+	// A downgrade is refused before the token can be sent in the clear
+	req, _ := http.NewRequest(http.MethodGet, "http://prtg.example.com/api/table.json", nil)
+	via, _ := http.NewRequest(http.MethodGet, "https://prtg.example.com/api/table.json?apitoken=test-api-key", nil)
+	if err := api.checkRedirect(req, []*http.Request{via}); err == nil {
+		t.Error("Expected https to http redirect to be refused")
+	}
+	if req.URL.Query().Get("apitoken") != "" {
+		t.Error("Expected no API token on refused redirect")
+	}
+}
+
+// ✅ Log-Einträge abrufen
+func TestGetMessages(t *testing.T) {
+	var params url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		fmt.Fprint(w, `{"messages": [{"objid": 1234, "datetime": "15.02.2025 12:00:00", "message_raw": "OK"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	messages, err := api.GetMessages(context.Background(), "1234", time.Now().Add(-time.Hour).UnixMilli(), time.Now().UnixMilli())
+	if err != nil {
+		t.Fatalf("GetMessages() failed: %v", err)
+	}
+	if len(messages.Messages) != 1 || messages.Messages[0].MessageRAW != "OK" {
+		t.Errorf("Expected 1 message 'OK', got %+v", messages.Messages)
+	}
+	if params.Get("content") != "messages" || params.Get("id") != "1234" {
+		t.Errorf("Unexpected request parameters: %v", params)
+	}
+
+	if _, err := api.GetMessages(context.Background(), "", 0, 1); err == nil {
+		t.Errorf("Expected error for missing object ID")
+	}
+}
+
+// ✅ Long ranges are fetched in chunks and merged in order
+func TestGetHistoricalDataChunking(t *testing.T) {
+	const format = "2006-01-02-15-04-05"
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// Return one point at the start and one at the end of the requested chunk
+		sdate, _ := time.Parse(format, r.URL.Query().Get("sdate"))
+		edate, _ := time.Parse(format, r.URL.Query().Get("edate"))
+		fmt.Fprintf(w, `{"histdata": [{"datetime": "%s", "value": 1}, {"datetime": "%s", "value": 2}]}`,
+			sdate.Format(time.RFC3339), edate.Format(time.RFC3339))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.historicMaxPoints = 60
+
+	start := time.Date(2025, 2, 15, 10, 0, 0, 0, time.Local)
+	end := start.Add(3 * time.Hour)
+	histData, err := api.GetRawHistoricalData(context.Background(), "1234", start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		t.Fatalf("GetRawHistoricalData() failed: %v", err)
+	}
+
+	if requests != 3 {
+		t.Errorf("Expected 3 chunk requests, got %d", requests)
+	}
+	// 3 chunks with 2 points each share 2 boundary points
+	if len(histData.HistData) != 4 {
+		t.Fatalf("Expected 4 merged points, got %d", len(histData.HistData))
+	}
+	for i := 1; i < len(histData.HistData); i++ {
+		prev, _ := time.Parse(time.RFC3339, histData.HistData[i-1].Datetime)
+		cur, _ := time.Parse(time.RFC3339, histData.HistData[i].Datetime)
+		if !cur.After(prev) {
+			t.Errorf("Expected points in ascending order, got %s after %s", histData.HistData[i].Datetime, histData.HistData[i-1].Datetime)
+		}
+	}
+}
+
+// ✅ Raw data chunks are sized by the scanning interval of the sensor
+func TestGetHistoricalDataChunking_ScanInterval(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sensordetails.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sensordata": {"name": "Ping", "interval": "30 seconds"}}`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `{"histdata": []}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.historicMaxPoints = 60
+
+	// 60 points of 30 seconds cover 30 minutes per chunk
+	start := time.Date(2025, 2, 15, 10, 0, 0, 0, time.Local)
+	end := start.Add(3 * time.Hour)
+	if _, err := api.GetRawHistoricalData(context.Background(), "1234", start.UnixMilli(), end.UnixMilli()); err != nil && !errors.Is(err, errNoHistoricData) {
+		t.Fatalf("GetRawHistoricalData() failed: %v", err)
+	}
+	if requests != 6 {
+		t.Errorf("Expected 6 chunk requests, got %d", requests)
+	}
+}
+
+// ✅ parseScanInterval test
+func TestParseScanInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		expected int64
+		ok       bool
+	}{
+		{"60 seconds", 60, true},
+		{"30 sec", 30, true},
+		{"5 minutes", 300, true},
+		{"1 hour", 3600, true},
+		{"1 day", 86400, true},
+		{"120", 120, true},
+		{"", 0, false},
+		{"unknown", 0, false},
+		{"5 fortnights", 0, false},
+	}
+
+	for _, tt := range tests {
+		seconds, ok := parseScanInterval(tt.interval)
+		if ok != tt.ok || seconds != tt.expected {
+			t.Errorf("parseScanInterval(%q) = %d/%v, expected %d/%v", tt.interval, seconds, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ splitHistoricRange test
+func TestSplitHistoricRange(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	chunks := splitHistoricRange(start, start.Add(24*time.Hour), 60, 50000)
+	if len(chunks) != 1 {
+		t.Errorf("Expected a single chunk for a short range, got %d", len(chunks))
+	}
+
+	chunks = splitHistoricRange(start, start.Add(100*24*time.Hour), 60, 50000)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks for 100 days of raw data, got %d", len(chunks))
+	}
+	if !chunks[0].start.Equal(start) || !chunks[2].end.Equal(start.Add(100*24*time.Hour)) {
+		t.Errorf("Chunks do not cover the full range: %v", chunks)
+	}
+	if !chunks[0].end.Equal(chunks[1].start) {
+		t.Errorf("Expected consecutive chunks, got %v", chunks)
+	}
+}
+
+// ✅ Requests are only sent to the configured PRTG host
+func TestBuildApiUrl_HostOverride(t *testing.T) {
+	api := NewApi("http://prtg.example.com", "test-api-key", 10*time.Second, 10*time.Second)
+
+	// Crafted endpoint and parameter values stay on the configured host
+	for _, endpoint := range []string{"table.json", "@evil.example.com/table.json", "//evil.example.com/table.json", "http://evil.example.com/"} {
+		apiUrl, err := api.buildApiUrl(endpoint, map[string]string{"id": "http://evil.example.com"})
+		if err != nil {
+			t.Errorf("Unexpected error for endpoint %q: %v", endpoint, err)
+			continue
+		}
+		parsedUrl, _ := url.Parse(apiUrl)
+		if parsedUrl.Host != "prtg.example.com" {
+			t.Errorf("Expected host prtg.example.com for endpoint %q, got %s", endpoint, parsedUrl.Host)
+		}
+	}
+
+	// Only the base and failover hosts are valid request targets
+	for _, node := range []string{"http://evil.example.com", "http://prtg.example.com@evil.example.com", "http://prtg2.example.com"} {
+		if _, err := api.buildNodeUrl(node, "table.json", nil); err == nil {
+			t.Errorf("Expected error for foreign host %q", node)
+		}
+	}
+	api.SetFailoverURLs([]string{"http://prtg2.example.com"})
+	if _, err := api.buildNodeUrl("http://prtg2.example.com", "table.json", nil); err != nil {
+		t.Errorf("Unexpected error for failover host: %v", err)
+	}
+	if _, err := api.buildNodeUrl("http://evil.example.com", "table.json", nil); err == nil {
+		t.Error("Expected error for foreign host after setting failover URLs")
+	}
+}
+
+// ✅ snapAvgInterval test: Arbitrary seconds snap to the closest PRTG interval
+func TestSnapAvgInterval(t *testing.T) {
+	tests := []struct {
+		seconds  int64
+		expected int64
+	}{
+		{1, 60},
+		{60, 60},
+		{100, 60},
+		{180, 300},
+		{1000, 900},
+		{1350, 1800},
+		{3600, 3600},
+		{10000, 7200},
+		{50000, 14400},
+		{1000000, 86400},
+	}
+
+	for _, tt := range tests {
+		if got := snapAvgInterval(tt.seconds); got != tt.expected {
+			t.Errorf("snapAvgInterval(%d) = %d, expected %d", tt.seconds, got, tt.expected)
+		}
+	}
+}
+
+// ✅ Minimum averaging interval: Auto selection and overrides are floored
+func TestMinAvgInterval(t *testing.T) {
+	var avg string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		avg = r.URL.Query().Get("avg")
+		w.Write([]byte(`{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 12.5}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetMinAvgInterval(600)
+	if api.minAvgInterval != 900 {
+		t.Fatalf("Expected the floor to be rounded up to 900, got %d", api.minAvgInterval)
+	}
+
+	now := time.Now()
+	from := now.Add(-12 * time.Hour).UnixMilli()
+
+	tests := []struct {
+		name           string
+		fetch          func() (*PrtgHistoricalDataResponse, error)
+		expectedAvg    string
+		expectedSource string
+	}{
+		{"Auto raw", func() (*PrtgHistoricalDataResponse, error) {
+			return api.GetHistoricalData(context.Background(), "1234", from, now.UnixMilli())
+		}, "900", avgSourceFallback},
+		{"Raw override", func() (*PrtgHistoricalDataResponse, error) {
+			return api.GetRawHistoricalData(context.Background(), "1234", from, now.UnixMilli())
+		}, "900", avgSourceFallback},
+		{"Fine override", func() (*PrtgHistoricalDataResponse, error) {
+			return api.GetHistoricalDataWithAvg(context.Background(), "1234", from, now.UnixMilli(), 300)
+		}, "900", avgSourceFallback},
+		{"Coarse override", func() (*PrtgHistoricalDataResponse, error) {
+			return api.GetHistoricalDataWithAvg(context.Background(), "1234", from, now.UnixMilli(), 3600)
+		}, "3600", avgSourceOverride},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.fetch()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if avg != tt.expectedAvg || response.AvgSource != tt.expectedSource {
+				t.Errorf("Expected avg=%s source=%s, got avg=%s source=%s", tt.expectedAvg, tt.expectedSource, avg, response.AvgSource)
+			}
+		})
+	}
+
+	// Without a floor raw data is requested as before
+	api.SetMinAvgInterval(0)
+	if _, err := api.GetRawHistoricalData(context.Background(), "1234", from, now.UnixMilli()); err != nil || avg != "0" {
+		t.Errorf("Expected raw data without a floor, got avg=%s err=%v", avg, err)
+	}
+}
+
+// ✅ Per-endpoint timeouts override the global timeout
+func TestEndpointTimeouts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"prtgversion": "21.2.68.1492", "groups": []}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 2*time.Second)
+	api.SetEndpointTimeout("status.json", 50*time.Millisecond)
+
+	if got := api.timeoutFor("status.json"); got != 50*time.Millisecond {
+		t.Errorf("Expected the status timeout, got %v", got)
+	}
+	if got := api.timeoutFor("historicdata.json"); got != 2*time.Second {
+		t.Errorf("Expected the global timeout for historicdata, got %v", got)
+	}
+
+	if _, err := api.GetStatusList(context.Background()); err == nil {
+		t.Errorf("Expected the short status timeout to fail the slow request")
+	}
+	if _, err := api.GetGroups(context.Background(), listFilter{}); err != nil {
+		t.Errorf("Expected table.json to use the global timeout, got %v", err)
+	}
+
+	// A zero timeout removes the override
+	api.SetEndpointTimeout("status.json", 0)
+	if got := api.timeoutFor("status.json"); got != 2*time.Second {
+		t.Errorf("Expected the global timeout after removing the override, got %v", got)
+	}
+}
+
+// ✅ The HTTP client is created once and reused for all requests
+func TestApiReusesClient(t *testing.T) {
+	server, api := setupMockServer(`{"prtgversion": "21.2.68.1492", "sensors": []}`, http.StatusOK)
+	defer server.Close()
+
+	client := api.client
+	if client == nil {
+		t.Fatal("Expected NewApi to create the HTTP client")
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+			t.Fatalf("GetSensors failed: %v", err)
+		}
+	}
+	if api.client != client {
+		t.Errorf("Expected the same HTTP client to be reused")
+	}
+}
+
+// BenchmarkGetSensors measures repeated sensor requests over the shared client.
+func BenchmarkGetSensors(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"prtgversion": "21.2.68.1492", "treesize": 1, "sensors": [{"objid": 1, "sensor": "Ping"}]}`))
+	}))
+	defer server.Close()
+
+	// No response cache, every iteration goes through the client
+	api := NewApi(server.URL, "test-api-key", 0, 10*time.Second)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.GetSensors(context.Background(), listFilter{}); err != nil {
+			b.Fatalf("GetSensors failed: %v", err)
+		}
+	}
+}
+
+// ✅ Channel and historicdata responses are not written to files
+func TestNoDebugFilesWritten(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	server, api := setupMockServer(`{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "value": 78.9}]}`, http.StatusOK)
+	defer server.Close()
+	api.SetDebugResponses(true)
+
+	if _, err := api.GetChannels(context.Background(), "1234"); err != nil {
+		t.Fatalf("GetChannels failed: %v", err)
+	}
+	if _, err := api.GetHistoricalData(context.Background(), "1234", time.Now().Add(-time.Hour).UnixMilli(), time.Now().UnixMilli()); err != nil {
+		t.Fatalf("GetHistoricalData failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("Unexpected file %s in the working directory", entry.Name())
+	}
+}
+
+// ✅ Cancelling the context aborts an in-flight request
+func TestRequestCancellation(t *testing.T) {
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(release)
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetRetries(3, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := api.GetSensors(ctx, listFilter{})
+	if err == nil {
+		t.Fatalf("Expected an error after cancelling the context")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the request to return promptly, took %v", elapsed)
+	}
+
+	// Cancellation propagates from QueryData down to the request
+	ds := &Datasource{api: api}
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start = time.Now()
+	resp, err := ds.QueryData(ctx, &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID: "A",
+			JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`),
+			TimeRange: backend.TimeRange{
+				From: time.Now().Add(-time.Hour),
+				To:   time.Now(),
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("QueryData failed: %v", err)
+	}
+	if resp.Responses["A"].Error == nil {
+		t.Errorf("Expected the cancelled query to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the query to return promptly, took %v", elapsed)
+	}
+}
+
+// denseHistoricServer serves a point every step seconds between sdate and edate, capped at count.
+func denseHistoricServer(step time.Duration, requests *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		q := r.URL.Query()
+		start, _ := time.ParseInLocation("2006-01-02-15-04-05", q.Get("sdate"), time.Local)
+		end, _ := time.ParseInLocation("2006-01-02-15-04-05", q.Get("edate"), time.Local)
+		count, _ := strconv.Atoi(q.Get("count"))
+
+		var points []string
+		for ts := start; !ts.After(end) && len(points) < count; ts = ts.Add(step) {
+			points = append(points, fmt.Sprintf(`{"datetime": %q, "value": 1}`, ts.UTC().Format(time.RFC3339)))
+		}
+		fmt.Fprintf(w, `{"histdata": [%s]}`, strings.Join(points, ","))
+	})
+	return httptest.NewServer(mux)
+}
+
+// ✅ Chunks hitting the count ceiling are paged until complete
+func TestHistoricCountCeilingPaging(t *testing.T) {
+	var requests int32
+	server := denseHistoricServer(10*time.Second, &requests)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetHistoricMaxPoints(10)
+
+	end := time.Now().Truncate(time.Minute)
+	start := end.Add(-200 * time.Second)
+	response, err := api.GetRawHistoricalData(context.Background(), "1234", start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		t.Fatalf("GetRawHistoricalData failed: %v", err)
+	}
+	if len(response.HistData) != 21 {
+		t.Errorf("Expected all 21 points after paging, got %d", len(response.HistData))
+	}
+	if response.Truncated {
+		t.Errorf("Expected the paged response to be complete")
+	}
+	if n := atomic.LoadInt32(&requests); n < 2 {
+		t.Errorf("Expected paging requests, got %d", n)
+	}
+}
+
+// ✅ A ceiling that paging cannot resolve is reported as a warning
+func TestHistoricCountCeilingWarning(t *testing.T) {
+	var requests int32
+	server := denseHistoricServer(time.Millisecond, &requests)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	api.SetHistoricMaxPoints(10)
+
+	end := time.Now().Truncate(time.Minute)
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"value","avg":0,"avgExact":true}`),
+		TimeRange: backend.TimeRange{From: end.Add(-10 * time.Minute), To: end},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	meta := resp.Frames[0].Meta
+	if meta == nil || len(meta.Notices) == 0 || !strings.Contains(meta.Notices[0].Text, "maximum of 10 points") {
+		t.Errorf("Expected a count ceiling warning, got %+v", meta)
+	}
+	if n := atomic.LoadInt32(&requests); n > 1<<(maxHistoricPagingDepth+1) {
+		t.Errorf("Expected paging to be bounded, got %d requests", n)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// ✅ SetTransport test: Requests go through the injected transport without a server
+func TestApiSetTransport(t *testing.T) {
+	api := NewApi("https://prtg.example.com", "test-api-key", 0, 10*time.Second)
+	api.SetRetries(2, 0)
+	api.SetRetryDelay(time.Millisecond)
+
+	var calls atomic.Int32
+	api.SetTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("connection reset")
+		}
+		if req.URL.Host != "prtg.example.com" || req.URL.Query().Get("apitoken") != "test-api-key" {
+			t.Errorf("Unexpected request %s", redactURL(req.URL.String()))
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"version": "24.1"}`)),
+			Request:    req,
+		}, nil
+	}))
+
+	status, err := api.GetStatusList(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Version != "24.1" || calls.Load() != 2 {
+		t.Errorf("Expected version 24.1 after a retry, got %q in %d calls", status.Version, calls.Load())
+	}
+
+	// A wrapper sees every request of the wrapped transport
+	var wrapped atomic.Int32
+	inner := api.Transport()
+	api.SetTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		wrapped.Add(1)
+		return inner.RoundTrip(req)
+	}))
+	if _, err := api.GetLiveStatusList(context.Background()); err != nil || wrapped.Load() != 1 {
+		t.Errorf("Expected 1 wrapped request, got %d (%v)", wrapped.Load(), err)
+	}
+
+	api.SetTransport(nil)
+	if _, ok := api.Transport().(*http.Transport); !ok {
+		t.Errorf("Expected the default transport, got %T", api.Transport())
+	}
+}
+
+// ✅ SetProxyURL test: Requests are sent through the configured proxy
+func TestApiSetProxyURL(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy receives the absolute URL of the PRTG server
+		if r.URL.Host != "prtg.example.invalid" || r.Header.Get("Proxy-Authorization") == "" {
+			t.Errorf("Unexpected proxied request to %q", r.URL.Host)
+		}
+		proxied.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version": "24.1"}`))
+	}))
+	defer proxy.Close()
+
+	api := NewApi("http://prtg.example.invalid", "test-api-key", 0, 10*time.Second)
+	proxyURL := strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+	if err := api.SetProxyURL(proxyURL); err != nil {
+		t.Fatalf("SetProxyURL failed: %v", err)
+	}
+	status, err := api.GetLiveStatusList(context.Background())
+	if err != nil || status.Version != "24.1" || proxied.Load() != 1 {
+		t.Fatalf("Expected the request to go through the proxy, got %v in %d requests", err, proxied.Load())
+	}
+
+	// The proxy survives restoring the default transport
+	api.SetTransport(nil)
+	if _, err := api.GetLiveStatusList(context.Background()); err != nil || proxied.Load() != 2 {
+		t.Errorf("Expected the default transport to keep the proxy, got %v", err)
+	}
+
+	ds := &Datasource{api: api}
+	if proxy := ds.effectiveConfig()["proxy"]; strings.Contains(proxy.(string), "secret") {
+		t.Errorf("Proxy credentials leaked into the config: %v", proxy)
+	}
+
+	for _, invalid := range []string{"ftp://proxy:21", "http://", "://proxy"} {
+		if err := api.SetProxyURL(invalid); err == nil {
+			t.Errorf("Expected an error for proxy URL %q", invalid)
+		}
+	}
+	if err := api.SetProxyURL(""); err != nil || api.proxyURL != nil {
+		t.Errorf("Expected an empty proxy URL to restore the environment, got %v", err)
+	}
+}
@@ -3,8 +3,10 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -25,14 +27,14 @@ type PRTGAPI interface {
 
 // query processes a single query. If QueryType is "metrics", it creates a time series,
 // otherwise property-based queries are handled by handlePropertyQuery.
-func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) backend.DataResponse {
-	_ = ctx  // ! Unused parameter: ctx is intentionally not used.
+func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (response backend.DataResponse) {
 	_ = pCtx // ! Unused parameter: pCtx is intentionally not used.
 
-	var response backend.DataResponse
-	var qm queryModel
-
+	// The execution time includes the PRTG round trips and shows slow panels in the query inspector
+	start := time.Now()
+	defer func() { setExecutionTime(&response, time.Since(start)) }()
 
+	var qm queryModel
 
 	backend.Logger.Debug("Raw query parameters",
 		"timeRange", fmt.Sprintf("%v to %v", query.TimeRange.From, query.TimeRange.To),
@@ -43,326 +45,1440 @@ func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, quer
 
 	switch qm.QueryType {
 	case "metrics":
-		// Metrics handling code
-		fromTime := query.TimeRange.From.UnixMilli()
-		toTime := query.TimeRange.To.UnixMilli()
+		return d.handleMetricsQuery(ctx, query, qm)
 
-		backend.Logger.Info("Fetching historical data",
-			"objectId", qm.ObjectId,
-			"channel", qm.Channel,
-			"from", fromTime,
-			"to", toTime)
-		historicalData, err := d.api.GetHistoricalData(qm.ObjectId, fromTime, toTime)
+	case "messageChanges":
+		return d.handleMessageChangesQuery(ctx, query, qm)
+
+	case "downtime":
+		return d.handleDowntimeQuery(ctx, query, qm)
+
+	case "alarms":
+		return d.handleAlarmsQuery(ctx)
+
+	case "alarmsByPriority":
+		return d.handleAlarmsByPriorityQuery(ctx)
+
+	case "statusTransitions":
+		return d.handleStatusTransitionsQuery(ctx, query, qm)
+
+	case "notifications":
+		return d.handleNotificationsQuery(ctx, qm)
+
+	case "backgroundTasks":
+		return d.handleBackgroundTasksQuery(ctx)
+
+	case "valueThreshold":
+		return d.handleValueThresholdQuery(ctx, qm)
+
+	case "deviceReachability":
+		return d.handleDeviceReachabilityQuery(ctx, qm)
+
+	case "statusRollup":
+		return d.handleStatusRollupQuery(ctx, qm)
+
+	case "channels":
+		return d.handleChannelsQuery(ctx, qm)
+
+	case "stateSince":
+		return d.handleStateSinceQuery(ctx, query, qm)
+
+	case "tagGroups":
+		return d.handleTagGroupsQuery(ctx, query, qm)
+
+	case "multi":
+		return d.handleMultiQuery(ctx, query, qm)
+
+	case "text":
+		// Handle text mode by using the non-raw property
+		return d.handlePropertyQuery(ctx, qm, qm.FilterProperty)
+
+	case "raw":
+		// Handle raw mode by appending "_raw" to the filter property
+		rawProperty := qm.FilterProperty
+		if !strings.HasSuffix(rawProperty, "_raw") {
+			rawProperty += "_raw"
+		}
+		return d.handlePropertyQuery(ctx, qm, rawProperty)
+
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("Unknown query type: %s", qm.QueryType))
+	}
+}
+
+// setExecutionTime adds the wall-clock time of a query in milliseconds to the metadata of its frames.
+func setExecutionTime(response *backend.DataResponse, elapsed time.Duration) {
+	ms := float64(elapsed.Microseconds()) / 1000
+	for _, frame := range response.Frames {
+		if frame.Meta == nil {
+			frame.SetMeta(&data.FrameMeta{})
+		}
+		if frame.Meta.Custom == nil {
+			frame.Meta.Custom = map[string]interface{}{}
+		}
+		if custom, ok := frame.Meta.Custom.(map[string]interface{}); ok {
+			custom["executionTimeMs"] = ms
+		}
+	}
+}
+
+// handleMetricsQuery fetches the historical data of a sensor and builds a time series
+// for the requested channel.
+func (d *Datasource) handleMetricsQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	// Stat and gauge panels only need the newest value, see latest.go
+	if qm.LatestOnly {
+		return d.handleLatestQuery(ctx, qm)
+	}
+
+	fromTime := query.TimeRange.From.UnixMilli()
+	toTime := query.TimeRange.To.UnixMilli()
+
+	backend.Logger.Info("Fetching historical data",
+		"objectId", qm.ObjectId,
+		"channel", qm.Channel,
+		"channels", qm.Channels,
+		"from", fromTime,
+		"to", toTime)
+	_, isBucketAggregation, err := parseBucketAggregation(qm.Aggregation)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	if qm.Avg < 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("invalid query: avg must be a number of seconds, or 0 with avgExact for raw data, got %d", qm.Avg))
+	}
+
+	switch qm.MissingChannels {
+	case "":
+		qm.MissingChannels = missingChannelsNotice
+	case missingChannelsNotice, missingChannelsFail:
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("invalid query: unknown missingChannels %q, expected %q or %q",
+				qm.MissingChannels, missingChannelsNotice, missingChannelsFail))
+	}
+
+	// A user-specified interval is snapped to the closest value PRTG supports, unless it is exact
+	var avg int64
+	if qm.AvgExact {
+		avg = qm.Avg
+	} else if qm.Avg > 0 {
+		avg = snapAvgInterval(qm.Avg)
+		if avg != qm.Avg {
+			backend.Logger.Debug("Snapped averaging interval", "requested", qm.Avg, "avg", avg)
+		}
+	}
+
+	fetchHistoricalData := func(objid string) (*PrtgHistoricalDataResponse, error) {
+		if isBucketAggregation {
+			// Aggregations are computed from raw data, PRTG only delivers averages, see aggregation.go
+			return d.api.GetRawHistoricalData(ctx, objid, fromTime, toTime)
+		}
+		if qm.AvgExact && avg == 0 {
+			return d.api.GetRawHistoricalData(ctx, objid, fromTime, toTime)
+		}
+		if avg > 0 {
+			return d.api.GetHistoricalDataWithAvg(ctx, objid, fromTime, toTime, avg)
+		}
+		return d.api.GetHistoricalData(ctx, objid, fromTime, toTime)
+	}
+
+	// A query without any channel is handled according to its empty channel policy
+	defaultedToPrimary := false
+	if strings.TrimSpace(qm.Channel) == "" && len(qm.Channels) == 0 {
+		switch qm.EmptyChannel {
+		case "", emptyChannelAll:
+			qm.Channel = ""
+		case emptyChannelPrimary:
+			qm.Channel = primaryChannel
+			defaultedToPrimary = true
+		case emptyChannelError:
+			return backend.ErrDataResponse(backend.StatusBadRequest,
+				"invalid query: no channel selected, select a channel of the sensor")
+		default:
+			return backend.ErrDataResponse(backend.StatusBadRequest,
+				fmt.Sprintf("invalid query: unknown emptyChannel %q, expected %q, %q or %q",
+					qm.EmptyChannel, emptyChannelAll, emptyChannelPrimary, emptyChannelError))
+		}
+	}
+
+	// A multi-channel query lists its channels in Channels, otherwise Channel is used
+	isMultiChannel := len(qm.Channels) > 0
+	requestedChannels := []string{qm.Channel}
+	if isMultiChannel {
+		requestedChannels = qm.Channels
+	}
+	channels := make([]string, 0, len(requestedChannels))
+	for _, channel := range requestedChannels {
+		if isPrimaryChannel(channel) {
+			resolved, err := d.resolvePrimaryChannel(ctx, qm.ObjectId)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+			backend.Logger.Debug("Resolved primary channel", "objectId", qm.ObjectId, "channel", resolved)
+			channel = resolved
+		}
+		channels = append(channels, channel)
+	}
+
+	// Resolved before fetching, the PRTG clock is only read on the first query
+	timezone, timezoneSource := d.effectiveTimezone(ctx)
+
+	historicalData, err := fetchHistoricalData(qm.ObjectId)
+	if errors.Is(err, errNoHistoricData) {
+		backend.Logger.Debug("No historical data in the time range", "objectId", qm.ObjectId)
+		return emptyMetricsResponse(qm, channels)
+	}
+	if err != nil {
+		backend.Logger.Error("API request failed", "error", err)
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+	backend.Logger.Info("Received historical data", "dataPoints", len(historicalData.HistData))
+
+	// Without any channel, all channels present in the data are returned
+	if qm.Channel == "" && !isMultiChannel {
+		qm.Channels = dataChannels(historicalData)
+		if len(qm.Channels) == 0 {
+			return backend.ErrDataResponse(backend.StatusBadRequest, "no channels found in the historical data")
+		}
+		isMultiChannel = true
+		channels = qm.Channels
+	}
+
+	var notices []data.Notice
+	if defaultedToPrimary {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("No channel selected, the primary channel %s is shown", channels[0]),
+		})
+	}
+	if isMultiChannel {
+		found, missing := partitionChannels(historicalData, channels)
+		if len(missing) > 0 {
+			if qm.MissingChannels == missingChannelsFail || len(found) == 0 {
+				return backend.ErrDataResponse(backend.StatusBadRequest,
+					fmt.Sprintf("channels not found: %s", strings.Join(missing, ", ")))
+			}
+			// missingChannelsNotice: the found channels are returned with a warning
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Channels not found: %s", strings.Join(missing, ", ")),
+			})
+		}
+		channels = found
+	}
+
+	// Optionally subtract a second sensor/channel from the series
+	var subtractData *PrtgHistoricalDataResponse
+	if qm.SubtractObjectId != "" {
+		subtractData, err = fetchHistoricalData(qm.SubtractObjectId)
+		if errors.Is(err, errNoHistoricData) {
+			// Without points to subtract the difference series has no points either
+			backend.Logger.Debug("No historical data in the time range", "objectId", qm.SubtractObjectId)
+			return emptyMetricsResponse(qm, channels)
+		}
 		if err != nil {
 			backend.Logger.Error("API request failed", "error", err)
 			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
 		}
-		backend.Logger.Info("Received historical data", "dataPoints", len(historicalData.HistData))
+	}
 
-		// Assumption: historicalData.Treesize contains the value from the JSON ("treesize")
-		times := make([]time.Time, 0, len(historicalData.HistData))
-		values := make([]float64, 0, len(historicalData.HistData))
+	// Optionally explain flat or stale lines by the current sensor state
+	if qm.CheckSensorState {
+		details, err := d.api.GetSensorDetails(ctx, qm.ObjectId)
+		if err != nil {
+			backend.Logger.Warn("Sensor state check failed", "objectId", qm.ObjectId, "error", err)
+		} else if text, ok := sensorStateNotice(details.SensorData); ok {
+			notices = append(notices, data.Notice{Severity: data.NoticeSeverityWarning, Text: text})
+		}
+	}
+
+	// Manually configured PRTG colors, channels without one keep Grafana's automatic color
+	var colors map[string]string
+	if qm.ChannelColors {
+		colors = d.channelColors(ctx, qm.ObjectId, channels)
+	}
+
+	// Decimal places and units configured per channel, others keep Grafana's defaults
+	var formats map[string]channelFormat
+	if qm.ChannelFormat {
+		formats = d.channelFormats(ctx, qm.ObjectId, channels)
+	}
+
+	// PRTG delivers no unit with the historic data, optionally it is taken from the sensor tags
+	unit, hasUnit := d.tagUnit(ctx, qm.ObjectId)
 
-		backend.Logger.Debug("Parsing historical data", "channel", len(times))
+	for i, channel := range channels {
+		requestedChannel := requestedChannels[0]
+		if isMultiChannel {
+			requestedChannel = channel
+		}
+		frame, err := d.channelFrame(ctx, query, qm, channel, requestedChannel, historicalData, subtractData, timezone)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
 
-		for _, item := range historicalData.HistData {
-			parsedTime, _, err := parsePRTGDateTime(item.Datetime)
+		// PRTG's own unit info wins: the custom unit of the channel over the caption unit.
+		// The unit from the tags only applies if PRTG provides none.
+		if format, ok := formats[channel]; ok {
+			if format.decimals != nil {
+				frame.Fields[1].Config.Decimals = format.decimals
+			}
+			if format.unit != "" {
+				frame.Fields[1].Config.Unit = format.unit
+			}
+		}
+		if hasUnit && frame.Fields[1].Config.Unit == "" {
+			frame.Fields[1].Config.Unit = unit
+		}
+		if color, ok := colors[channel]; ok {
+			frame.Fields[1].Config.Color = map[string]interface{}{
+				"mode":       "fixed",
+				"fixedColor": color,
+			}
+		}
+
+		if custom, ok := frame.Meta.Custom.(map[string]interface{}); ok {
+			custom["avgInterval"] = historicalData.AvgInterval
+			custom["avgSource"] = historicalData.AvgSource
+			if avg > 0 && avg != qm.Avg && !isBucketAggregation {
+				custom["avgRequested"] = qm.Avg
+			}
+			custom["timezone"] = timezone.String()
+			custom["timezoneSource"] = timezoneSource
+		}
+		if i == 0 && historicalData.AvgSource == avgSourceFallback {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityInfo,
+				Text: fmt.Sprintf("Averaging interval raised to the configured minimum of %d seconds",
+					historicalData.AvgInterval),
+			})
+		}
+		if i == 0 && historicalData.Truncated {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text: fmt.Sprintf("PRTG returned the maximum of %d points per request even after paging, data may be incomplete. "+
+					"Narrow the time range, use a coarser averaging interval or raise the historic count setting",
+					d.api.historicMaxPoints),
+			})
+		}
+		if i == 0 && len(notices) > 0 {
+			frame.AppendNotices(notices...)
+		}
+
+		response.Frames = append(response.Frames, frame)
+	}
+	if qm.NormalizeUnit != "" {
+		normalizeUnits(response.Frames)
+	}
+	var summaries data.Frames
+	if qm.IncludeSummary {
+		for _, frame := range response.Frames {
+			if summary := summaryFrame(frame); summary != nil {
+				summaries = append(summaries, summary)
+			}
+		}
+	}
+	if !qm.SplitFrames && len(response.Frames) > 1 {
+		response.Frames = data.Frames{mergeChannelFrames(response.Frames)}
+	}
+	response.Frames = append(response.Frames, summaries...)
+	return response
+}
+
+// emptyMetricsResponse answers a metrics query without historic data in the time range with
+// an empty frame per channel and an informational notice instead of an error.
+func emptyMetricsResponse(qm queryModel, channels []string) backend.DataResponse {
+	var response backend.DataResponse
+	for i, channel := range channels {
+		timeFieldName, valueFieldName := qm.fieldNames(channel)
+		if len(qm.Channels) > 0 {
+			valueFieldName = channel
+		}
+		labels := qm.seriesLabels(channel, qm.Aggregation)
+		frame := data.NewFrame("response",
+			data.NewField(timeFieldName, nil, []time.Time{}),
+			data.NewField(valueFieldName, labels, []float64{}).SetConfig(&data.FieldConfig{
+				DisplayName: qm.seriesName(channel),
+			}),
+		)
+		frame.SetMeta(&data.FrameMeta{})
+		if i == 0 {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityInfo,
+				Text:     "PRTG has no data for the selected time range",
+			})
+		}
+		response.Frames = append(response.Frames, frame)
+	}
+	if !qm.SplitFrames && len(response.Frames) > 1 {
+		response.Frames = data.Frames{mergeChannelFrames(response.Frames)}
+	}
+	return response
+}
+
+// channelFrame builds the time series frame of a single channel. requestedChannel is the
+// channel as given in the query, it is used as default for the subtracted channel.
+func (d *Datasource) channelFrame(ctx context.Context, query backend.DataQuery, qm queryModel, channel, requestedChannel string,
+	historicalData, subtractData *PrtgHistoricalDataResponse, timezone *time.Location) (*data.Frame, error) {
+	aggregation, isBucketAggregation, err := parseBucketAggregation(qm.Aggregation)
+	if err != nil {
+		return nil, err
+	}
+
+	times, values, formatted, drops := extractChannelSeries(historicalData, channel, timezone, qm.RangeTimestamp, d.nonNumericPolicy)
+	times, values, formatted, drops.OutOfRange = dropOutOfRange(times, values, formatted, query.TimeRange,
+		time.Duration(historicalData.AvgInterval)*time.Second)
+	parsedCount := len(values)
+
+	// Normalization needs the unit of every point, which is only known from the captions
+	var unit captionUnit
+	hasUnit := false
+	fieldUnit := ""
+	if qm.NormalizeUnit != "" {
+		values, unit, hasUnit = seriesUnit(values, formatted)
+	} else {
+		// Normalized frames get their unit from normalizeUnits
+		fieldUnit = captionFieldUnit(values, formatted)
+	}
+
+	if qm.AlignToClock {
+		times = alignToInterval(times, time.Duration(historicalData.AvgInterval)*time.Second)
+	}
+
+	isDifference := subtractData != nil
+	var subtractChannel string
+	if isDifference {
+		subtractChannel = qm.SubtractChannel
+		if subtractChannel == "" {
+			subtractChannel = requestedChannel
+		}
+		if isPrimaryChannel(subtractChannel) {
+			var err error
+			subtractChannel, err = d.resolvePrimaryChannel(ctx, qm.SubtractObjectId)
 			if err != nil {
-				backend.Logger.Warn("Date parsing failed", "datetime", item.Datetime, "error", err)
-				continue
+				return nil, err
 			}
-			if val, ok := item.Value[qm.Channel]; ok {
-				switch v := val.(type) {
-				case float64:
-					values = append(values, v)
-				case string:
-					if floatVal, err := strconv.ParseFloat(v, 64); err == nil {
-						values = append(values, floatVal)
+		}
+		subtractTimes, subtractValues, _, _ := extractChannelSeries(subtractData, subtractChannel, timezone, qm.RangeTimestamp, d.nonNumericPolicy)
+		if qm.AlignToClock {
+			subtractTimes = alignToInterval(subtractTimes, time.Duration(subtractData.AvgInterval)*time.Second)
+		}
+
+		tolerance := time.Duration(qm.AlignTolerance) * time.Second
+		if tolerance <= 0 {
+			tolerance = defaultAlignTolerance(historicalData.AvgInterval)
+		}
+		times, values = alignedDifference(times, values, subtractTimes, subtractValues, tolerance)
+		// Formatted values describe the original points, not the difference
+		formatted = nil
+	}
+
+	if isBucketAggregation {
+		bucket := percentileBucketSize(query.Interval, query.TimeRange.From, query.TimeRange.To)
+		times, values = bucketAggregate(times, values, bucket, aggregation, timezone)
+		// Formatted values of single raw points do not describe a bucket aggregation
+		formatted = nil
+		backend.Logger.Debug("Computed aggregation buckets",
+			"aggregation", qm.Aggregation,
+			"bucket", bucket.String(),
+			"buckets", len(times))
+	}
+
+	// The summary describes all points, not only the changed or downsampled ones, see summary.go
+	summary := summarizeSeries(values)
+
+	if qm.ChangesOnly {
+		indices := changedPointIndices(values)
+		backend.Logger.Debug("Collapsed unchanged values", "points", len(values), "kept", len(indices))
+		times = selectIndices(times, indices)
+		values = selectIndices(values, indices)
+		if formatted != nil {
+			formatted = selectIndices(formatted, indices)
+		}
+	}
+
+	// The panel cannot show more points than its width, see downsample.go
+	pointsBeforeDownsample := len(values)
+	if query.MaxDataPoints > 0 && int64(len(values)) > query.MaxDataPoints {
+		indices := lttbIndices(times, values, int(query.MaxDataPoints))
+		backend.Logger.Debug("Downsampled series", "points", len(values), "maxDataPoints", query.MaxDataPoints, "kept", len(indices))
+		times = selectIndices(times, indices)
+		values = selectIndices(values, indices)
+		if formatted != nil {
+			formatted = selectIndices(formatted, indices)
+		}
+	}
+
+	displayName := qm.seriesName(channel)
+	if isDifference {
+		displayName = fmt.Sprintf("%s - %s", displayName, qm.SubtractObjectId)
+	}
+	if isBucketAggregation {
+		displayName = fmt.Sprintf("%s (%s)", displayName, aggregation.name)
+	}
+
+	timeFieldName, valueFieldName := qm.fieldNames(channel)
+	if len(qm.Channels) > 0 {
+		// A configured value field name would be ambiguous for several channels
+		valueFieldName = channel
+	}
+	// The key identifies the series independent of names, the difference partner is part of it
+	property := qm.Aggregation
+	if isDifference {
+		property = fmt.Sprintf("%s-%s/%s", property, qm.SubtractObjectId, subtractChannel)
+	}
+	labels := qm.seriesLabels(channel, property)
+
+	frame := data.NewFrame("response",
+		data.NewField(timeFieldName, nil, times),
+		data.NewField(valueFieldName, labels, values).SetConfig(&data.FieldConfig{
+			DisplayName: displayName,
+			Unit:        fieldUnit,
+		}),
+	)
+
+	// Optionally add PRTG's formatted representation next to the numeric value
+	if qm.IncludeFormattedValue && formatted != nil {
+		frame.Fields = append(frame.Fields,
+			data.NewField(valueFieldName+" (formatted)", nil, formatted).SetConfig(&data.FieldConfig{
+				DisplayName: displayName + " (formatted)",
+			}),
+		)
+	}
+
+	// Point counts make data loss between PRTG and the panel traceable
+	returned := historicalData.ReturnedCount
+	if returned == 0 {
+		returned = len(historicalData.HistData)
+	}
+	custom := map[string]interface{}{
+		"pointsReturned": returned,
+		"pointsParsed":   parsedCount,
+		"pointsDropped": map[string]int{
+			"duplicate":      historicalData.DuplicateCount,
+			"parseFailure":   drops.ParseFailure,
+			"invalidValue":   drops.InvalidValue,
+			"nonNumeric":     drops.NonNumeric,
+			"missingChannel": drops.MissingChannel,
+			"outOfRange":     drops.OutOfRange,
+		},
+		"summary": summary,
+	}
+	if len(values) < pointsBeforeDownsample {
+		custom["pointsDownsampled"] = pointsBeforeDownsample
+	}
+	if hasUnit {
+		custom["unit"] = unit.name
+		custom["normalizeUnit"] = qm.NormalizeUnit
+	}
+	frame.SetMeta(&data.FrameMeta{Custom: custom})
+	if drops.MissingChannel > 0 {
+		text := fmt.Sprintf("Channel %q was not found in the historic data, check the channel name", channel)
+		if parsedCount > 0 {
+			text = fmt.Sprintf("%d points without a value for channel %q were skipped", drops.MissingChannel, channel)
+		}
+		frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: text})
+	}
+	if drops.NonNumeric > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text: fmt.Sprintf("%d points of %s with values like \"<1\" or \">99\" were dropped, see the non-numeric value setting",
+				drops.NonNumeric, channel),
+		})
+	}
+
+	return frame, nil
+}
+
+// seriesName returns the display name of a channel series, prefixed by the group, device and
+// sensor names if the query includes them.
+func (qm queryModel) seriesName(channel string) string {
+	var parts []string
+	if qm.IncludeGroupName && qm.Group != "" {
+		parts = append(parts, qm.Group)
+	}
+	if qm.IncludeDeviceName && qm.Device != "" {
+		parts = append(parts, qm.Device)
+	}
+	if qm.IncludeSensorName && qm.Sensor != "" {
+		parts = append(parts, qm.Sensor)
+	}
+	parts = append(parts, channel)
+	return strings.Join(parts, " - ")
+}
+
+// fieldNames returns the names of the time and value fields of a frame. Without explicit names
+// the time field is called "Time" and the value field is named after defaultValue (the channel or
+// property), falling back to "Value".
+func (qm queryModel) fieldNames(defaultValue string) (string, string) {
+	timeFieldName := strings.TrimSpace(qm.TimeFieldName)
+	if timeFieldName == "" {
+		timeFieldName = "Time"
+	}
+	valueFieldName := strings.TrimSpace(qm.ValueFieldName)
+	if valueFieldName == "" {
+		valueFieldName = defaultValue
+	}
+	if valueFieldName == "" {
+		valueFieldName = "Value"
+	}
+	return timeFieldName, valueFieldName
+}
+
+// extractChannelSeries converts the historical data of a single channel into time and value slices.
+// The third slice holds PRTG's formatted representation of every value (e.g. "45.6 %"), or an
+// empty string where PRTG delivered only the number.
+// rangePosition selects the timestamp of averaged points whose datetime is a range,
+// nonNumericPolicy how text values like "<1" are treated (see values.go).
+func extractChannelSeries(historicalData *PrtgHistoricalDataResponse, channel string, loc *time.Location, rangePosition, nonNumericPolicy string) ([]time.Time, []float64, []string, seriesDrops) {
+	var drops seriesDrops
+	times := make([]time.Time, 0, len(historicalData.HistData))
+	values := make([]float64, 0, len(historicalData.HistData))
+	formatted := make([]string, 0, len(historicalData.HistData))
+
+	backend.Logger.Debug("Parsing historical data", "channel", channel)
+
+	for _, item := range historicalData.HistData {
+		parsedTime, err := parsePRTGDateTimeRange(item.Datetime, loc, rangePosition)
+		if err != nil {
+			backend.Logger.Warn("Date parsing failed", "datetime", item.Datetime, "error", err)
+			drops.ParseFailure++
+			continue
+		}
+		if val, ok := item.Value[channel]; ok {
+			switch v := val.(type) {
+			case float64:
+				values = append(values, v)
+				caption, _ := channelCaption(item.Value, channel)
+				formatted = append(formatted, caption)
+			case string:
+				if floatVal, err := strconv.ParseFloat(v, 64); err == nil {
+					values = append(values, floatVal)
+				} else if rawVal, ok := channelRawValue(item.Value, channel); ok {
+					// usecaption delivers "45.6 %" style strings, the numeric value is in the raw column
+					values = append(values, rawVal)
+				} else if numVal, ok := parseFormattedValue(v); ok {
+					// Locale formatted values like "1.234,5" or "45 %"
+					values = append(values, numVal)
+				} else if numVal, ok := parseNonNumericValue(v, nonNumericPolicy); ok {
+					values = append(values, numVal)
+				} else {
+					backend.Logger.Warn("Cannot convert value to float64", "value", v, "error", err)
+					if _, ok := parseNonNumericValue(v, nonNumericBound); ok {
+						drops.NonNumeric++
 					} else {
-						backend.Logger.Warn("Cannot convert value to float64", "value", v, "error", err)
-						continue
+						drops.InvalidValue++
 					}
-				default:
-					backend.Logger.Warn("Unexpected value type", "type", fmt.Sprintf("%T", v), "value", v)
 					continue
 				}
-				times = append(times, parsedTime)
-			} else {
-				backend.Logger.Warn("Channel not found in item.Value, using default value", "channel", qm.Channel)
-				times = append(times, parsedTime)
-				values = append(values, 0.0)
+				formatted = append(formatted, v)
+			default:
+				backend.Logger.Warn("Unexpected value type", "type", fmt.Sprintf("%T", v), "value", v)
+				drops.InvalidValue++
+				continue
 			}
+			times = append(times, parsedTime)
+		} else {
+			// A zero would draw a misleading flat line, the point is skipped and reported instead
+			drops.MissingChannel++
 		}
+	}
+	if drops.MissingChannel > 0 {
+		backend.Logger.Warn("Channel not found in historic data items", "channel", channel, "items", drops.MissingChannel)
+	}
+	return times, values, formatted, drops
+}
+
+// seriesDrops counts the points dropped while extracting a channel series, by reason.
+type seriesDrops struct {
+	ParseFailure int
+	InvalidValue int
+	NonNumeric   int // text values like "<1" dropped by the non-numeric value policy
+
+	// MissingChannel counts the items without a value for the channel
+	MissingChannel int
+
+	// OutOfRange counts the points outside the time range of the query, see dropOutOfRange
+	OutOfRange int
+}
+
+// dropOutOfRange removes the points outside timeRange and returns their number. Averaged
+// points are timestamped at the start, end or middle of their interval, so points up to
+// tolerance outside the range are kept. formatted may be nil.
+func dropOutOfRange(times []time.Time, values []float64, formatted []string, timeRange backend.TimeRange, tolerance time.Duration) ([]time.Time, []float64, []string, int) {
+	if timeRange.From.IsZero() || timeRange.To.IsZero() {
+		return times, values, formatted, 0
+	}
+	from, to := timeRange.From.Add(-tolerance), timeRange.To.Add(tolerance)
 
-		var parts []string
-		if qm.IncludeGroupName && qm.Group != "" {
-			parts = append(parts, qm.Group)
+	kept := 0
+	for i, t := range times {
+		if t.Before(from) || t.After(to) {
+			continue
 		}
-		if qm.IncludeDeviceName && qm.Device != "" {
-			parts = append(parts, qm.Device)
+		times[kept], values[kept] = t, values[i]
+		if formatted != nil {
+			formatted[kept] = formatted[i]
 		}
-		if qm.IncludeSensorName && qm.Sensor != "" {
-			parts = append(parts, qm.Sensor)
+		kept++
+	}
+	dropped := len(times) - kept
+	if formatted != nil {
+		formatted = formatted[:kept]
+	}
+	return times[:kept], values[:kept], formatted, dropped
+}
+
+// isNumericProperty reports whether a property query for filterProperty yields numbers, which
+// decides the value field type of a frame without rows.
+func isNumericProperty(filterProperty string) bool {
+	switch filterProperty {
+	case "active_raw", "priority_raw", "status_raw":
+		return true
+	default:
+		return false
+	}
+}
+
+// propertyValueField creates the value field of a property frame. The field type is chosen
+// from all values: numeric if every value is a number, string otherwise. Mixed values are
+// converted to strings so that no value is dropped or mistyped.
+func propertyValueField(name string, values []interface{}) *data.Field {
+	numeric, text := 0, 0
+	for _, v := range values {
+		switch v.(type) {
+		case float64, int:
+			numeric++
+		case string:
+			text++
 		}
-		parts = append(parts, qm.Channel)
-		displayName := strings.Join(parts, " - ")
+	}
 
-		frame := data.NewFrame("response",
-			data.NewField("Time", nil, times),
-			data.NewField("Value", nil, values).SetConfig(&data.FieldConfig{
-				DisplayName: displayName,
-			}),
-		)
+	if numeric == len(values) {
+		// Convert all values to float64
+		floatVals := make([]float64, len(values))
+		for i, v := range values {
+			switch tv := v.(type) {
+			case float64:
+				floatVals[i] = tv
+			case int:
+				floatVals[i] = float64(tv)
+			}
+		}
+		return data.NewField(name, nil, floatVals)
+	}
 
-		response.Frames = append(response.Frames, frame)
+	if numeric > 0 && numeric+text == len(values) {
+		backend.Logger.Warn("Mixed numeric and string property values, using strings",
+			"field", name,
+			"numeric", numeric,
+			"strings", text)
+	}
 
-	case "text":
-		// Handle text mode by using the non-raw property
-		return d.handlePropertyQuery(qm, qm.FilterProperty)
+	// Keep strings as they are and convert other types to strings
+	strVals := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			strVals[i] = s
+		} else {
+			strVals[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return data.NewField(name, nil, strVals)
+}
 
-	case "raw":
-		// Handle raw mode by appending "_raw" to the filter property
-		rawProperty := qm.FilterProperty
-		if !strings.HasSuffix(rawProperty, "_raw") {
-			rawProperty += "_raw"
+// Policies for channels of a multi-channel query that are missing in the historic data:
+// return the found channels with a notice listing the missing ones (default), or fail.
+const (
+	missingChannelsNotice = "notice"
+	missingChannelsFail   = "fail"
+)
+
+// Policies for metrics queries without any channel: all channels of the data (default), the
+// primary channel of the sensor with a notice, or an error asking to select a channel.
+const (
+	emptyChannelAll     = "all"
+	emptyChannelPrimary = "primary"
+	emptyChannelError   = "error"
+)
+
+// partitionChannels splits the requested channels into those present in the historic data
+// and those missing, keeping the requested order.
+func partitionChannels(historicalData *PrtgHistoricalDataResponse, channels []string) ([]string, []string) {
+	present := make(map[string]bool)
+	for _, item := range historicalData.HistData {
+		for key := range item.Value {
+			present[key] = true
 		}
-		return d.handlePropertyQuery(qm, rawProperty)
+	}
 
-	default:
-		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("Unknown query type: %s", qm.QueryType))
+	var found, missing []string
+	for _, channel := range channels {
+		if present[channel] {
+			found = append(found, channel)
+		} else {
+			missing = append(missing, channel)
+		}
+	}
+	return found, missing
+}
+
+// channelCaption looks up the formatted value PRTG delivers next to a raw channel column, e.g.
+// "45.6 %" in "CPU Load" for the channel "CPU Load(RAW)".
+func channelCaption(item map[string]interface{}, channel string) (string, bool) {
+	for _, suffix := range []string{" (RAW)", "(RAW)", "_raw"} {
+		if base, ok := strings.CutSuffix(channel, suffix); ok {
+			caption, ok := item[base].(string)
+			return caption, ok
+		}
+	}
+	return "", false
+}
+
+// channelRawValue looks up the numeric raw column PRTG delivers next to a formatted channel value.
+func channelRawValue(item map[string]interface{}, channel string) (float64, bool) {
+	for _, key := range []string{channel + "(RAW)", channel + " (RAW)", channel + "_raw"} {
+		if v, ok := item[key].(float64); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// handleMessageChangesQuery returns an event stream with one point per change of the sensor message,
+// suitable for annotations.
+func (d *Datasource) handleMessageChangesQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	messages, err := d.api.GetMessages(ctx, qm.ObjectId, query.TimeRange.From.UnixMilli(), query.TimeRange.To.UnixMilli())
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	timezone, _ := d.effectiveTimezone(ctx)
+	times, texts := messageTransitions(messages.Messages, timezone)
+	backend.Logger.Debug("Computed message transitions",
+		"objectId", qm.ObjectId,
+		"messages", len(messages.Messages),
+		"transitions", len(times))
+
+	timeFieldName, _ := qm.fieldNames("")
+	frame := data.NewFrame("response",
+		data.NewField(timeFieldName, nil, times),
+		data.NewField("Text", nil, texts),
+	)
+	response.Frames = append(response.Frames, frame)
+	return response
+}
+
+// handleDowntimeQuery returns the uptime and downtime percentage of a sensor as a single-value
+// frame. The values reported by PRTG are preferred, see status.go for the computed fallback.
+func (d *Datasource) handleDowntimeQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	details, err := d.api.GetSensorDetails(ctx, qm.ObjectId)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	source := "reported"
+	uptime, downtime, ok := reportedUptime(details.SensorData)
+	if !ok {
+		backend.Logger.Debug("Sensor reports no uptime, computing it from the message log", "objectId", qm.ObjectId)
+		messages, err := d.api.GetMessages(ctx, qm.ObjectId, query.TimeRange.From.UnixMilli(), query.TimeRange.To.UnixMilli())
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+		}
+		timezone, _ := d.effectiveTimezone(ctx)
+		uptime, ok = computedUptime(messages.Messages, query.TimeRange.From, query.TimeRange.To, d.unknownStatusPolicy, timezone)
+		if !ok {
+			return backend.ErrDataResponse(backend.StatusBadRequest, "no uptime data available for the given time range")
+		}
+		downtime = 100 - uptime
+		source = "computed"
+	}
+
+	frame := data.NewFrame("response",
+		data.NewField("Uptime", nil, []float64{uptime}).SetConfig(&data.FieldConfig{Unit: "percent"}),
+		data.NewField("Downtime", nil, []float64{downtime}).SetConfig(&data.FieldConfig{Unit: "percent"}),
+	)
+	frame.SetMeta(&data.FrameMeta{
+		Custom: map[string]interface{}{
+			"source": source,
+		},
+	})
+
+	response.Frames = append(response.Frames, frame)
+	return response
+}
+
+// handleStatusTransitionsQuery counts the status changes of a sensor in the time range.
+// High counts indicate a flapping sensor. The transition times are added as a second frame
+// if requested.
+func (d *Datasource) handleStatusTransitionsQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	messages, err := d.api.GetMessages(ctx, qm.ObjectId, query.TimeRange.From.UnixMilli(), query.TimeRange.To.UnixMilli())
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	timezone, _ := d.effectiveTimezone(ctx)
+	times, states := statusTransitions(messages.Messages, timezone)
+	response.Frames = append(response.Frames, data.NewFrame("transitions",
+		data.NewField("Transitions", nil, []int64{int64(len(times))}),
+	))
+
+	if qm.IncludeTransitionTimes {
+		timeFieldName, _ := qm.fieldNames("")
+		response.Frames = append(response.Frames, data.NewFrame("transition times",
+			data.NewField(timeFieldName, nil, times),
+			data.NewField("Status", nil, states),
+		))
+	}
+	return response
+}
+
+// handleDeviceReachabilityQuery returns whether the device given by ObjectId answers its
+// ping sensor. Devices without a ping sensor get an empty indicator and a notice.
+func (d *Datasource) handleDeviceReachabilityQuery(ctx context.Context, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	sensors, err := d.api.GetDeviceSensors(ctx, qm.ObjectId)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	var reachable *bool
+	sensorName, status := "", ""
+	ping, found := findPingSensor(sensors.Sensors, qm.PingSensorType, qm.PingSensorName)
+	if found {
+		reachable = reachability(ping.StatusRAW)
+		sensorName, status = ping.Sensor, ping.Status
+	}
+
+	frame := data.NewFrame("response",
+		data.NewField("Sensor", nil, []string{sensorName}),
+		data.NewField("Status", nil, []string{status}),
+		data.NewField("Reachable", nil, []*bool{reachable}),
+	)
+	if !found {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("Device %s has no ping sensor", qm.ObjectId),
+		})
+	}
+
+	response.Frames = append(response.Frames, frame)
+	return response
+}
+
+// handleAlarmsByPriorityQuery returns the number of current alarms per priority.
+func (d *Datasource) handleAlarmsByPriorityQuery(ctx context.Context) backend.DataResponse {
+	var response backend.DataResponse
+
+	alarms, err := d.api.GetAlarms(ctx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	priorities, counts, skipped := alarmCountsByPriority(alarms.Alarms)
+	if skipped > 0 {
+		backend.Logger.Warn("Alarms without readable priority", "count", skipped)
 	}
 
+	frame := data.NewFrame("response",
+		data.NewField("Priority", nil, priorities),
+		data.NewField("Count", nil, counts),
+	)
+	response.Frames = append(response.Frames, frame)
 	return response
 }
 
+// handleValueThresholdQuery returns the sensors whose last value crosses the threshold.
+// PRTG cannot filter by value, so the full sensor list is fetched and filtered client-side.
+func (d *Datasource) handleValueThresholdQuery(ctx context.Context, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	if qm.Threshold == nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "invalid query: missing threshold")
+	}
+	comparator, err := parseComparator(qm.Comparator)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	sensors, err := d.api.GetSensorValues(ctx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	matches, values, skipped := sensorsCrossingThreshold(sensors.Sensors, comparator, *qm.Threshold)
+	if skipped > 0 {
+		backend.Logger.Debug("Sensors without numeric value", "count", skipped)
+	}
+
+	objids := make([]int64, len(matches))
+	names := make([]string, len(matches))
+	devices := make([]string, len(matches))
+	lastValues := make([]string, len(matches))
+	for i, sensor := range matches {
+		objids[i] = sensor.ObjectId
+		names[i] = sensor.Sensor
+		devices[i] = sensor.Device
+		lastValues[i] = cleanMessageHTML(sensor.LastValue)
+	}
+
+	frame := data.NewFrame("response",
+		data.NewField("Object ID", nil, objids),
+		data.NewField("Sensor", nil, names),
+		data.NewField("Device", nil, devices),
+		data.NewField("Value", nil, values),
+		data.NewField("Last Value", nil, lastValues),
+	)
+	response.Frames = append(response.Frames, frame)
+	return response
+}
+
+// handleBackgroundTasksQuery returns the number of running tasks of the PRTG core server.
+func (d *Datasource) handleBackgroundTasksQuery(ctx context.Context) backend.DataResponse {
+	var response backend.DataResponse
+
+	status, err := d.api.GetStatusList(ctx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	tasks := parseBackgroundTasks(status)
+	frame := data.NewFrame("response",
+		data.NewField("Background", nil, []int64{tasks.Background}),
+		data.NewField("Correlation", nil, []int64{tasks.Correlation}),
+		data.NewField("Auto-Discovery", nil, []int64{tasks.AutoDiscovery}),
+		data.NewField("Reports", nil, []int64{tasks.Reports}),
+	)
+	response.Frames = append(response.Frames, frame)
+	return response
+}
+
+// handleNotificationsQuery lists the current alarms with their notification trigger count.
+func (d *Datasource) handleNotificationsQuery(ctx context.Context, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	alarms, err := d.api.GetNotificationAlarms(ctx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	sensors, counts := triggeredNotifications(alarms.Sensors, qm.NotifiedOnly)
+	objids := make([]int64, len(sensors))
+	names := make([]string, len(sensors))
+	devices := make([]string, len(sensors))
+	statuses := make([]string, len(sensors))
+	for i, sensor := range sensors {
+		objids[i] = sensor.ObjectId
+		names[i] = sensor.Sensor
+		devices[i] = sensor.Device
+		statuses[i] = sensor.Status
+	}
+
+	frame := data.NewFrame("response",
+		data.NewField("Object ID", nil, objids),
+		data.NewField("Sensor", nil, names),
+		data.NewField("Device", nil, devices),
+		data.NewField("Status", nil, statuses),
+		data.NewField("Notifications", nil, counts),
+	)
+	if len(sensors) == 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     "No active notifications",
+		})
+	}
+
+	response.Frames = append(response.Frames, frame)
+	return response
+}
+
+// messageTransitions sorts the log entries by time and keeps only the entries whose message
+// differs from the previous one. Consecutive identical messages are collapsed into the first.
+// Datetimes without zone are read in loc.
+func messageTransitions(messages []PrtgMessageListItemStruct, loc *time.Location) ([]time.Time, []string) {
+	type entry struct {
+		time time.Time
+		text string
+	}
+
+	entries := make([]entry, 0, len(messages))
+	for _, m := range messages {
+		timestamp, _, err := parsePRTGDateTimeIn(m.Datetime, loc)
+		if err != nil {
+			backend.Logger.Warn("Date parsing failed", "datetime", m.Datetime, "error", err)
+			continue
+		}
+		text := m.MessageRAW
+		if text == "" {
+			text = cleanMessageHTML(m.Message)
+		}
+		entries = append(entries, entry{time: timestamp, text: text})
+	}
+
+	// PRTG returns the newest entry first
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+
+	times := make([]time.Time, 0, len(entries))
+	texts := make([]string, 0, len(entries))
+	for i, e := range entries {
+		if i > 0 && e.text == entries[i-1].text {
+			continue
+		}
+		times = append(times, e.time)
+		texts = append(texts, e.text)
+	}
+	return times, texts
+}
+
+// selectPropertyObjects returns the objects a property query refers to. The objid is
+// preferred and also scopes the table fetch; if it is empty or matches no object of the
+// list (e.g. the objid of a sensor in a group query), the objects are matched by name.
+// Tags of byName narrow both fetches; with tags but without a name all tagged objects match.
+// An object that is found by its objid but lacks the tags matches nothing.
+func selectPropertyObjects[T any](objid, name string, byName listFilter, fetch func(listFilter) ([]T, error), key func(T) (int64, string)) ([]T, error) {
+	var matched []T
+	hasTags := byName.tagFilter() != ""
+	if objid = strings.TrimSpace(objid); objid != "" {
+		items, err := fetch(listFilter{ObjectId: objid, Tags: byName.Tags})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if id, _ := key(item); strconv.FormatInt(id, 10) == objid {
+				matched = append(matched, item)
+			}
+		}
+		if len(matched) > 0 || (name == "" && !hasTags) {
+			return matched, nil
+		}
+		if hasTags {
+			// Tell an object without the tags from an objid of another object type
+			untagged, err := fetch(listFilter{ObjectId: objid})
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range untagged {
+				if id, _ := key(item); strconv.FormatInt(id, 10) == objid {
+					return nil, nil
+				}
+			}
+		}
+	}
+	if name == "" && !hasTags {
+		return matched, nil
+	}
+
+	items, err := fetch(byName)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if _, itemName := key(item); name == "" || itemName == name {
+			matched = append(matched, item)
+		}
+	}
+	return matched, nil
+}
+
+// parentObjectId returns the objid of the parent a property query for the given content is
+// scoped to: the group for devices, the device for sensors. The parent is looked up by name in
+// the cached object lists, an empty, unknown or ambiguous name yields "" (no scoping).
+func (d *Datasource) parentObjectId(ctx context.Context, qm queryModel, content string) string {
+	var ids []int64
+	switch {
+	case content == "devices" && qm.Group != "":
+		groups, err := cachedTree(ctx, d.treeCache, "groups", d.api.GetGroups)
+		if err != nil {
+			return ""
+		}
+		for _, g := range groups.Groups {
+			if g.Group == qm.Group {
+				ids = append(ids, g.ObjectId)
+			}
+		}
+	case content == "sensors" && qm.Device != "":
+		devices, err := cachedTree(ctx, d.treeCache, "devices", d.api.GetDevices)
+		if err != nil {
+			return ""
+		}
+		for _, dev := range devices.Devices {
+			if dev.Device == qm.Device && (qm.Group == "" || dev.Group == qm.Group) {
+				ids = append(ids, dev.ObjectId)
+			}
+		}
+	}
+	if len(ids) != 1 {
+		return ""
+	}
+	return strconv.FormatInt(ids[0], 10)
+}
+
 // handlePropertyQuery processes a property query based on the queryModel (qm)
 // and a filter property.
-func (d *Datasource) handlePropertyQuery(qm queryModel, filterProperty string) backend.DataResponse {
+func (d *Datasource) handlePropertyQuery(ctx context.Context, qm queryModel, filterProperty string) backend.DataResponse {
 	var response backend.DataResponse
-	var times []time.Time
+	times := []time.Time{}
 	var values []interface{}
+	neverPolled := 0
 
 	if !d.isValidPropertyType(qm.Property) {
 		return backend.ErrDataResponse(backend.StatusBadRequest, "Invalid property type")
 	}
+	if err := (listFilter{Tags: qm.Tags}).validate(); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid query: %v", err))
+	}
+	timezone, _ := d.effectiveTimezone(ctx)
 
 	switch qm.Property {
 	case "group":
-		groups, err := d.api.GetGroups()
+		groups, err := selectPropertyObjects(qm.ObjectId, qm.Group, listFilter{Group: qm.Group, Tags: qm.Tags},
+			func(filter listFilter) ([]PrtgGroupListItemStruct, error) {
+				resp, err := d.api.GetGroups(ctx, filter)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Groups, nil
+			},
+			func(g PrtgGroupListItemStruct) (int64, string) { return g.ObjectId, g.Group })
 		if err != nil {
 			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
 		}
-		for _, g := range groups.Groups {
-			if g.Group == qm.Group {
-				timestamp, _, err := parsePRTGDateTime(g.Datetime)
-				if err != nil {
-					backend.Logger.Warn("Date parsing failed", "datetime", g.Datetime, "error", err)
-					continue
-				}
+		for _, g := range groups {
+			timestamp, missing, err := d.propertyTimestamp(g.Datetime, g.DatetimeRAW, timezone)
+			if err != nil {
+				backend.Logger.Warn("Date parsing failed", "datetime", g.Datetime, "error", err)
+				continue
+			}
+			if missing {
+				neverPolled++
+			}
 
-				// Retrieve the property value based on filterProperty
-				var value interface{}
-				switch filterProperty {
-				case "active":
-					value = g.Active
-				case "active_raw":
-					value = g.ActiveRAW
-				case "message":
-					value = cleanMessageHTML(g.Message)
-				case "message_raw":
-					value = g.MessageRAW
-				case "priority":
-					value = g.Priority
-				case "priority_raw":
-					value = g.PriorityRAW
-				case "status":
-					value = g.Status
-				case "status_raw":
-					value = g.StatusRAW
-				case "tags":
-					value = g.Tags
-				case "tags_raw":
-					value = g.TagsRAW
-				}
+			// Retrieve the property value based on filterProperty
+			var value interface{}
+			switch filterProperty {
+			case "active":
+				value = g.Active
+			case "active_raw":
+				value = g.ActiveRAW
+			case "message":
+				value = cleanMessageHTML(g.Message)
+			case "message_raw":
+				value = g.MessageRAW
+			case "priority":
+				value = g.Priority
+			case "priority_raw":
+				value = g.PriorityRAW
+			case "status":
+				_, value = mapStatus(g.StatusRAW, g.Status, d.unknownStatusPolicy)
+			case "status_raw":
+				value, _ = mapStatus(g.StatusRAW, g.Status, d.unknownStatusPolicy)
+			case "tags":
+				value = g.Tags
+			case "tags_raw":
+				value = g.TagsRAW
+			}
 
-				if value != nil {
-					times = append(times, timestamp)
-					values = append(values, value)
-					backend.Logger.Debug("Adding value", "timestamp", timestamp, "value", value)
-				}
+			if value != nil {
+				times = append(times, timestamp)
+				values = append(values, value)
+				backend.Logger.Debug("Adding value", "timestamp", timestamp, "value", value)
 			}
 		}
 
 	case "device":
 		// Similar structure for devices
-		devices, err := d.api.GetDevices()
+		devices, err := selectPropertyObjects(qm.ObjectId, qm.Device, listFilter{ParentId: d.parentObjectId(ctx, qm, "devices"), Device: qm.Device, Tags: qm.Tags},
+			func(filter listFilter) ([]PrtgDeviceListItemStruct, error) {
+				resp, err := d.api.GetDevices(ctx, filter)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Devices, nil
+			},
+			func(dev PrtgDeviceListItemStruct) (int64, string) { return dev.ObjectId, dev.Device })
 		if err != nil {
 			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
 		}
-		for _, dev := range devices.Devices {
-			if dev.Device == qm.Device {
-				timestamp, _, err := parsePRTGDateTime(dev.Datetime)
-				if err != nil {
-					continue
-				}
+		for _, dev := range devices {
+			timestamp, missing, err := d.propertyTimestamp(dev.Datetime, dev.DatetimeRAW, timezone)
+			if err != nil {
+				continue
+			}
+			if missing {
+				neverPolled++
+			}
 
-				var value interface{}
-				switch filterProperty {
-				case "active":
-					value = dev.Active
-				case "active_raw":
-					value = dev.ActiveRAW
-				case "message":
-					value = cleanMessageHTML(dev.Message)
-				case "message_raw":
-					value = dev.MessageRAW
-				case "priority":
-					value = dev.Priority
-				case "priority_raw":
-					value = dev.PriorityRAW
-				case "status":
-					value = dev.Status
-				case "status_raw":
-					value = dev.StatusRAW
-				case "tags":
-					value = dev.Tags
-				case "tags_raw":
-					value = dev.TagsRAW
-				}
+			var value interface{}
+			switch filterProperty {
+			case "active":
+				value = dev.Active
+			case "active_raw":
+				value = dev.ActiveRAW
+			case "message":
+				value = cleanMessageHTML(dev.Message)
+			case "message_raw":
+				value = dev.MessageRAW
+			case "priority":
+				value = dev.Priority
+			case "priority_raw":
+				value = dev.PriorityRAW
+			case "status":
+				_, value = mapStatus(dev.StatusRAW, dev.Status, d.unknownStatusPolicy)
+			case "status_raw":
+				value, _ = mapStatus(dev.StatusRAW, dev.Status, d.unknownStatusPolicy)
+			case "tags":
+				value = dev.Tags
+			case "tags_raw":
+				value = dev.TagsRAW
+			}
 
-				if value != nil {
-					times = append(times, timestamp)
-					values = append(values, value)
-					backend.Logger.Debug("Adding value", "timestamp", timestamp, "value", value)
-				}
+			if value != nil {
+				times = append(times, timestamp)
+				values = append(values, value)
+				backend.Logger.Debug("Adding value", "timestamp", timestamp, "value", value)
 			}
 		}
 
 	case "sensor":
-		sensors, err := d.api.GetSensors()
+		sensors, err := selectPropertyObjects(qm.ObjectId, qm.Sensor, listFilter{ParentId: d.parentObjectId(ctx, qm, "sensors"), Sensor: qm.Sensor, Tags: qm.Tags},
+			func(filter listFilter) ([]PrtgSensorListItemStruct, error) {
+				resp, err := d.api.GetSensors(ctx, filter)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Sensors, nil
+			},
+			func(s PrtgSensorListItemStruct) (int64, string) { return s.ObjectId, s.Sensor })
 		if err != nil {
 			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
 		}
 
 		backend.Logger.Debug("Processing sensors response",
-			"sensorCount", len(sensors.Sensors),
+			"sensorCount", len(sensors),
+			"objectId", qm.ObjectId,
 			"lookingFor", qm.Sensor,
 			"filterProperty", filterProperty)
 
-		for _, s := range sensors.Sensors {
-			if s.Sensor == qm.Sensor {
-				timestamp, _, err := parsePRTGDateTime(s.Datetime)
-				if err != nil {
-					backend.Logger.Error("Failed to parse sensor datetime",
-						"sensor", s.Sensor,
-						"datetime", s.Datetime,
-						"error", err)
-					continue
-				}
+		for _, s := range sensors {
+			timestamp, missing, err := d.propertyTimestamp(s.Datetime, s.DatetimeRAW, timezone)
+			if err != nil {
+				backend.Logger.Error("Failed to parse sensor datetime",
+					"sensor", s.Sensor,
+					"datetime", s.Datetime,
+					"error", err)
+				continue
+			}
+			if missing {
+				neverPolled++
+			}
 
-				// Retrieve the value based on filterProperty
-				var value interface{}
-				switch filterProperty {
-				case "status", "status_raw":
-					if filterProperty == "status_raw" {
-						value = float64(s.StatusRAW) // Convert to float64 for consistent graphing
-					} else {
-						value = s.Status
-					}
-				case "active", "active_raw":
-					if filterProperty == "active_raw" {
-						value = float64(s.ActiveRAW)
-					} else {
-						value = s.Active
-					}
-				case "priority", "priority_raw":
-					if filterProperty == "priority_raw" {
-						value = float64(s.PriorityRAW)
-					} else {
-						value = s.Priority
-					}
-				case "message", "message_raw":
-					if filterProperty == "message_raw" {
-						value = s.MessageRAW
-					} else {
-						value = cleanMessageHTML(s.Message)
-					}
-				case "tags", "tags_raw":
-					if filterProperty == "tags_raw" {
-						value = s.TagsRAW
-					} else {
-						value = s.Tags
-					}
+			// Retrieve the value based on filterProperty
+			var value interface{}
+			switch filterProperty {
+			case "status", "status_raw":
+				statusRaw, status := mapStatus(s.StatusRAW, s.Status, d.unknownStatusPolicy)
+				if filterProperty == "status_raw" {
+					value = float64(statusRaw) // Convert to float64 for consistent graphing
+				} else {
+					value = status
 				}
-
-				if value != nil {
-					times = append(times, timestamp)
-					values = append(values, value)
-					backend.Logger.Debug("Adding data point",
-						"timestamp", timestamp,
-						"value", value,
-						"filterProperty", filterProperty,
-						"sensor", qm.Sensor)
+			case "active", "active_raw":
+				if filterProperty == "active_raw" {
+					value = float64(s.ActiveRAW)
+				} else {
+					value = s.Active
 				}
-			}
-		}
-	}
-
-	// Create a frame with proper field configuration
-	if len(times) > 0 && len(values) > 0 {
-		timeField := data.NewField("Time", nil, times)
-
-		// Determine the type of values and create an appropriate field
-		var valueField *data.Field
-		if len(values) > 0 {
-			switch values[0].(type) {
-			case float64, int:
-				// Convert all values to float64
-				floatVals := make([]float64, len(values))
-				for i, v := range values {
-					switch tv := v.(type) {
-					case float64:
-						floatVals[i] = tv
-					case int:
-						floatVals[i] = float64(tv)
-					}
+			case "priority", "priority_raw":
+				if filterProperty == "priority_raw" {
+					value = float64(s.PriorityRAW)
+				} else {
+					value = s.Priority
 				}
-				valueField = data.NewField("Value", nil, floatVals)
-			case string:
-				// Keep string values as they are
-				strVals := make([]string, len(values))
-				for i, v := range values {
-					strVals[i] = v.(string)
+			case "message", "message_raw":
+				if filterProperty == "message_raw" {
+					value = s.MessageRAW
+				} else {
+					value = cleanMessageHTML(s.Message)
 				}
-				valueField = data.NewField("Value", nil, strVals)
-			default:
-				// Convert other types to strings
-				strVals := make([]string, len(values))
-				for i, v := range values {
-					strVals[i] = fmt.Sprintf("%v", v)
+			case "tags", "tags_raw":
+				if filterProperty == "tags_raw" {
+					value = s.TagsRAW
+				} else {
+					value = s.Tags
 				}
-				valueField = data.NewField("Value", nil, strVals)
 			}
-		}
 
-		// Set display name
-		displayName := fmt.Sprintf("%s - %s (%s)", qm.Property, qm.Sensor, filterProperty)
-		valueField.Config = &data.FieldConfig{
-			DisplayName: displayName,
+			if value != nil {
+				times = append(times, timestamp)
+				values = append(values, value)
+				backend.Logger.Debug("Adding data point",
+					"timestamp", timestamp,
+					"value", value,
+					"filterProperty", filterProperty,
+					"sensor", qm.Sensor)
+			}
 		}
+	}
 
-		frame := data.NewFrame("response",
-			timeField,
-			valueField,
-		)
+	// Create a frame with proper field configuration. Without matches the frame is still
+	// returned with its schema, so transformations and alert rules see a consistent structure.
+	timeFieldName, valueFieldName := qm.fieldNames(filterProperty)
+	timeField := data.NewField(timeFieldName, nil, times)
 
-		response.Frames = append(response.Frames, frame)
-		backend.Logger.Debug("Created frame",
-			"frameLength", len(response.Frames),
-			"timePoints", len(times),
-			"valuePoints", len(values))
+	// Determine the type of values and create an appropriate field
+	var valueField *data.Field
+	switch {
+	case len(values) > 0:
+		valueField = propertyValueField(valueFieldName, values)
+	case isNumericProperty(filterProperty):
+		valueField = data.NewField(valueFieldName, nil, []float64{})
+	default:
+		valueField = data.NewField(valueFieldName, nil, []string{})
 	}
 
+	// Set display name
+	displayName := fmt.Sprintf("%s - %s (%s)", qm.Property, qm.Sensor, filterProperty)
+	valueField.Config = &data.FieldConfig{
+		DisplayName: displayName,
+	}
+	if filterProperty == "status_raw" {
+		valueField.Config.Mappings = statusValueMappings()
+	}
+
+	frame := data.NewFrame("response",
+		timeField,
+		valueField,
+	)
+	if neverPolled > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("%d objects have no data time yet (never polled), they are shown at epoch zero", neverPolled),
+		})
+	}
+	if len(values) == 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("No %s matches the query", qm.Property),
+		})
+	}
+
+	response.Frames = append(response.Frames, frame)
+	backend.Logger.Debug("Created frame",
+		"frameLength", len(response.Frames),
+		"timePoints", len(times),
+		"valuePoints", len(values))
+
 	return response
 }
 
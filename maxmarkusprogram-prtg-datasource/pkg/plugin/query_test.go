@@ -2,16 +2,24 @@ package plugin
 
 import (
 	"context"
-
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
+// histdataTimeRange covers the fixed 2025-02-15 samples of the mocked histdata responses.
+var histdataTimeRange = backend.TimeRange{
+	From: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+	To:   time.Date(2025, 2, 16, 0, 0, 0, 0, time.UTC),
+}
+
 // ✅ Mock API sunucusu oluştur
 func setupMockAPI(responseBody string, statusCode int) (*httptest.Server, *Api) {
 	mux := http.NewServeMux()
@@ -192,3 +200,649 @@ func TestCleanMessageHTML(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expected, result)
 	}
 }
+
+// ✅ QueryData test: Metric sorgusu with formatted values
+func TestQueryData_MetricsFormattedValue(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "CPU Load": "45.6 %", "CPU Load(RAW)": 45.6},
+		{"datetime": "2025-02-15T12:01:00Z", "CPU Load": "47.1 %", "CPU Load(RAW)": 47.1}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load","includeFormattedValue":true}`),
+		TimeRange: histdataTimeRange,
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 1 {
+		t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+	}
+
+	fields := resp.Frames[0].Fields
+	if len(fields) != 3 {
+		t.Fatalf("Expected Time, Value and Formatted fields, got %d fields", len(fields))
+	}
+	if fields[1].At(0).(float64) != 45.6 {
+		t.Errorf("Expected numeric value 45.6, got %v", fields[1].At(0))
+	}
+	if fields[2].Name != "CPU Load (formatted)" || fields[2].At(1).(string) != "47.1 %" {
+		t.Errorf("Expected formatted value '47.1 %%', got %v", fields[2].At(1))
+	}
+
+	// The raw column gets PRTG's caption as formatted value, not the printed number
+	query.JSON = []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load(RAW)","includeFormattedValue":true}`)
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if formatted := resp.Frames[0].Fields[2].At(0).(string); formatted != "45.6 %" {
+		t.Errorf("Expected formatted value '45.6 %%' for the raw column, got %q", formatted)
+	}
+
+	// Without the option only the numeric field is returned
+	query.JSON = []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`)
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if len(resp.Frames) != 1 || len(resp.Frames[0].Fields) != 2 {
+		t.Errorf("Expected a single value field by default")
+	}
+}
+
+// ✅ messageTransitions test: Repeated and changing messages
+func TestMessageTransitions(t *testing.T) {
+	// Newest first, as delivered by PRTG
+	messages := []PrtgMessageListItemStruct{
+		{Datetime: "15.02.2025 12:05:00", MessageRAW: "OK"},
+		{Datetime: "15.02.2025 12:04:00", MessageRAW: "OK"},
+		{Datetime: "15.02.2025 12:03:00", MessageRAW: "Timeout"},
+		{Datetime: "15.02.2025 12:02:00", MessageRAW: "Timeout"},
+		{Datetime: "15.02.2025 12:01:00", MessageRAW: "OK"},
+		{Datetime: "15.02.2025 12:00:00", MessageRAW: "OK"},
+	}
+
+	times, texts := messageTransitions(messages, time.UTC)
+	expected := []string{"OK", "Timeout", "OK"}
+	if len(texts) != len(expected) {
+		t.Fatalf("Expected %d transitions, got %d: %v", len(expected), len(texts), texts)
+	}
+	for i := range expected {
+		if texts[i] != expected[i] {
+			t.Errorf("Expected transition %d to be %q, got %q", i, expected[i], texts[i])
+		}
+	}
+	if times[1].Minute() != 2 || times[2].Minute() != 4 {
+		t.Errorf("Expected transitions at 12:02 and 12:04, got %v and %v", times[1], times[2])
+	}
+}
+
+// ✅ QueryData test: Message changes query
+func TestQueryData_MessageChanges(t *testing.T) {
+	mockResponse := `{"messages": [
+		{"datetime": "15.02.2025 12:02:00", "message_raw": "Down"},
+		{"datetime": "15.02.2025 12:01:00", "message_raw": "OK"},
+		{"datetime": "15.02.2025 12:00:00", "message_raw": "OK"}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"messageChanges","objid":"1234"}`),
+		TimeRange: backend.TimeRange{
+			From: time.Now().Add(-24 * time.Hour),
+			To:   time.Now(),
+		},
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 1 || resp.Frames[0].Rows() != 2 {
+		t.Fatalf("Expected 1 frame with 2 rows")
+	}
+	if resp.Frames[0].Fields[1].At(1).(string) != "Down" {
+		t.Errorf("Expected last transition to be 'Down', got %v", resp.Frames[0].Fields[1].At(1))
+	}
+}
+
+// ✅ Configurable frame field names
+func TestQueryData_FieldNames(t *testing.T) {
+	mockResponse := `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 12.5}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	timeRange := backend.TimeRange{From: time.Now().Add(-24 * time.Hour), To: time.Now()}
+
+	tests := []struct {
+		name          string
+		json          string
+		expectedTime  string
+		expectedValue string
+	}{
+		{"Defaults", `{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`, "Time", "CPU Load"},
+		{"Custom names", `{"queryType":"metrics","objid":"1234","channel":"CPU Load","timeFieldName":"ts","valueFieldName":"cpu"}`, "ts", "cpu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{RefID: "A", JSON: []byte(tt.json), TimeRange: timeRange})
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			fields := resp.Frames[0].Fields
+			if fields[0].Name != tt.expectedTime || fields[1].Name != tt.expectedValue {
+				t.Errorf("Expected field names %q/%q, got %q/%q", tt.expectedTime, tt.expectedValue, fields[0].Name, fields[1].Name)
+			}
+		})
+	}
+}
+
+// ✅ Property queries use the filter property as value field name
+func TestQueryData_PropertyFieldNames(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "CPU Load", "datetime": "2025-02-15T12:00:00Z", "status": "Up"}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"text","property":"sensor","sensor":"CPU Load","filterProperty":"status","timeFieldName":"When"}`),
+	})
+	if len(resp.Frames) != 1 {
+		t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+	}
+	fields := resp.Frames[0].Fields
+	if fields[0].Name != "When" || fields[1].Name != "status" {
+		t.Errorf("Expected field names When/status, got %q/%q", fields[0].Name, fields[1].Name)
+	}
+}
+
+// ✅ Property queries without a match return an empty frame with schema
+func TestQueryData_PropertyNoMatch(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "CPU Load", "datetime": "2025-02-15T12:00:00Z", "status": "Up", "status_raw": 3}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	tests := []struct {
+		filterProperty string
+		expectedType   data.FieldType
+	}{
+		{"status", data.FieldTypeString},
+		{"status_raw", data.FieldTypeFloat64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filterProperty, func(t *testing.T) {
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+				RefID: "A",
+				JSON:  []byte(`{"queryType":"text","property":"sensor","sensor":"Memory","filterProperty":"` + tt.filterProperty + `"}`),
+			})
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			if len(resp.Frames) != 1 {
+				t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+			}
+			fields := resp.Frames[0].Fields
+			if len(fields) != 2 || fields[0].Len() != 0 || fields[1].Len() != 0 {
+				t.Fatalf("Expected two empty fields, got %d", len(fields))
+			}
+			if fields[0].Type() != data.FieldTypeTime || fields[1].Type() != tt.expectedType {
+				t.Errorf("Expected time/%s fields, got %s/%s", tt.expectedType, fields[0].Type(), fields[1].Type())
+			}
+			if len(resp.Frames[0].Meta.Notices) != 1 {
+				t.Errorf("Expected a notice, got %+v", resp.Frames[0].Meta.Notices)
+			}
+		})
+	}
+}
+
+// ✅ Property queries match by objid before the name, a foreign objid falls back to the name
+func TestQueryData_PropertyByObjid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("content") == "sensors":
+			fmt.Fprint(w, `{"sensors": [
+				{"objid": 1001, "sensor": "Ping", "datetime": "2025-02-15T12:00:00Z", "status": "Up"},
+				{"objid": 1002, "sensor": "Ping", "datetime": "2025-02-15T12:00:00Z", "status": "Down"}
+			]}`)
+		case q.Has("filter_objid"):
+			// The objid of a sensor matches no group
+			fmt.Fprint(w, `{"groups": []}`)
+		default:
+			fmt.Fprint(w, `{"groups": [{"objid": 50, "group": "Berlin", "datetime": "2025-02-15T12:00:00Z", "status": "Up"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC}
+	request := func(model string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{RefID: "A", JSON: []byte(model)})
+	}
+
+	resp := request(`{"queryType":"text","property":"sensor","objid":"1002","sensor":"Ping","filterProperty":"status"}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if field := resp.Frames[0].Fields[1]; field.Len() != 1 || field.At(0) != "Down" {
+		t.Errorf("Expected only the status of objid 1002, got %d values", field.Len())
+	}
+
+	// Without objid all sensors with the name match
+	resp = request(`{"queryType":"text","property":"sensor","sensor":"Ping","filterProperty":"status"}`)
+	if resp.Error != nil || resp.Frames[0].Fields[1].Len() != 2 {
+		t.Errorf("Expected two values, got %v", resp.Error)
+	}
+
+	resp = request(`{"queryType":"text","property":"group","objid":"1002","group":"Berlin","filterProperty":"status"}`)
+	if resp.Error != nil || resp.Frames[0].Fields[1].Len() != 1 {
+		t.Errorf("Expected the group to be matched by name, got %v", resp.Error)
+	}
+}
+
+// ✅ Frame metadata reports the averaging interval
+func TestQueryData_AvgIntervalMetadata(t *testing.T) {
+	mockResponse := `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 12.5}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+
+	tests := []struct {
+		name             string
+		json             string
+		duration         time.Duration
+		expectedInterval int64
+		expectedSource   string
+		expectedSnap     interface{}
+	}{
+		{"Auto raw", `{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`, 12 * time.Hour, 0, "auto", nil},
+		{"Auto averaged", `{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`, 7 * 24 * time.Hour, 900, "auto", nil},
+		{"Percentile override", `{"queryType":"metrics","objid":"1234","channel":"CPU Load","aggregation":"p95"}`, 7 * 24 * time.Hour, 0, "override", nil},
+		{"User avg", `{"queryType":"metrics","objid":"1234","channel":"CPU Load","avg":3600}`, 12 * time.Hour, 3600, "override", nil},
+		{"Snapped user avg", `{"queryType":"metrics","objid":"1234","channel":"CPU Load","avg":1000}`, 12 * time.Hour, 900, "override", int64(1000)},
+		{"Exact raw interval", `{"queryType":"metrics","objid":"1234","channel":"CPU Load","avg":0,"avgExact":true}`, 7 * 24 * time.Hour, 0, "override", nil},
+		{"Exact interval unchanged", `{"queryType":"metrics","objid":"1234","channel":"CPU Load","avg":1000,"avgExact":true}`, 12 * time.Hour, 1000, "override", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+				RefID:     "A",
+				JSON:      []byte(tt.json),
+				TimeRange: backend.TimeRange{From: now.Add(-tt.duration), To: now},
+			})
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			meta := resp.Frames[0].Meta
+			if meta == nil {
+				t.Fatalf("Expected frame metadata")
+			}
+			custom := meta.Custom.(map[string]interface{})
+			if custom["avgInterval"] != tt.expectedInterval || custom["avgSource"] != tt.expectedSource {
+				t.Errorf("Expected avgInterval=%d avgSource=%s, got %v", tt.expectedInterval, tt.expectedSource, custom)
+			}
+			if custom["avgRequested"] != tt.expectedSnap {
+				t.Errorf("Expected avgRequested=%v, got %v", tt.expectedSnap, custom["avgRequested"])
+			}
+		})
+	}
+}
+
+// ✅ A negative averaging interval is rejected
+func TestQueryData_NegativeAvg(t *testing.T) {
+	server, api := setupMockAPI(`{"histdata": []}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load","avg":-60,"avgExact":true}`),
+		TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+	})
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "avg must be") {
+		t.Errorf("Expected an avg error, got %v", resp.Error)
+	}
+}
+
+// ✅ Metrics query: A time range without data returns an empty frame with a notice, not an error
+func TestQueryData_NoHistoricData(t *testing.T) {
+	server, api := setupMockAPI(`{"histdata": []}`, http.StatusOK)
+	defer server.Close()
+
+	if _, err := api.GetHistoricalData(context.Background(), "1234", time.Now().Add(-time.Hour).UnixMilli(), time.Now().UnixMilli()); !errors.Is(err, errNoHistoricData) {
+		t.Fatalf("Expected errNoHistoricData, got %v", err)
+	}
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channels":["Traffic In","Traffic Out"]}`),
+		TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 1 {
+		t.Fatalf("Expected one empty wide frame, got %d", len(resp.Frames))
+	}
+	fields := resp.Frames[0].Fields
+	if len(fields) != 3 || fields[0].Len() != 0 || fields[2].Name != "Traffic Out" {
+		t.Errorf("Unexpected empty frame %+v", resp.Frames[0])
+	}
+	notices := resp.Frames[0].Meta.Notices
+	if len(notices) != 1 || notices[0].Severity != data.NoticeSeverityInfo {
+		t.Errorf("Expected an info notice, got %+v", notices)
+	}
+}
+
+// ✅ Multi-channel query: Missing channels with notice and fail policy
+func TestQueryData_MultiChannelMissing(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "Traffic In": 10, "Traffic Out": 20},
+		{"datetime": "2025-02-15T12:01:00Z", "Traffic In": 11, "Traffic Out": 21}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	timeRange := backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()}
+
+	// Default: found channels are returned, the missing one is listed in a notice
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channels":["Traffic In","Renamed","Traffic Out"],"splitFrames":true}`),
+		TimeRange: timeRange,
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(resp.Frames))
+	}
+	if resp.Frames[0].Fields[1].Name != "Traffic In" || resp.Frames[1].Fields[1].Name != "Traffic Out" {
+		t.Errorf("Unexpected channels: %s, %s", resp.Frames[0].Fields[1].Name, resp.Frames[1].Fields[1].Name)
+	}
+	notices := resp.Frames[0].Meta.Notices
+	if len(notices) != 1 || !strings.Contains(notices[0].Text, "Renamed") {
+		t.Errorf("Expected a notice listing the missing channel, got %+v", notices)
+	}
+
+	// Fail policy
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channels":["Traffic In","Renamed"],"missingChannels":"fail"}`),
+		TimeRange: timeRange,
+	})
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "Renamed") {
+		t.Errorf("Expected an error listing the missing channel, got %v", resp.Error)
+	}
+
+	// Unknown policies are rejected
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channels":["Traffic In"],"missingChannels":"ignore"}`),
+		TimeRange: timeRange,
+	})
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "missingChannels") {
+		t.Errorf("Expected an invalid policy error, got %v", resp.Error)
+	}
+}
+
+// ✅ Metrics query: Items without the channel are skipped with a notice instead of zeros
+func TestQueryData_ChannelMissingInItems(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "Traffic In": 10},
+		{"datetime": "2025-02-15T12:01:00Z", "Traffic Out": 21},
+		{"datetime": "2025-02-15T12:02:00Z", "Traffic In": 12}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	request := func(channel string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"` + channel + `"}`),
+			TimeRange: histdataTimeRange,
+		})
+	}
+
+	resp := request("Traffic In")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 2 {
+		t.Errorf("Expected 2 points, got %d", rows)
+	}
+	for i := 0; i < frame.Fields[1].Len(); i++ {
+		if frame.Fields[1].At(i).(float64) == 0 {
+			t.Errorf("Unexpected zero at row %d", i)
+		}
+	}
+	if dropped := frame.Meta.Custom.(map[string]interface{})["pointsDropped"].(map[string]int); dropped["missingChannel"] != 1 {
+		t.Errorf("Expected 1 point without channel, got %v", dropped)
+	}
+	if len(frame.Meta.Notices) != 1 || !strings.Contains(frame.Meta.Notices[0].Text, "1 points") {
+		t.Errorf("Expected a notice about the skipped point, got %+v", frame.Meta.Notices)
+	}
+
+	// A channel missing in all items is most likely misspelled
+	resp = request("Trafic In")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame = resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 0 {
+		t.Errorf("Expected no points, got %d", rows)
+	}
+	if len(frame.Meta.Notices) != 1 || !strings.Contains(frame.Meta.Notices[0].Text, "check the channel name") {
+		t.Errorf("Expected a notice about the unknown channel, got %+v", frame.Meta.Notices)
+	}
+}
+
+// ✅ propertyValueField test: Field type is decided from all values
+func TestPropertyValueField(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []interface{}
+		expected data.FieldType
+		last     interface{}
+	}{
+		{"Numeric", []interface{}{1.5, 2}, data.FieldTypeFloat64, float64(2)},
+		{"Strings", []interface{}{"Up", "Down"}, data.FieldTypeString, "Down"},
+		{"Mixed", []interface{}{1.5, "Down"}, data.FieldTypeString, "Down"},
+		{"Mixed keeps numbers", []interface{}{"Up", 3}, data.FieldTypeString, "3"},
+		{"Other types", []interface{}{true, false}, data.FieldTypeString, "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := propertyValueField("value", tt.values)
+			if field.Type() != tt.expected {
+				t.Errorf("Expected type %v, got %v", tt.expected, field.Type())
+			}
+			if field.Len() != len(tt.values) {
+				t.Errorf("Expected %d values, got %d", len(tt.values), field.Len())
+			}
+			if last := field.At(field.Len() - 1); last != tt.last {
+				t.Errorf("Expected last value %v, got %v", tt.last, last)
+			}
+		})
+	}
+}
+
+// ✅ Frame metadata reports returned, parsed and dropped points
+func TestQueryData_PointCounts(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 10},
+		{"datetime": "not a date", "CPU Load": 11},
+		{"datetime": "2025-02-15T12:02:00Z", "CPU Load": "n/a"},
+		{"datetime": "2025-02-15T12:03:00Z", "CPU Load": 13},
+		{"datetime": "2025-02-16T12:00:00Z", "CPU Load": 14}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`),
+		TimeRange: histdataTimeRange,
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	custom := resp.Frames[0].Meta.Custom.(map[string]interface{})
+	if custom["pointsReturned"] != 5 || custom["pointsParsed"] != 2 {
+		t.Errorf("Expected 5 returned and 2 parsed points, got %v/%v", custom["pointsReturned"], custom["pointsParsed"])
+	}
+	dropped := custom["pointsDropped"].(map[string]int)
+	if dropped["parseFailure"] != 1 || dropped["invalidValue"] != 1 || dropped["duplicate"] != 0 || dropped["outOfRange"] != 1 {
+		t.Errorf("Unexpected drop reasons: %v", dropped)
+	}
+}
+
+// ✅ mergeHistoricalChunks counts duplicates of overlapping chunks
+func TestMergeHistoricalChunks_Counts(t *testing.T) {
+	first := &PrtgHistoricalDataResponse{HistData: []PrtgValues{{Datetime: "a"}, {Datetime: "b"}}}
+	second := &PrtgHistoricalDataResponse{HistData: []PrtgValues{{Datetime: "b"}, {Datetime: "c"}}}
+
+	merged := mergeHistoricalChunks([]*PrtgHistoricalDataResponse{first, second})
+	if len(merged.HistData) != 3 || merged.ReturnedCount != 4 || merged.DuplicateCount != 1 {
+		t.Errorf("Expected 3 points, 4 returned and 1 duplicate, got %d/%d/%d",
+			len(merged.HistData), merged.ReturnedCount, merged.DuplicateCount)
+	}
+}
+
+// ✅ Metrics query: Notice when the averaging interval is floored
+func TestQueryData_MinAvgIntervalNotice(t *testing.T) {
+	mockResponse := `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 12.5}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+	api.SetMinAvgInterval(3600)
+
+	ds := &Datasource{api: api}
+	now := time.Now()
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load","aggregation":"p95"}`),
+		TimeRange: backend.TimeRange{From: now.Add(-365 * 24 * time.Hour), To: now},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	meta := resp.Frames[0].Meta
+	custom := meta.Custom.(map[string]interface{})
+	if custom["avgInterval"] != int64(3600) || custom["avgSource"] != avgSourceFallback {
+		t.Errorf("Expected the floored interval, got %v", custom)
+	}
+	if len(meta.Notices) != 1 {
+		t.Errorf("Expected a notice for the floored interval, got %v", meta.Notices)
+	}
+}
+
+// ✅ Background tasks query
+func TestQueryData_BackgroundTasks(t *testing.T) {
+	mockResponse := `{"backgroundtasks": "3", "correlationtasks": "0", "autodiscotasks": "1", "reporttasks": ""}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"backgroundTasks"}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	fields := resp.Frames[0].Fields
+	if fields[0].At(0) != int64(3) || fields[2].At(0) != int64(1) || fields[3].At(0) != int64(0) {
+		t.Errorf("Unexpected task counts: %v %v %v %v", fields[0].At(0), fields[1].At(0), fields[2].At(0), fields[3].At(0))
+	}
+}
+
+// ✅ Metrics query: Same series key across runs, distinct keys per channel
+func TestQueryData_SeriesKey(t *testing.T) {
+	mockResponse := `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Traffic In": 10, "Traffic Out": 20}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	runQuery := func() []string {
+		now := time.Now()
+		resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(`{"queryType":"metrics","objid":"1234","channels":["Traffic In","Traffic Out"],"splitFrames":true}`),
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+		})
+		if resp.Error != nil {
+			t.Fatalf("Unexpected error: %v", resp.Error)
+		}
+		keys := make([]string, 0, len(resp.Frames))
+		for _, frame := range resp.Frames {
+			keys = append(keys, frame.Fields[1].Labels[seriesKeyLabel])
+		}
+		return keys
+	}
+
+	first, second := runQuery(), runQuery()
+	if len(first) != 2 || first[0] == "" || first[0] == first[1] {
+		t.Fatalf("Expected two distinct series keys, got %v", first)
+	}
+	if first[0] != second[0] || first[1] != second[1] {
+		t.Errorf("Expected the same series keys across runs, got %v and %v", first, second)
+	}
+	if first[0] != seriesKey("1234", "Traffic In", "") {
+		t.Errorf("Unexpected key composition: %s", first[0])
+	}
+}
+
+// ✅ Query execution time is reported in the frame metadata
+func TestQueryData_ExecutionTime(t *testing.T) {
+	mockResponse := `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 12.5}]}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(mockResponse))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	now := time.Now()
+	start := time.Now()
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`),
+		TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+	})
+	total := float64(time.Since(start).Microseconds()) / 1000
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	custom := resp.Frames[0].Meta.Custom.(map[string]interface{})
+	elapsed, ok := custom["executionTimeMs"].(float64)
+	if !ok {
+		t.Fatalf("Expected executionTimeMs in the metadata, got %v", custom)
+	}
+	if elapsed < 20 || elapsed > total {
+		t.Errorf("Implausible execution time %vms, the query took %vms", elapsed, total)
+	}
+}
@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"strings"
+)
+
+// Device reachability
+//
+// Whether a device is reachable is answered by its ping sensor, not by the aggregated
+// status of all its sensors. The ping sensor is identified by its sensor type
+// (type_raw, "ping" by default) and, if no sensor has that type, by its name ("Ping" by
+// default, the name of the sensor PRTG creates automatically). Both can be overridden per
+// query for installations using other checks.
+const (
+	defaultPingSensorType = "ping"
+	defaultPingSensorName = "Ping"
+)
+
+// findPingSensor returns the ping sensor among the sensors of a device.
+func findPingSensor(sensors []PrtgSensorListItemStruct, sensorType, sensorName string) (PrtgSensorListItemStruct, bool) {
+	if sensorType == "" {
+		sensorType = defaultPingSensorType
+	}
+	if sensorName == "" {
+		sensorName = defaultPingSensorName
+	}
+
+	for _, s := range sensors {
+		if strings.EqualFold(s.TypeRAW, sensorType) {
+			return s, true
+		}
+	}
+	for _, s := range sensors {
+		if strings.EqualFold(strings.TrimSpace(s.Sensor), sensorName) {
+			return s, true
+		}
+	}
+	return PrtgSensorListItemStruct{}, false
+}
+
+// reachability maps the status of a ping sensor to reachable (true), unreachable (false)
+// or unknown (nil) for paused and unknown states.
+func reachability(statusRaw int) *bool {
+	reachable := true
+	switch statusRaw {
+	case prtgStatusUp, prtgStatusWarning, prtgStatusUnusual:
+		return &reachable
+	case prtgStatusDown, prtgStatusDownAcknowledged, prtgStatusDownPartial:
+		reachable = false
+		return &reachable
+	default:
+		return nil
+	}
+}
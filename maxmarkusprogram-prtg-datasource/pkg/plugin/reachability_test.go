@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ findPingSensor test: By type, by name and with custom heuristics
+func TestFindPingSensor(t *testing.T) {
+	sensors := []PrtgSensorListItemStruct{
+		{Sensor: "CPU Load", TypeRAW: "snmpcpu"},
+		{Sensor: "Ping", TypeRAW: "ping"},
+		{Sensor: "HTTP", TypeRAW: "http"},
+	}
+
+	if s, ok := findPingSensor(sensors, "", ""); !ok || s.Sensor != "Ping" {
+		t.Errorf("Expected the ping sensor by type, got %+v", s)
+	}
+	if s, ok := findPingSensor(sensors, "http", ""); !ok || s.Sensor != "HTTP" {
+		t.Errorf("Expected the configured type, got %+v", s)
+	}
+
+	// Name fallback when no sensor has the type
+	byName := []PrtgSensorListItemStruct{{Sensor: "ping", TypeRAW: "pingv2"}}
+	if _, ok := findPingSensor(byName, "", ""); !ok {
+		t.Errorf("Expected the ping sensor by name")
+	}
+
+	if _, ok := findPingSensor([]PrtgSensorListItemStruct{{Sensor: "CPU Load"}}, "", ""); ok {
+		t.Errorf("Expected no ping sensor")
+	}
+}
+
+// ✅ reachability test: Status mapping
+func TestReachability(t *testing.T) {
+	if r := reachability(3); r == nil || !*r {
+		t.Errorf("Expected Up to be reachable")
+	}
+	if r := reachability(13); r == nil || *r {
+		t.Errorf("Expected Down acknowledged to be unreachable")
+	}
+	if r := reachability(7); r != nil {
+		t.Errorf("Expected paused to be unknown")
+	}
+}
+
+// ✅ Device reachability query: With and without ping sensor
+func TestQueryData_DeviceReachability(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		reachable *bool
+		notices   int
+	}{
+		{"Down", `{"sensors": [{"sensor": "Ping", "type_raw": "ping", "status": "Down", "status_raw": 5}]}`, new(bool), 0},
+		{"No ping sensor", `{"sensors": [{"sensor": "CPU Load", "type_raw": "snmpcpu", "status_raw": 3}]}`, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, api := setupMockAPI(tt.response, http.StatusOK)
+			defer server.Close()
+
+			ds := &Datasource{api: api}
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+				RefID: "A",
+				JSON:  []byte(`{"queryType":"deviceReachability","objid":"2001"}`),
+			})
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			frame := resp.Frames[0]
+			reachable := frame.Fields[2].At(0).(*bool)
+			if (reachable == nil) != (tt.reachable == nil) || (reachable != nil && *reachable != *tt.reachable) {
+				t.Errorf("Expected reachable %v, got %v", tt.reachable, reachable)
+			}
+			notices := 0
+			if frame.Meta != nil {
+				notices = len(frame.Meta.Notices)
+			}
+			if notices != tt.notices {
+				t.Errorf("Expected %d notices, got %d", tt.notices, notices)
+			}
+		})
+	}
+}
+
+// ✅ reachability route: Configured heuristic, missing ping sensor and invalid objid
+func TestCallResourceReachability(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "Ping", "type_raw": "ping", "status": "Down", "status_raw": 5}, {"sensor": "HTTP", "type_raw": "http", "status": "Up", "status_raw": 3}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	tests := []struct {
+		name     string
+		path     string
+		url      string
+		status   int
+		contains string
+	}{
+		{"Ping sensor", "reachability/2001", "reachability/2001", http.StatusOK, `"reachable":false`},
+		{"Configured type", "reachability/2001", "reachability/2001?type=http", http.StatusOK, `"reachable":true`},
+		{"No ping sensor", "reachability/2001", "reachability/2001?type=snmp&name=Uptime", http.StatusOK, `"found":false`},
+		{"Invalid objid", "reachability/abc", "reachability/abc", http.StatusBadRequest, `invalid objid`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			respSender := &mockResourceResponseSender{}
+			if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: tt.path, URL: tt.url}, respSender); err != nil {
+				t.Fatalf("CallResource failed: %v", err)
+			}
+			if respSender.status != tt.status || !strings.Contains(string(respSender.body), tt.contains) {
+				t.Errorf("Expected %d with %s, got %d %s", tt.status, tt.contains, respSender.status, respSender.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Status rollup
+//
+// The rollup of a set of objects is the worst status among them, ordered like PRTG rolls
+// up the status of a device or group from its sensors:
+//
+//	Down > Down (Partial) > Down (Acknowledged) > Warning > Unusual > Up > Paused > Unknown
+//
+// Unknown states are mapped by the unknown status policy first.
+var statusSeverityOrder = []int{
+	prtgStatusDown,
+	prtgStatusDownPartial,
+	prtgStatusDownAcknowledged,
+	prtgStatusWarning,
+	prtgStatusUnusual,
+	prtgStatusUp,
+	prtgStatusPausedByUser,
+	prtgStatusPausedByDependency,
+	prtgStatusPausedBySchedule,
+	prtgStatusPausedUntil,
+	prtgStatusUnknown,
+	prtgStatusNone,
+}
+
+// statusColors are the colors of the rollup value per status, following the PRTG web interface.
+var statusColors = map[int]string{
+	prtgStatusDown:             "red",
+	prtgStatusDownPartial:      "red",
+	prtgStatusDownAcknowledged: "orange",
+	prtgStatusWarning:          "yellow",
+	prtgStatusUnusual:          "orange",
+	prtgStatusUp:               "green",
+}
+
+// statusSeverity returns the severity of a status code, higher is worse.
+// Codes missing from statusSeverityOrder rank below Unknown.
+func statusSeverity(statusRaw int) int {
+	for i, code := range statusSeverityOrder {
+		if code == statusRaw {
+			return len(statusSeverityOrder) - i
+		}
+	}
+	return 0
+}
+
+// statusColor returns the color of a status code, paused and unknown states are grey.
+func statusColor(statusRaw int) string {
+	if color, ok := statusColors[statusRaw]; ok {
+		return color
+	}
+	if isPausedStatus(statusRaw) {
+		return "blue"
+	}
+	return "grey"
+}
+
+// objectStatus is the status of a single object of a rollup.
+type objectStatus struct {
+	ObjectId  int64
+	Name      string
+	Status    string
+	StatusRAW int
+}
+
+// worstStatus returns the object with the worst status. Of equally severe objects the
+// first one wins.
+func worstStatus(statuses []objectStatus, policy string) (objectStatus, bool) {
+	var worst objectStatus
+	found := false
+	for _, s := range statuses {
+		s.StatusRAW, s.Status = mapStatus(s.StatusRAW, s.Status, policy)
+		if !found || statusSeverity(s.StatusRAW) > statusSeverity(worst.StatusRAW) {
+			worst = s
+			found = true
+		}
+	}
+	return worst, found
+}
+
+// selectedStatuses returns the statuses of the sensors and devices with the given objids.
+// The second return value lists the objids that were not found.
+func (d *Datasource) selectedStatuses(ctx context.Context, objids []string) ([]objectStatus, []string, error) {
+	wanted := make(map[int64]string, len(objids))
+	for _, raw := range objids {
+		id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid object ID %q", raw)
+		}
+		wanted[id] = raw
+	}
+
+	var statuses []objectStatus
+	sensors, err := d.api.GetSensors(ctx, listFilter{ObjectId: joinObjectIds(wanted)})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, s := range sensors.Sensors {
+		if _, ok := wanted[s.ObjectId]; ok {
+			statuses = append(statuses, objectStatus{s.ObjectId, s.Sensor, s.Status, s.StatusRAW})
+			delete(wanted, s.ObjectId)
+		}
+	}
+
+	if len(wanted) > 0 {
+		devices, err := d.api.GetDevices(ctx, listFilter{ObjectId: joinObjectIds(wanted)})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, dev := range devices.Devices {
+			if _, ok := wanted[dev.ObjectId]; ok {
+				statuses = append(statuses, objectStatus{dev.ObjectId, dev.Device, dev.Status, dev.StatusRAW})
+				delete(wanted, dev.ObjectId)
+			}
+		}
+	}
+
+	var missing []string
+	for _, raw := range objids {
+		id, _ := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if _, ok := wanted[id]; ok {
+			missing = append(missing, raw)
+			delete(wanted, id)
+		}
+	}
+	return statuses, missing, nil
+}
+
+// joinObjectIds returns the ids as comma separated objid filter, sorted for stable cache keys.
+func joinObjectIds(ids map[int64]string) string {
+	sorted := make([]int64, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// handleStatusRollupQuery returns the worst status of the selected sensors and devices
+// as a single value.
+func (d *Datasource) handleStatusRollupQuery(ctx context.Context, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	if len(qm.ObjectIds) == 0 {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "invalid query: missing object IDs")
+	}
+
+	statuses, missing, err := d.selectedStatuses(ctx, qm.ObjectIds)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	worst, found := worstStatus(statuses, d.unknownStatusPolicy)
+	if !found {
+		worst.StatusRAW, worst.Status = mapStatus(prtgStatusUnknown, "Unknown", d.unknownStatusPolicy)
+	}
+
+	statusField := data.NewField("Status", nil, []string{worst.Status})
+	codeField := data.NewField("Status Code", nil, []int64{int64(worst.StatusRAW)})
+	for _, field := range []*data.Field{statusField, codeField} {
+		field.Config = &data.FieldConfig{
+			Color: map[string]interface{}{
+				"mode":       "fixed",
+				"fixedColor": statusColor(worst.StatusRAW),
+			},
+		}
+	}
+
+	frame := data.NewFrame("response",
+		statusField,
+		codeField,
+		data.NewField("Object", nil, []string{worst.Name}),
+		data.NewField("Objects", nil, []int64{int64(len(statuses))}),
+	)
+	if len(missing) > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Objects not found: %s", strings.Join(missing, ", ")),
+		})
+	}
+
+	response.Frames = append(response.Frames, frame)
+	return response
+}
@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestWorstStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		policy   string
+		expected int
+	}{
+		{"Down wins", []int{prtgStatusUp, prtgStatusWarning, prtgStatusDown, prtgStatusPausedByUser}, "", prtgStatusDown},
+		{"Warning over unusual", []int{prtgStatusUnusual, prtgStatusUp, prtgStatusWarning}, "", prtgStatusWarning},
+		{"Partial over acknowledged", []int{prtgStatusDownAcknowledged, prtgStatusDownPartial}, "", prtgStatusDownPartial},
+		{"Up over paused and unknown", []int{prtgStatusPausedBySchedule, prtgStatusUnknown, prtgStatusUp}, "", prtgStatusUp},
+		{"Unknown mapped to down", []int{prtgStatusWarning, prtgStatusUnknown}, unknownStatusDown, prtgStatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statuses []objectStatus
+			for i, code := range tt.statuses {
+				statuses = append(statuses, objectStatus{ObjectId: int64(i), StatusRAW: code})
+			}
+			worst, ok := worstStatus(statuses, tt.policy)
+			if !ok || worst.StatusRAW != tt.expected {
+				t.Errorf("Expected status %d, got %d (ok=%v)", tt.expected, worst.StatusRAW, ok)
+			}
+		})
+	}
+
+	if _, ok := worstStatus(nil, ""); ok {
+		t.Errorf("Expected no status for an empty set")
+	}
+}
+
+func TestQueryData_StatusRollup(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("content") {
+		case "sensors":
+			w.Write([]byte(`{"sensors": [
+				{"objid": 1, "sensor": "Ping", "status": "Up", "status_raw": 3},
+				{"objid": 2, "sensor": "CPU Load", "status": "Warning", "status_raw": 4},
+				{"objid": 3, "sensor": "Disk", "status": "Down", "status_raw": 5}
+			]}`))
+		case "devices":
+			w.Write([]byte(`{"devices": [{"objid": 40, "device": "Router", "status": "Unusual", "status_raw": 10}]}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	tests := []struct {
+		name           string
+		json           string
+		expectedStatus string
+		expectedObject string
+		expectedColor  string
+	}{
+		{"Mixed set", `{"queryType":"statusRollup","objids":["1","2","40"]}`, "Warning", "CPU Load", "yellow"},
+		{"Down wins", `{"queryType":"statusRollup","objids":["1","3","40"]}`, "Down", "Disk", "red"},
+		{"Device only", `{"queryType":"statusRollup","objids":["1","40"]}`, "Unusual", "Router", "orange"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{RefID: "A", JSON: []byte(tt.json)})
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			frame := resp.Frames[0]
+			statusField, _ := frame.FieldByName("Status")
+			objectField, _ := frame.FieldByName("Object")
+			if got := statusField.At(0).(string); got != tt.expectedStatus {
+				t.Errorf("Expected status %q, got %q", tt.expectedStatus, got)
+			}
+			if got := objectField.At(0).(string); got != tt.expectedObject {
+				t.Errorf("Expected object %q, got %q", tt.expectedObject, got)
+			}
+			if got := statusField.Config.Color["fixedColor"]; got != tt.expectedColor {
+				t.Errorf("Expected color %q, got %v", tt.expectedColor, got)
+			}
+		})
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"statusRollup","objids":["1","99"]}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if meta := resp.Frames[0].Meta; meta == nil || len(meta.Notices) != 1 {
+		t.Errorf("Expected a notice for the missing object, got %+v", meta)
+	}
+
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"statusRollup"}`),
+	})
+	if resp.Error == nil {
+		t.Errorf("Expected an error without object IDs")
+	}
+}
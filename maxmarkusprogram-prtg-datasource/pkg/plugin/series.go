@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// seriesKeyLabel is the label carrying the stable series key, see seriesKey.
+const seriesKeyLabel = "seriesKey"
+
+// seriesKey returns a stable identifier of a series for alerting and deduplication. It is
+// the first 16 hex characters of the SHA-256 of "<objid>|<channel>|<property>", where property
+// distinguishes variants of the same channel (e.g. the aggregation). Display names are not
+// part of the key, so renaming a sensor in PRTG keeps the key.
+func seriesKey(objid, channel, property string) string {
+	parts := []string{
+		strings.TrimSpace(objid),
+		strings.TrimSpace(channel),
+		strings.ToLower(strings.TrimSpace(property)),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// seriesLabels returns the labels of a metrics series: the series key plus the objid, group,
+// device, sensor and channel of the query, so transformations and templating can key off the
+// object identity. Empty values are left out.
+func (qm queryModel) seriesLabels(channel, property string) data.Labels {
+	labels := data.Labels{seriesKeyLabel: seriesKey(qm.ObjectId, channel, property)}
+	for name, value := range map[string]string{
+		"objid":   qm.ObjectId,
+		"group":   qm.Group,
+		"device":  qm.Device,
+		"sensor":  qm.Sensor,
+		"channel": channel,
+	} {
+		if value = strings.TrimSpace(value); value != "" {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// minAlignTolerance is the smallest default tolerance used to pair points of two series.
+const minAlignTolerance = 30 * time.Second
+
+// defaultAlignTolerance returns half the averaging interval, but at least minAlignTolerance.
+func defaultAlignTolerance(avgInterval int64) time.Duration {
+	tolerance := time.Duration(avgInterval) * time.Second / 2
+	if tolerance < minAlignTolerance {
+		tolerance = minAlignTolerance
+	}
+	return tolerance
+}
+
+// alignedDifference computes a - b. Every point of a is paired with the nearest point of b;
+// points without a partner within the tolerance are dropped. Both series must be sorted by time.
+func alignedDifference(aTimes []time.Time, aValues []float64, bTimes []time.Time, bValues []float64, tolerance time.Duration) ([]time.Time, []float64) {
+	times := make([]time.Time, 0, len(aTimes))
+	values := make([]float64, 0, len(aTimes))
+
+	j := 0
+	for i, t := range aTimes {
+		// Advance to the last point of b that is not after t
+		for j+1 < len(bTimes) && !bTimes[j+1].After(t) {
+			j++
+		}
+
+		nearest := -1
+		var nearestDist time.Duration
+		for _, k := range []int{j, j + 1} {
+			if k < 0 || k >= len(bTimes) {
+				continue
+			}
+			dist := absDuration(bTimes[k].Sub(t))
+			if nearest == -1 || dist < nearestDist {
+				nearest, nearestDist = k, dist
+			}
+		}
+
+		if nearest == -1 || nearestDist > tolerance {
+			continue
+		}
+		times = append(times, t)
+		values = append(values, aValues[i]-bValues[nearest])
+	}
+	return times, values
+}
+
+// absDuration returns the absolute value of d.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// changedPointIndices returns the indices of the points that start a new value, collapsing
+// runs of equal values into their first point. The last point is always kept so that the
+// series still extends to the end of the range.
+func changedPointIndices(values []float64) []int {
+	indices := make([]int, 0, len(values))
+	for i, v := range values {
+		if i == 0 || v != values[i-1] || i == len(values)-1 {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// selectIndices returns the elements of s at the given indices.
+func selectIndices[T any](s []T, indices []int) []T {
+	out := make([]T, 0, len(indices))
+	for _, i := range indices {
+		out = append(out, s[i])
+	}
+	return out
+}
+
+// alignToInterval floors every timestamp to a multiple of the interval, so that averaged
+// series fetched at different times share the same clock boundaries (e.g. :00, :05).
+// An interval of zero (raw data) leaves the timestamps unchanged.
+func alignToInterval(times []time.Time, interval time.Duration) []time.Time {
+	if interval <= 0 {
+		return times
+	}
+	aligned := make([]time.Time, len(times))
+	for i, t := range times {
+		aligned[i] = t.Truncate(interval)
+	}
+	return aligned
+}
@@ -0,0 +1,256 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ alignedDifference test: Aligned inputs
+func TestAlignedDifference_Aligned(t *testing.T) {
+	start := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	times := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}
+
+	outTimes, outValues := alignedDifference(times, []float64{100, 200, 300}, times, []float64{40, 50, 60}, 30*time.Second)
+	expected := []float64{60, 150, 240}
+	if len(outValues) != len(expected) {
+		t.Fatalf("Expected %d points, got %d", len(expected), len(outValues))
+	}
+	for i := range expected {
+		if outValues[i] != expected[i] || !outTimes[i].Equal(times[i]) {
+			t.Errorf("Point %d: expected %v at %v, got %v at %v", i, expected[i], times[i], outValues[i], outTimes[i])
+		}
+	}
+}
+
+// ✅ alignedDifference test: Slightly misaligned inputs and mismatched lengths
+func TestAlignedDifference_Misaligned(t *testing.T) {
+	start := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	aTimes := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(3 * time.Minute)}
+	aValues := []float64{100, 200, 300, 400}
+	// b is shifted by a few seconds and misses the point at 12:02
+	bTimes := []time.Time{start.Add(5 * time.Second), start.Add(time.Minute - 3*time.Second), start.Add(3*time.Minute + 10*time.Second)}
+	bValues := []float64{10, 20, 40}
+
+	outTimes, outValues := alignedDifference(aTimes, aValues, bTimes, bValues, 15*time.Second)
+	expected := []float64{90, 180, 360}
+	if len(outValues) != len(expected) {
+		t.Fatalf("Expected %d points, got %d: %v", len(expected), len(outValues), outValues)
+	}
+	for i := range expected {
+		if outValues[i] != expected[i] {
+			t.Errorf("Point %d: expected %v, got %v", i, expected[i], outValues[i])
+		}
+	}
+	if !outTimes[2].Equal(aTimes[3]) {
+		t.Errorf("Expected the unmatched point at 12:02 to be dropped, got %v", outTimes)
+	}
+}
+
+// ✅ QueryData test: Difference of two sensors
+func TestQueryData_Difference(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		value := 100
+		if r.URL.Query().Get("id") == "5678" {
+			value = 30
+		}
+		fmt.Fprintf(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Total": %d}]}`, value)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Total","subtractObjid":"5678"}`),
+		TimeRange: histdataTimeRange,
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	valueField := resp.Frames[0].Fields[1]
+	if valueField.Len() != 1 || valueField.At(0).(float64) != 70 {
+		t.Errorf("Expected a single difference value of 70, got %v", valueField.At(0))
+	}
+}
+
+// ✅ changedPointIndices test: Flat-then-changing data
+func TestChangedPointIndices(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		expected []int
+	}{
+		{"Empty", nil, []int{}},
+		{"Single point", []float64{1}, []int{0}},
+		{"Flat keeps first and last", []float64{5, 5, 5, 5}, []int{0, 3}},
+		{"Flat then changing", []float64{1, 1, 1, 2, 2, 3, 3, 3}, []int{0, 3, 5, 7}},
+		{"Changing at the end", []float64{1, 1, 2}, []int{0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := changedPointIndices(tt.values)
+			if fmt.Sprint(result) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+// ✅ QueryData test: Change-only option collapses equal values
+func TestQueryData_ChangesOnly(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "Total": 1},
+		{"datetime": "2025-02-15T12:01:00Z", "Total": 1},
+		{"datetime": "2025-02-15T12:02:00Z", "Total": 1},
+		{"datetime": "2025-02-15T12:03:00Z", "Total": 4},
+		{"datetime": "2025-02-15T12:04:00Z", "Total": 4}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Total","changesOnly":true}`),
+		TimeRange: histdataTimeRange,
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	timeField, valueField := resp.Frames[0].Fields[0], resp.Frames[0].Fields[1]
+	if valueField.Len() != 3 {
+		t.Fatalf("Expected 3 points, got %d", valueField.Len())
+	}
+	expectedMinutes := []int{0, 3, 4}
+	for i, minute := range expectedMinutes {
+		if timeField.At(i).(time.Time).Minute() != minute {
+			t.Errorf("Point %d: expected minute %d, got %v", i, minute, timeField.At(i))
+		}
+	}
+
+	// Without the option every point is returned
+	query.JSON = []byte(`{"queryType":"metrics","objid":"1234","channel":"Total"}`)
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Frames[0].Fields[1].Len() != 5 {
+		t.Errorf("Expected all 5 points by default, got %d", resp.Frames[0].Fields[1].Len())
+	}
+}
+
+// ✅ alignToInterval test: Timestamps are floored to clock boundaries
+func TestAlignToInterval(t *testing.T) {
+	base := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	times := []time.Time{base.Add(17 * time.Second), base.Add(5*time.Minute + 17*time.Second), base.Add(9*time.Minute + 59*time.Second)}
+
+	aligned := alignToInterval(times, 5*time.Minute)
+	expected := []time.Time{base, base.Add(5 * time.Minute), base.Add(5 * time.Minute)}
+	for i := range expected {
+		if !aligned[i].Equal(expected[i]) {
+			t.Errorf("Point %d: expected %v, got %v", i, expected[i], aligned[i])
+		}
+	}
+
+	// Raw data keeps its timestamps
+	if raw := alignToInterval(times, 0); !raw[0].Equal(times[0]) {
+		t.Errorf("Expected unchanged timestamps for raw data, got %v", raw[0])
+	}
+}
+
+// ✅ QueryData test: Averaged timestamps are aligned on request
+func TestQueryData_AlignToClock(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:17Z", "Total": 1},
+		{"datetime": "2025-02-15T12:15:17Z", "Total": 2}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	now := time.Date(2025, 2, 16, 0, 0, 0, 0, time.UTC)
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Total","alignToClock":true}`),
+		// A week uses PRTG's 15 minute averages
+		TimeRange: backend.TimeRange{From: now.Add(-7 * 24 * time.Hour), To: now},
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	timeField := resp.Frames[0].Fields[0]
+	if ts := timeField.At(1).(time.Time); !ts.Equal(time.Date(2025, 2, 15, 12, 15, 0, 0, time.UTC)) {
+		t.Errorf("Expected 12:15:00, got %v", ts)
+	}
+
+	// Default keeps PRTG's native boundaries
+	query.JSON = []byte(`{"queryType":"metrics","objid":"1234","channel":"Total"}`)
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if ts := resp.Frames[0].Fields[0].At(0).(time.Time); ts.Second() != 17 {
+		t.Errorf("Expected native timestamp 12:00:17, got %v", ts)
+	}
+}
+
+// ✅ seriesKey test: Stable and distinct keys
+func TestSeriesKey(t *testing.T) {
+	key := seriesKey("1234", "Traffic In", "")
+	if len(key) != 16 {
+		t.Fatalf("Expected a 16 character key, got %q", key)
+	}
+	if seriesKey(" 1234 ", "Traffic In", "") != key {
+		t.Errorf("Expected surrounding whitespace to be ignored")
+	}
+	if seriesKey("1234", "Traffic In", "P95") != seriesKey("1234", "Traffic In", "p95") {
+		t.Errorf("Expected the property to be case insensitive")
+	}
+	if seriesKey("1234", "Traffic Out", "") == key || seriesKey("1235", "Traffic In", "") == key ||
+		seriesKey("1234", "Traffic In", "p95") == key {
+		t.Errorf("Expected different series to have different keys")
+	}
+}
+
+// ✅ Metrics query: The value field is labeled with the object identity
+func TestQueryData_SeriesLabels(t *testing.T) {
+	mockResponse := `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Traffic In": 10}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, timezone: time.UTC}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","group":"Berlin","device":"Router","channel":"Traffic In"}`),
+		TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	labels := resp.Frames[0].Fields[1].Labels
+	expected := map[string]string{
+		"objid":        "1234",
+		"group":        "Berlin",
+		"device":       "Router",
+		"channel":      "Traffic In",
+		seriesKeyLabel: seriesKey("1234", "Traffic In", ""),
+	}
+	for name, value := range expected {
+		if labels[name] != value {
+			t.Errorf("Expected label %s=%q, got %q", name, value, labels[name])
+		}
+	}
+	// The sensor name is not part of the query, so there is no label for it
+	if _, ok := labels["sensor"]; ok || len(labels) != len(expected) {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+}
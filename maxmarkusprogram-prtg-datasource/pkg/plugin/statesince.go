@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Formats of the state since duration: seconds for numeric panels, text like "2h15m" for
+// text panels.
+const (
+	stateSinceSeconds = "seconds"
+	stateSinceText    = "text"
+)
+
+// Sources of the state since time.
+const (
+	stateSinceSourceHistory    = "history"
+	stateSinceSourceLastUpDown = "lastUpDown"
+)
+
+// parseLastUpDown parses the lastup or lastdown value of the sensor details. PRTG appends the
+// relative time in brackets, e.g. "15.02.2025 12:00:00 [2 h ago]", and reports "-" if the
+// sensor has never been in that state.
+func parseLastUpDown(value string, loc *time.Location) (time.Time, bool) {
+	text, _, _ := strings.Cut(cleanMessageHTML(value), "[")
+	text = strings.TrimSpace(text)
+	if isNeverPolled(text) {
+		return time.Time{}, false
+	}
+	parsed, _, err := parsePRTGDateTimeIn(text, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// stateSince returns the time the sensor entered its current state. The last transition of the
+// status history is preferred. Without one, a down sensor has been down since it was last up and
+// any other sensor is in its state since it was last down. ok is false for sensors that never
+// changed their state.
+func stateSince(details PrtgSensorDetailsStruct, transitions []time.Time, loc *time.Location) (time.Time, string, bool) {
+	if len(transitions) > 0 {
+		return transitions[len(transitions)-1], stateSinceSourceHistory, true
+	}
+
+	statusRaw, _ := strconv.Atoi(strings.TrimSpace(details.StatusId))
+	reference := details.LastDown
+	switch statusRaw {
+	case prtgStatusDown, prtgStatusDownAcknowledged, prtgStatusDownPartial:
+		reference = details.LastUp
+	}
+	since, ok := parseLastUpDown(reference, loc)
+	return since, stateSinceSourceLastUpDown, ok
+}
+
+// formatStateDuration formats a duration for text panels, e.g. "3d4h", "2h15m" or "45s".
+// Only the two largest units are shown.
+func formatStateDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int64(d / time.Second)
+	days, hours := seconds/86400, seconds%86400/3600
+	minutes, seconds := seconds%3600/60, seconds%60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// handleStateSinceQuery returns how long the sensor has been in its current state. The status
+// history of the time range is read first, the lastup and lastdown times of the sensor are the
+// fallback.
+func (d *Datasource) handleStateSinceQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	format := qm.StateSinceFormat
+	switch format {
+	case "":
+		format = stateSinceSeconds
+	case stateSinceSeconds, stateSinceText:
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("invalid query: unknown stateSinceFormat %q, expected %q or %q", format, stateSinceSeconds, stateSinceText))
+	}
+
+	details, err := d.api.GetSensorDetails(ctx, qm.ObjectId)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	timezone, _ := d.effectiveTimezone(ctx)
+	var transitions []time.Time
+	messages, err := d.api.GetMessages(ctx, qm.ObjectId, query.TimeRange.From.UnixMilli(), query.TimeRange.To.UnixMilli())
+	if err != nil {
+		backend.Logger.Warn("Status history unavailable, using lastup/lastdown", "objectId", qm.ObjectId, "error", err)
+	} else {
+		transitions, _ = statusTransitions(messages.Messages, timezone)
+	}
+
+	since, source, ok := stateSince(details.SensorData, transitions, timezone)
+
+	var sinceValue *time.Time
+	var seconds *float64
+	var text *string
+	if ok {
+		duration := time.Since(since)
+		sinceValue = &since
+		value := duration.Seconds()
+		seconds = &value
+		formatted := formatStateDuration(duration)
+		text = &formatted
+	}
+
+	durationField := data.NewField("Duration", nil, []*float64{seconds}).SetConfig(&data.FieldConfig{Unit: "s"})
+	if format == stateSinceText {
+		durationField = data.NewField("Duration", nil, []*string{text})
+	}
+	frame := data.NewFrame("response",
+		data.NewField("Status", nil, []string{cleanMessageHTML(details.SensorData.StatusText)}),
+		data.NewField("Since", nil, []*time.Time{sinceValue}),
+		durationField,
+	)
+	frame.SetMeta(&data.FrameMeta{
+		Custom: map[string]interface{}{
+			"source": source,
+		},
+	})
+	if !ok {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("Sensor %s has not changed its state yet", qm.ObjectId),
+		})
+	}
+
+	response.Frames = append(response.Frames, frame)
+	return response
+}
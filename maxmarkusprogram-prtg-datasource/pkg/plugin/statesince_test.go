@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ formatStateDuration test: The two largest units are shown
+func TestFormatStateDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{-time.Minute, "0s"},
+		{45 * time.Second, "45s"},
+		{15*time.Minute + 30*time.Second, "15m30s"},
+		{2*time.Hour + 15*time.Minute + 10*time.Second, "2h15m"},
+		{3*24*time.Hour + 4*time.Hour + 59*time.Minute, "3d4h"},
+	}
+
+	for _, tt := range tests {
+		if got := formatStateDuration(tt.duration); got != tt.expected {
+			t.Errorf("formatStateDuration(%v) = %q; expected %q", tt.duration, got, tt.expected)
+		}
+	}
+}
+
+// ✅ stateSince test: Status history first, then lastup/lastdown by the current state
+func TestStateSince(t *testing.T) {
+	transition := time.Date(2025, 2, 15, 12, 10, 0, 0, time.UTC)
+	lastUp := time.Date(2025, 2, 15, 11, 0, 0, 0, time.UTC)
+	lastDown := time.Date(2025, 2, 14, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		details        PrtgSensorDetailsStruct
+		transitions    []time.Time
+		expected       time.Time
+		expectedSource string
+		expectedOk     bool
+	}{
+		{
+			name:           "History",
+			details:        PrtgSensorDetailsStruct{StatusId: "5", LastUp: "15.02.2025 11:00:00"},
+			transitions:    []time.Time{lastUp, transition},
+			expected:       transition,
+			expectedSource: stateSinceSourceHistory,
+			expectedOk:     true,
+		},
+		{
+			name:           "Down since last up",
+			details:        PrtgSensorDetailsStruct{StatusId: "5", LastUp: "15.02.2025 11:00:00 [1 h ago]", LastDown: "14.02.2025 09:30:00"},
+			expected:       lastUp,
+			expectedSource: stateSinceSourceLastUpDown,
+			expectedOk:     true,
+		},
+		{
+			name:           "Up since last down",
+			details:        PrtgSensorDetailsStruct{StatusId: "3", LastUp: "15.02.2025 11:00:00", LastDown: "14.02.2025 09:30:00 [1 d ago]"},
+			expected:       lastDown,
+			expectedSource: stateSinceSourceLastUpDown,
+			expectedOk:     true,
+		},
+		{
+			name:           "Never changed",
+			details:        PrtgSensorDetailsStruct{StatusId: "3", LastUp: "15.02.2025 11:00:00", LastDown: "-"},
+			expectedSource: stateSinceSourceLastUpDown,
+			expectedOk:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, source, ok := stateSince(tt.details, tt.transitions, time.UTC)
+			if ok != tt.expectedOk || source != tt.expectedSource || (ok && !since.Equal(tt.expected)) {
+				t.Errorf("Expected %v %q %v, got %v %q %v", tt.expected, tt.expectedSource, tt.expectedOk, since, source, ok)
+			}
+		})
+	}
+}
+
+// ✅ State since query in seconds and as text
+func TestQueryData_StateSince(t *testing.T) {
+	lastDown := time.Now().UTC().Add(-2*time.Hour - 15*time.Minute)
+	details := `{"sensordata": {"statustext": "Up", "statusid": "3", "lastdown": "-"}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sensordetails.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, details)
+	})
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"messages": []}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second), timezone: time.UTC}
+	request := func(format string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(`{"queryType":"stateSince","objid":"1234","stateSinceFormat":"` + format + `"}`),
+			TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+		})
+	}
+
+	// A sensor that never changed its state has no duration
+	resp := request("")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if v := resp.Frames[0].Fields[2].At(0).(*float64); v != nil {
+		t.Errorf("Expected no duration, got %v", *v)
+	}
+	if len(resp.Frames[0].Meta.Notices) != 1 {
+		t.Errorf("Expected a notice, got %+v", resp.Frames[0].Meta.Notices)
+	}
+
+	details = `{"sensordata": {"statustext": "Up", "statusid": "3", "lastdown": "` + lastDown.Format("02.01.2006 15:04:05") + ` [2 h ago]"}}`
+	resp = request("seconds")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	duration := resp.Frames[0].Fields[2]
+	if v := duration.At(0).(*float64); v == nil || *v < 8100 || *v > 8200 {
+		t.Errorf("Expected about 8100 seconds, got %v", v)
+	}
+	if duration.Config == nil || duration.Config.Unit != "s" {
+		t.Errorf("Expected unit s, got %+v", duration.Config)
+	}
+
+	resp = request("text")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if v := resp.Frames[0].Fields[2].At(0).(*string); v == nil || *v != "2h15m" {
+		t.Errorf("Expected 2h15m, got %v", v)
+	}
+
+	if resp := request("hours"); resp.Error == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,350 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Unknown status policy
+//
+// PRTG reports objects it has no state for with status code 0 (none) or 1 (unknown).
+// Such samples say nothing about whether the object worked, so the policy decides how
+// they are treated in status fields and availability calculations:
+//
+//   - "ignore" (default): status fields keep the reported value, availability leaves
+//     the samples out of both numerator and denominator.
+//   - "down": unknown is reported as Down and counts as unavailable.
+//   - "up": unknown is reported as Up and counts as available.
+//
+// The choice can move SLA numbers considerably on sensors with frequent gaps.
+const (
+	unknownStatusIgnore = "ignore"
+	unknownStatusDown   = "down"
+	unknownStatusUp     = "up"
+)
+
+// PRTG status codes used by the status mapping.
+const (
+	prtgStatusNone    = 0
+	prtgStatusUnknown = 1
+	prtgStatusUp      = 3
+	prtgStatusWarning = 4
+	prtgStatusDown    = 5
+	prtgStatusUnusual = 10
+
+	prtgStatusCollecting  = 2
+	prtgStatusNoProbe     = 6
+	prtgStatusNotLicensed = 11
+
+	prtgStatusDownAcknowledged = 13
+	prtgStatusDownPartial      = 14
+
+	prtgStatusPausedByUser       = 7
+	prtgStatusPausedByDependency = 8
+	prtgStatusPausedBySchedule   = 9
+	prtgStatusPausedUntil        = 12
+)
+
+// statusNames are the state names of all PRTG status codes.
+var statusNames = map[int]string{
+	prtgStatusNone:               "None",
+	prtgStatusUnknown:            "Unknown",
+	prtgStatusCollecting:         "Collecting",
+	prtgStatusUp:                 "Up",
+	prtgStatusWarning:            "Warning",
+	prtgStatusDown:               "Down",
+	prtgStatusNoProbe:            "No Probe",
+	prtgStatusPausedByUser:       "Paused by User",
+	prtgStatusPausedByDependency: "Paused by Dependency",
+	prtgStatusPausedBySchedule:   "Paused by Schedule",
+	prtgStatusUnusual:            "Unusual",
+	prtgStatusNotLicensed:        "Not Licensed",
+	prtgStatusPausedUntil:        "Paused Until",
+	prtgStatusDownAcknowledged:   "Down Acknowledged",
+	prtgStatusDownPartial:        "Down Partial",
+}
+
+// statusValueMappings maps the raw status codes to their names and colors, so State Timeline
+// and Status History panels show states instead of numbers.
+func statusValueMappings() data.ValueMappings {
+	mapper := make(data.ValueMapper, len(statusNames))
+	for code, name := range statusNames {
+		mapper[strconv.Itoa(code)] = data.ValueMappingResult{
+			Text:  name,
+			Color: statusColor(code),
+			Index: code,
+		}
+	}
+	return data.ValueMappings{mapper}
+}
+
+// normalizeUnknownStatusPolicy returns a known policy, defaulting to ignore.
+func normalizeUnknownStatusPolicy(policy string) string {
+	switch p := strings.ToLower(strings.TrimSpace(policy)); p {
+	case unknownStatusDown, unknownStatusUp:
+		return p
+	default:
+		return unknownStatusIgnore
+	}
+}
+
+// isUnknownStatus reports whether the raw status code means "no state known".
+func isUnknownStatus(statusRaw int) bool {
+	return statusRaw == prtgStatusNone || statusRaw == prtgStatusUnknown
+}
+
+// mapStatus applies the unknown status policy to a status code and its text.
+func mapStatus(statusRaw int, status string, policy string) (int, string) {
+	if !isUnknownStatus(statusRaw) {
+		return statusRaw, status
+	}
+	switch normalizeUnknownStatusPolicy(policy) {
+	case unknownStatusDown:
+		return prtgStatusDown, "Down"
+	case unknownStatusUp:
+		return prtgStatusUp, "Up"
+	default:
+		return statusRaw, status
+	}
+}
+
+// isAvailableStatus reports whether a status code counts as available.
+// The second return value is false if the sample must be left out of the calculation.
+func isAvailableStatus(statusRaw int, policy string) (bool, bool) {
+	statusRaw, _ = mapStatus(statusRaw, "", policy)
+	if isUnknownStatus(statusRaw) {
+		return false, false
+	}
+	switch statusRaw {
+	case prtgStatusUp, prtgStatusWarning, prtgStatusUnusual:
+		return true, true
+	default:
+		return false, true
+	}
+}
+
+// isPausedStatus reports whether the raw status code is one of the paused states.
+func isPausedStatus(statusRaw int) bool {
+	switch statusRaw {
+	case prtgStatusPausedByUser, prtgStatusPausedByDependency, prtgStatusPausedBySchedule, prtgStatusPausedUntil:
+		return true
+	default:
+		return false
+	}
+}
+
+// sensorStateNotice returns a notice text if the sensor is paused or in a simulated error
+// state, which typically explains flat or stale historic data.
+func sensorStateNotice(details PrtgSensorDetailsStruct) (string, bool) {
+	statusText := cleanMessageHTML(details.StatusText)
+	statusRaw, err := strconv.Atoi(strings.TrimSpace(details.StatusId))
+	if (err == nil && isPausedStatus(statusRaw)) || strings.Contains(strings.ToLower(statusText), "paused") {
+		return fmt.Sprintf("Sensor %s is paused (%s), its data may be stale", details.Name, statusText), true
+	}
+
+	lastMessage := strings.ToLower(cleanMessageHTML(details.LastMessage))
+	if strings.Contains(strings.ToLower(statusText), "simulated") || strings.Contains(lastMessage, "simulated error") {
+		return fmt.Sprintf("Sensor %s is in a simulated error state, its data may not reflect real measurements", details.Name), true
+	}
+	return "", false
+}
+
+// statusTransitions returns the times and new states of all status changes in the message
+// log. The first logged status is the starting state and does not count as a transition.
+// Datetimes without zone are read in loc.
+func statusTransitions(messages []PrtgMessageListItemStruct, loc *time.Location) ([]time.Time, []string) {
+	type entry struct {
+		time      time.Time
+		statusRaw int
+		status    string
+	}
+
+	entries := make([]entry, 0, len(messages))
+	for _, m := range messages {
+		timestamp, _, err := parsePRTGDateTimeIn(m.Datetime, loc)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{time: timestamp, statusRaw: m.StatusRAW, status: m.Status})
+	}
+	// PRTG returns the newest entry first
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+
+	var times []time.Time
+	var states []string
+	for i := 1; i < len(entries); i++ {
+		if entries[i].statusRaw == entries[i-1].statusRaw {
+			continue
+		}
+		times = append(times, entries[i].time)
+		states = append(states, entries[i].status)
+	}
+	return times, states
+}
+
+// Reported vs. computed uptime
+//
+// PRTG keeps its own uptime/downtime statistics per sensor (sensordetails.json). These are
+// the values shown in the PRTG web interface: they cover the time since the statistics were
+// last reset, not the dashboard time range, and PRTG decides how paused or unknown periods
+// count. When a sensor does not report them, the uptime is computed from the status changes
+// in the message log within the dashboard time range instead. The computed value weighs every
+// logged status by how long it lasted, applies the unknown status policy and treats the time
+// before the first log entry as unknown. Both values can therefore differ for the same sensor.
+
+// parsePercent parses PRTG percentage strings like "99.9876%" or "99,9876 %".
+func parsePercent(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	s = strings.ReplaceAll(s, ",", ".")
+	if s == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// reportedUptime returns the uptime and downtime percentage reported by PRTG.
+func reportedUptime(details PrtgSensorDetailsStruct) (float64, float64, bool) {
+	uptime, okUp := parsePercent(details.Uptime)
+	downtime, okDown := parsePercent(details.Downtime)
+	switch {
+	case okUp && okDown:
+		return uptime, downtime, true
+	case okUp:
+		return uptime, 100 - uptime, true
+	case okDown:
+		return 100 - downtime, downtime, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// messageStatus returns the sensor status a message log entry changed to. The log also holds
+// entries like "Info", "Started" or setting changes, which are not status changes. The
+// status text is read first ("Down (Acknowledged)", "Paused"), entries without text fall
+// back to the raw status code.
+func messageStatus(m PrtgMessageListItemStruct) (int, bool) {
+	text := strings.TrimSpace(cleanMessageHTML(m.Status))
+	if text == "" {
+		if _, ok := statusNames[m.StatusRAW]; ok && m.StatusRAW != prtgStatusNone {
+			return m.StatusRAW, true
+		}
+		return 0, false
+	}
+
+	text = strings.NewReplacer("(", "", ")", "").Replace(text)
+	if strings.HasPrefix(strings.ToLower(text), "paused") {
+		return prtgStatusPausedByUser, true
+	}
+	for code, name := range statusNames {
+		if code != prtgStatusNone && strings.EqualFold(name, text) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// computedUptime computes the uptime percentage between from and to from the status changes
+// of the message log. Every status holds until the next status change; ok is false if no
+// time counts. Datetimes without zone are read in loc.
+func computedUptime(messages []PrtgMessageListItemStruct, from, to time.Time, policy string, loc *time.Location) (float64, bool) {
+	type change struct {
+		time      time.Time
+		statusRaw int
+	}
+
+	changes := make([]change, 0, len(messages))
+	for _, m := range messages {
+		statusRaw, ok := messageStatus(m)
+		if !ok {
+			continue
+		}
+		timestamp, _, err := parsePRTGDateTimeIn(m.Datetime, loc)
+		if err != nil {
+			continue
+		}
+		changes = append(changes, change{time: timestamp, statusRaw: statusRaw})
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].time.Before(changes[j].time) })
+
+	var available, total time.Duration
+	for i, c := range changes {
+		start := c.time
+		if start.Before(from) {
+			start = from
+		}
+		end := to
+		if i+1 < len(changes) && changes[i+1].time.Before(to) {
+			end = changes[i+1].time
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		up, counted := isAvailableStatus(c.statusRaw, policy)
+		if !counted {
+			continue
+		}
+		total += end.Sub(start)
+		if up {
+			available += end.Sub(start)
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(available) / float64(total) * 100, true
+}
+
+// parseTaskCount parses the task counters of status.json. PRTG delivers them as text which
+// may be empty, contain HTML, thousands separators ("1.234") or trailing text. Unreadable
+// values count as 0.
+func parseTaskCount(raw string) int64 {
+	text := strings.TrimSpace(cleanMessageHTML(raw))
+	start := strings.IndexFunc(text, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 {
+		return 0
+	}
+
+	var digits strings.Builder
+	for _, r := range text[start:] {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+			continue
+		}
+		if r != '.' && r != ',' {
+			break
+		}
+	}
+	count, err := strconv.ParseInt(digits.String(), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// backgroundTasks are the task counters of the PRTG core server.
+type backgroundTasks struct {
+	Background    int64 `json:"background"`
+	Correlation   int64 `json:"correlation"`
+	AutoDiscovery int64 `json:"autoDiscovery"`
+	Reports       int64 `json:"reports"`
+}
+
+// parseBackgroundTasks reads the task counters from status.json.
+func parseBackgroundTasks(status *PrtgStatusListResponse) backgroundTasks {
+	return backgroundTasks{
+		Background:    parseTaskCount(status.BackgroundTasks),
+		Correlation:   parseTaskCount(status.CorrelationTasks),
+		AutoDiscovery: parseTaskCount(status.AutoDiscoTasks),
+		Reports:       parseTaskCount(status.ReportTasks),
+	}
+}
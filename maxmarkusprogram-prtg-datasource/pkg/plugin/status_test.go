@@ -0,0 +1,435 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ✅ mapStatus test: Every policy for unknown and known codes
+func TestMapStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		statusRaw      int
+		status         string
+		policy         string
+		expectedRaw    int
+		expectedStatus string
+	}{
+		{"Ignore keeps none", 0, "", "ignore", 0, ""},
+		{"Ignore keeps unknown", 1, "Unknown", "ignore", 1, "Unknown"},
+		{"Empty policy ignores", 1, "Unknown", "", 1, "Unknown"},
+		{"Down maps none", 0, "", "down", 5, "Down"},
+		{"Down maps unknown", 1, "Unknown", "down", 5, "Down"},
+		{"Up maps unknown", 1, "Unknown", "up", 3, "Up"},
+		{"Up leaves down", 5, "Down", "up", 5, "Down"},
+		{"Down leaves warning", 4, "Warning", "down", 4, "Warning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, status := mapStatus(tt.statusRaw, tt.status, tt.policy)
+			if raw != tt.expectedRaw || status != tt.expectedStatus {
+				t.Errorf("Expected %d/%q, got %d/%q", tt.expectedRaw, tt.expectedStatus, raw, status)
+			}
+		})
+	}
+}
+
+// ✅ Property queries apply the unknown status policy
+func TestQueryData_UnknownStatusPolicy(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "CPU Load", "datetime": "2025-02-15T12:00:00Z", "status": "Unknown", "status_raw": 1}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	tests := []struct {
+		policy         string
+		filterProperty string
+		expected       interface{}
+	}{
+		{"", "status", "Unknown"},
+		{"ignore", "status_raw", float64(1)},
+		{"down", "status", "Down"},
+		{"down", "status_raw", float64(5)},
+		{"up", "status", "Up"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy+"/"+tt.filterProperty, func(t *testing.T) {
+			ds := &Datasource{api: api, unknownStatusPolicy: tt.policy}
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+				RefID: "A",
+				JSON:  []byte(`{"queryType":"text","property":"sensor","sensor":"CPU Load","filterProperty":"` + tt.filterProperty + `"}`),
+			})
+			if len(resp.Frames) != 1 {
+				t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+			}
+			if value := resp.Frames[0].Fields[1].At(0); value != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+// ✅ Raw status values carry value mappings to the PRTG state names
+func TestQueryData_StatusValueMappings(t *testing.T) {
+	mockResponse := `{"sensors": [{"sensor": "CPU Load", "datetime": "2025-02-15T12:00:00Z", "status": "Down", "status_raw": 5}]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	query := func(filterProperty string) *data.Field {
+		resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID: "A",
+			JSON:  []byte(`{"queryType":"text","property":"sensor","sensor":"CPU Load","filterProperty":"` + filterProperty + `"}`),
+		})
+		if len(resp.Frames) != 1 {
+			t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+		}
+		return resp.Frames[0].Fields[1]
+	}
+
+	field := query("status_raw")
+	if len(field.Config.Mappings) != 1 {
+		t.Fatalf("Expected one value mapping, got %d", len(field.Config.Mappings))
+	}
+	mapper, ok := field.Config.Mappings[0].(data.ValueMapper)
+	if !ok || len(mapper) != 15 {
+		t.Fatalf("Expected a value mapper for all 15 status codes, got %#v", field.Config.Mappings[0])
+	}
+	expected := map[string]data.ValueMappingResult{
+		"1":  {Text: "Unknown", Color: "grey", Index: 1},
+		"3":  {Text: "Up", Color: "green", Index: 3},
+		"4":  {Text: "Warning", Color: "yellow", Index: 4},
+		"5":  {Text: "Down", Color: "red", Index: 5},
+		"7":  {Text: "Paused by User", Color: "blue", Index: 7},
+		"13": {Text: "Down Acknowledged", Color: "orange", Index: 13},
+	}
+	for code, result := range expected {
+		if mapper[code] != result {
+			t.Errorf("Expected %+v for status %s, got %+v", result, code, mapper[code])
+		}
+	}
+
+	// The status text needs no mapping
+	if field := query("status"); len(field.Config.Mappings) != 0 {
+		t.Errorf("Expected no value mappings for the status text, got %d", len(field.Config.Mappings))
+	}
+}
+
+// ✅ parsePercent test: PRTG percentage formats
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		ok       bool
+	}{
+		{"99.9876%", 99.9876, true},
+		{"99,9876 %", 99.9876, true},
+		{" 0 %", 0, true},
+		{"", 0, false},
+		{"n/a", 0, false},
+	}
+
+	for _, tt := range tests {
+		result, ok := parsePercent(tt.input)
+		if ok != tt.ok || result != tt.expected {
+			t.Errorf("parsePercent(%q): expected %v/%v, got %v/%v", tt.input, tt.expected, tt.ok, result, ok)
+		}
+	}
+}
+
+// ✅ computedUptime test: Time-weighted status history
+func TestComputedUptime(t *testing.T) {
+	from := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	to := from.Add(100 * time.Minute)
+	// Newest first, like PRTG returns the log
+	messages := []PrtgMessageListItemStruct{
+		{Datetime: "2025-02-15T13:30:00Z", StatusRAW: 3},
+		// Entries that are no status change do not end the down period
+		{Datetime: "2025-02-15T13:20:00Z", Status: "Info", StatusRAW: 609},
+		{Datetime: "2025-02-15T13:10:00Z", Status: "Acknowledged", StatusRAW: 0},
+		{Datetime: "2025-02-15T13:00:00Z", Status: "Down", StatusRAW: 5},
+		{Datetime: "2025-02-15T12:50:00Z", StatusRAW: 1},
+		{Datetime: "2025-02-15T11:00:00Z", StatusRAW: 3},
+	}
+
+	// Up 50 min, unknown 10 min, down 30 min, up 10 min
+	tests := []struct {
+		policy   string
+		expected float64
+	}{
+		{"ignore", float64(60) / float64(90) * 100},
+		{"down", float64(60) / float64(100) * 100},
+		{"up", float64(70) / float64(100) * 100},
+	}
+
+	for _, tt := range tests {
+		result, ok := computedUptime(messages, from, to, tt.policy, time.UTC)
+		if !ok {
+			t.Fatalf("%s: expected a result", tt.policy)
+		}
+		if math.Abs(result-tt.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", tt.policy, tt.expected, result)
+		}
+	}
+
+	if _, ok := computedUptime([]PrtgMessageListItemStruct{{Datetime: "2025-02-15T12:00:00Z", Status: "Started"}}, from, to, "ignore", time.UTC); ok {
+		t.Errorf("Expected no result without status changes")
+	}
+	if _, ok := computedUptime(nil, from, to, "ignore", time.UTC); ok {
+		t.Errorf("Expected no result without messages")
+	}
+}
+
+// ✅ Downtime query: Reported values and computed fallback
+func TestQueryData_Downtime(t *testing.T) {
+	var details string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sensordetails.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, details)
+	})
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"messages": [{"datetime": "2025-02-15T12:00:00Z", "status_raw": 3}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"downtime","objid":"1234"}`),
+		TimeRange: backend.TimeRange{
+			From: time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC),
+			To:   time.Date(2025, 2, 15, 13, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name           string
+		details        string
+		expectedUptime float64
+		expectedSource string
+	}{
+		{"Reported", `{"sensordata": {"uptime": "99,5 %", "downtime": "0,5 %"}}`, 99.5, "reported"},
+		{"Computed", `{"sensordata": {"name": "Ping"}}`, 100, "computed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details = tt.details
+			resp := ds.query(context.Background(), backend.PluginContext{}, query)
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			frame := resp.Frames[0]
+			if frame.Fields[0].At(0).(float64) != tt.expectedUptime {
+				t.Errorf("Expected uptime %v, got %v", tt.expectedUptime, frame.Fields[0].At(0))
+			}
+			if frame.Fields[1].At(0).(float64) != 100-tt.expectedUptime {
+				t.Errorf("Expected downtime %v, got %v", 100-tt.expectedUptime, frame.Fields[1].At(0))
+			}
+			if frame.Meta.Custom.(map[string]interface{})["source"] != tt.expectedSource {
+				t.Errorf("Expected source %q, got %v", tt.expectedSource, frame.Meta.Custom)
+			}
+		})
+	}
+}
+
+// ✅ Metrics query attaches a notice for a paused sensor
+func TestQueryData_PausedSensorNotice(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Total": 5}]}`)
+	})
+	mux.HandleFunc("/api/sensordetails.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sensordata": {"name": "Ping", "statustext": "Paused by User", "statusid": "7"}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)}
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Total","checkSensorState":true}`),
+		TimeRange: backend.TimeRange{
+			From: time.Now().Add(-time.Hour),
+			To:   time.Now(),
+		},
+	}
+
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	notices := resp.Frames[0].Meta.Notices
+	if len(notices) != 1 || !strings.Contains(notices[0].Text, "paused") {
+		t.Errorf("Expected a paused notice, got %+v", notices)
+	}
+
+	// Without the option no status call is made
+	query.JSON = []byte(`{"queryType":"metrics","objid":"1234","channel":"Total"}`)
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if len(resp.Frames[0].Meta.Notices) != 0 {
+		t.Errorf("Expected no notices by default, got %+v", resp.Frames[0].Meta.Notices)
+	}
+}
+
+// ✅ sensorStateNotice test: Simulated error and normal state
+func TestSensorStateNotice(t *testing.T) {
+	if _, ok := sensorStateNotice(PrtgSensorDetailsStruct{Name: "Ping", StatusText: "Down", StatusId: "5", LastMessage: "Simulated error"}); !ok {
+		t.Errorf("Expected a notice for a simulated error")
+	}
+	if text, ok := sensorStateNotice(PrtgSensorDetailsStruct{Name: "Ping", StatusText: "Up", StatusId: "3", LastMessage: "OK"}); ok {
+		t.Errorf("Expected no notice for an up sensor, got %q", text)
+	}
+}
+
+// ✅ statusTransitions test: Known sequence with repeated states
+func TestStatusTransitions(t *testing.T) {
+	// Newest first, like PRTG returns the log
+	messages := []PrtgMessageListItemStruct{
+		{Datetime: "2025-02-15T12:50:00Z", Status: "Up", StatusRAW: 3},
+		{Datetime: "2025-02-15T12:40:00Z", Status: "Down", StatusRAW: 5},
+		{Datetime: "2025-02-15T12:30:00Z", Status: "Warning", StatusRAW: 4},
+		{Datetime: "2025-02-15T12:20:00Z", Status: "Warning", StatusRAW: 4},
+		{Datetime: "2025-02-15T12:10:00Z", Status: "Up", StatusRAW: 3},
+		{Datetime: "2025-02-15T12:00:00Z", Status: "Up", StatusRAW: 3},
+	}
+
+	times, states := statusTransitions(messages, time.UTC)
+	expected := []string{"Warning", "Down", "Up"}
+	if len(states) != len(expected) {
+		t.Fatalf("Expected %d transitions, got %d: %v", len(expected), len(states), states)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("Transition %d: expected %s, got %s", i, expected[i], states[i])
+		}
+	}
+	if times[0].Minute() != 20 {
+		t.Errorf("Expected the first transition at 12:20, got %v", times[0])
+	}
+
+	if times, _ := statusTransitions(messages[:1], time.UTC); len(times) != 0 {
+		t.Errorf("Expected no transition for a single entry")
+	}
+}
+
+// ✅ Status transitions query with optional timestamps
+func TestQueryData_StatusTransitions(t *testing.T) {
+	mockResponse := `{"messages": [
+		{"datetime": "2025-02-15T12:20:00Z", "status": "Up", "status_raw": 3},
+		{"datetime": "2025-02-15T12:10:00Z", "status": "Down", "status_raw": 5},
+		{"datetime": "2025-02-15T12:00:00Z", "status": "Up", "status_raw": 3}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"statusTransitions","objid":"1234","includeTransitionTimes":true}`),
+		TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Fatalf("Expected count and times frames, got %d", len(resp.Frames))
+	}
+	if count := resp.Frames[0].Fields[0].At(0).(int64); count != 2 {
+		t.Errorf("Expected 2 transitions, got %d", count)
+	}
+	if resp.Frames[1].Fields[1].Len() != 2 {
+		t.Errorf("Expected 2 transition times, got %d", resp.Frames[1].Fields[1].Len())
+	}
+}
+
+// ✅ parseTaskCount test: Robust parsing of the status.json task counters
+func TestParseTaskCount(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected int64
+	}{
+		{"0", 0},
+		{"3", 3},
+		{" 12 ", 12},
+		{"1.234", 1234},
+		{"1,234", 1234},
+		{"<span class=\"count\">7</span>", 7},
+		{"5 (running)", 5},
+		{"", 0},
+		{"none", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseTaskCount(tt.raw); got != tt.expected {
+			t.Errorf("parseTaskCount(%q) = %d, expected %d", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+// ✅ parseBackgroundTasks test
+func TestParseBackgroundTasks(t *testing.T) {
+	status := &PrtgStatusListResponse{
+		BackgroundTasks:  "4",
+		CorrelationTasks: "",
+		AutoDiscoTasks:   "1",
+		ReportTasks:      "2 ",
+	}
+
+	tasks := parseBackgroundTasks(status)
+	expected := backgroundTasks{Background: 4, Correlation: 0, AutoDiscovery: 1, Reports: 2}
+	if tasks != expected {
+		t.Errorf("Expected %+v, got %+v", expected, tasks)
+	}
+}
+
+// ✅ backgroundtasks route: Task counters parsed as integers
+func TestCallResourceBackgroundTasks(t *testing.T) {
+	mockResponse := `{"backgroundtasks": "4", "correlationtasks": "", "autodiscotasks": "1", "reporttasks": "1.234"}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "backgroundtasks"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	expected := `{"background":4,"correlation":0,"autoDiscovery":1,"reports":1234}`
+	if respSender.status != http.StatusOK || string(respSender.body) != expected {
+		t.Errorf("Expected %s, got %d %s", expected, respSender.status, respSender.body)
+	}
+}
+
+// ✅ messageStatus test: Status changes by text or raw code, other log entries are skipped
+func TestMessageStatus(t *testing.T) {
+	tests := []struct {
+		message  PrtgMessageListItemStruct
+		expected int
+		ok       bool
+	}{
+		{PrtgMessageListItemStruct{Status: "Down", StatusRAW: 608}, prtgStatusDown, true},
+		{PrtgMessageListItemStruct{Status: "Down (Acknowledged)"}, prtgStatusDownAcknowledged, true},
+		{PrtgMessageListItemStruct{Status: "Paused"}, prtgStatusPausedByUser, true},
+		{PrtgMessageListItemStruct{StatusRAW: prtgStatusWarning}, prtgStatusWarning, true},
+		{PrtgMessageListItemStruct{Status: "Info", StatusRAW: prtgStatusUp}, 0, false},
+		{PrtgMessageListItemStruct{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		statusRaw, ok := messageStatus(tt.message)
+		if ok != tt.ok || statusRaw != tt.expected {
+			t.Errorf("%+v: expected %d/%v, got %d/%v", tt.message, tt.expected, tt.ok, statusRaw, ok)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Series summary
+//
+// Every metrics frame carries the minimum, maximum and average of its series in the
+// "summary" metadata. The values are taken before changes-only collapsing and downsampling,
+// so they describe all points of the time range. With includeSummary the query also returns
+// a single-row companion frame per channel that stat panels can show without a transform.
+
+// summaryFrameName is the name of the companion frames holding the series summaries.
+const summaryFrameName = "summary"
+
+// seriesSummary holds the aggregates of a series. Min, Max and Avg are nil for a series
+// without values.
+type seriesSummary struct {
+	Count int      `json:"count"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Avg   *float64 `json:"avg"`
+}
+
+// summarizeSeries computes the summary of the given values, NaN values are skipped.
+func summarizeSeries(values []float64) *seriesSummary {
+	summary := &seriesSummary{}
+	minValue, maxValue, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		summary.Count++
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+		sum += v
+	}
+	if summary.Count > 0 {
+		avg := sum / float64(summary.Count)
+		summary.Min, summary.Max, summary.Avg = &minValue, &maxValue, &avg
+	}
+	return summary
+}
+
+// scale multiplies the aggregates by ratio, used when the series is normalized to another unit.
+func (s *seriesSummary) scale(ratio float64) {
+	for _, v := range []*float64{s.Min, s.Max, s.Avg} {
+		if v != nil {
+			*v *= ratio
+		}
+	}
+}
+
+// summaryFrame builds the companion frame of a metrics frame from its summary metadata. It
+// returns nil if the frame has no summary.
+func summaryFrame(frame *data.Frame) *data.Frame {
+	if frame == nil || frame.Meta == nil || len(frame.Fields) < 2 {
+		return nil
+	}
+	custom, ok := frame.Meta.Custom.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	summary, ok := custom["summary"].(*seriesSummary)
+	if !ok {
+		return nil
+	}
+
+	value := frame.Fields[1]
+	displayName := value.Name
+	var unit string
+	if value.Config != nil {
+		if value.Config.DisplayName != "" {
+			displayName = value.Config.DisplayName
+		}
+		unit = value.Config.Unit
+	}
+
+	result := data.NewFrame(summaryFrameName)
+	for _, stat := range []struct {
+		name  string
+		value *float64
+	}{
+		{"min", summary.Min},
+		{"max", summary.Max},
+		{"avg", summary.Avg},
+	} {
+		result.Fields = append(result.Fields,
+			data.NewField(stat.name, value.Labels, []*float64{stat.value}).SetConfig(&data.FieldConfig{
+				DisplayName: fmt.Sprintf("%s (%s)", displayName, stat.name),
+				Unit:        unit,
+			}),
+		)
+	}
+	result.SetMeta(&data.FrameMeta{
+		Custom: map[string]interface{}{"count": summary.Count},
+	})
+	return result
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ summarizeSeries test: Minimum, maximum and average, NaN and empty series
+func TestSummarizeSeries(t *testing.T) {
+	summary := summarizeSeries([]float64{4, math.NaN(), 1, 7})
+	if summary.Count != 3 || *summary.Min != 1 || *summary.Max != 7 || *summary.Avg != 4 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	summary.scale(0.5)
+	if *summary.Min != 0.5 || *summary.Max != 3.5 || *summary.Avg != 2 {
+		t.Errorf("Unexpected scaled summary %+v", summary)
+	}
+
+	empty := summarizeSeries(nil)
+	if empty.Count != 0 || empty.Min != nil || empty.Max != nil || empty.Avg != nil {
+		t.Errorf("Expected an empty summary, got %+v", empty)
+	}
+	empty.scale(2)
+}
+
+// ✅ Metrics query: Summary metadata and the companion frame with includeSummary
+func TestQueryData_Summary(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "CPU Load": 10},
+		{"datetime": "2025-02-15T12:01:00Z", "CPU Load": 30},
+		{"datetime": "2025-02-15T12:02:00Z", "CPU Load": 20}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, timezone: time.UTC}
+	request := func(model string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:         "A",
+			JSON:          []byte(model),
+			MaxDataPoints: 2,
+			TimeRange: backend.TimeRange{
+				From: time.Date(2025, 2, 15, 11, 0, 0, 0, time.UTC),
+				To:   time.Date(2025, 2, 15, 13, 0, 0, 0, time.UTC),
+			},
+		})
+	}
+
+	resp := request(`{"queryType":"metrics","objid":"1234","channel":"CPU Load"}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 1 {
+		t.Fatalf("Expected no summary frame without includeSummary, got %d frames", len(resp.Frames))
+	}
+	// The summary covers the points removed by downsampling
+	summary := resp.Frames[0].Meta.Custom.(map[string]interface{})["summary"].(*seriesSummary)
+	if summary.Count != 3 || *summary.Min != 10 || *summary.Max != 30 || *summary.Avg != 20 {
+		t.Errorf("Unexpected summary metadata %+v", summary)
+	}
+
+	resp = request(`{"queryType":"metrics","objid":"1234","channel":"CPU Load","includeSummary":true}`)
+	if resp.Error != nil || len(resp.Frames) != 2 {
+		t.Fatalf("Expected a summary frame, got %d frames, error %v", len(resp.Frames), resp.Error)
+	}
+	frame := resp.Frames[1]
+	if frame.Name != summaryFrameName || len(frame.Fields) != 3 {
+		t.Fatalf("Unexpected summary frame %+v", frame)
+	}
+	for i, expected := range []float64{10, 30, 20} {
+		field := frame.Fields[i]
+		if value, ok := field.ConcreteAt(0); !ok || value.(float64) != expected {
+			t.Errorf("Expected %s to be %v, got %v", field.Name, expected, value)
+		}
+	}
+	if frame.Fields[2].Config.DisplayName != "CPU Load (avg)" {
+		t.Errorf("Unexpected display name %q", frame.Fields[2].Config.DisplayName)
+	}
+}
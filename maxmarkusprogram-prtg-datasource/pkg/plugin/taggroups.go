@@ -0,0 +1,271 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Tag groups
+//
+// A tag group query compares sets of sensors, e.g. all "production" against all "staging"
+// sensors. The sensors carrying a tag with the configured prefix are grouped by the rest of
+// the tag: with prefix "env:" the tags "env:production" and "env:staging" form the groups
+// "production" and "staging". A sensor with several such tags is part of every group.
+//
+// The channel of every sensor is fetched like in a metrics query and aggregated per group:
+//
+//   - Timestamps are floored to buckets of the averaging interval, or of the query interval
+//     for raw data (at least a minute), so sensors polled at different times meet.
+//   - Several points of one sensor within a bucket are averaged first, so every sensor
+//     contributes a single value per bucket and sum is not inflated by the scan interval.
+//   - The sensor values of a bucket are combined with the aggregation: avg (default), sum,
+//     min or max. Sensors without a value in a bucket are left out, not counted as zero.
+//   - Sensors without the channel, or whose data cannot be fetched, are skipped and listed
+//     in a notice.
+//
+// One frame is returned per group, sorted by the tag value.
+
+// Aggregations of the sensor values of a tag group.
+const (
+	tagAggregationAvg = "avg"
+	tagAggregationSum = "sum"
+	tagAggregationMin = "min"
+	tagAggregationMax = "max"
+)
+
+// maxTagGroupSensors bounds the sensors of a tag group query, each costs a historic request.
+const maxTagGroupSensors = 200
+
+// tagGroupLabel is the label carrying the tag value of a group series.
+const tagGroupLabel = "tag"
+
+// normalizeTagAggregation returns a known aggregation, empty selects avg.
+func normalizeTagAggregation(aggregation string) (string, error) {
+	switch a := strings.ToLower(strings.TrimSpace(aggregation)); a {
+	case "":
+		return tagAggregationAvg, nil
+	case tagAggregationAvg, tagAggregationSum, tagAggregationMin, tagAggregationMax:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid query: unknown tagAggregation %q, expected avg, sum, min or max", aggregation)
+	}
+}
+
+// tagValues returns the values of all tags with the given prefix, compared case-insensitively,
+// in tag order and without duplicates. PRTG separates tags by spaces or commas.
+func tagValues(tags, prefix string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if len(tag) <= len(prefix) || !strings.EqualFold(tag[:len(prefix)], prefix) {
+			continue
+		}
+		value := tag[len(prefix):]
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// aggregateValues combines the values of a bucket.
+func aggregateValues(values []float64, aggregation string) float64 {
+	result := values[0]
+	for _, v := range values[1:] {
+		switch aggregation {
+		case tagAggregationMin:
+			result = math.Min(result, v)
+		case tagAggregationMax:
+			result = math.Max(result, v)
+		default:
+			result += v
+		}
+	}
+	if aggregation == tagAggregationAvg {
+		result /= float64(len(values))
+	}
+	return result
+}
+
+// sensorSeries is the channel series of a single sensor.
+type sensorSeries struct {
+	times  []time.Time
+	values []float64
+}
+
+// aggregateTagGroup combines the series of the sensors of a group into one series, see the
+// semantics above. The result is sorted by time.
+func aggregateTagGroup(series []sensorSeries, bucket time.Duration, aggregation string) ([]time.Time, []float64) {
+	buckets := make(map[time.Time][]float64)
+	for _, s := range series {
+		sums := make(map[time.Time]float64)
+		counts := make(map[time.Time]int)
+		for i, t := range alignToInterval(s.times, bucket) {
+			sums[t] += s.values[i]
+			counts[t]++
+		}
+		for t, sum := range sums {
+			buckets[t] = append(buckets[t], sum/float64(counts[t]))
+		}
+	}
+
+	times := make([]time.Time, 0, len(buckets))
+	for t := range buckets {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	values := make([]float64, len(times))
+	for i, t := range times {
+		values[i] = aggregateValues(buckets[t], aggregation)
+	}
+	return times, values
+}
+
+// handleTagGroupsQuery returns one series per value of the grouping tag, aggregating the
+// channel of all sensors carrying that tag value.
+func (d *Datasource) handleTagGroupsQuery(ctx context.Context, query backend.DataQuery, qm queryModel) backend.DataResponse {
+	var response backend.DataResponse
+
+	if strings.TrimSpace(qm.GroupTag) == "" {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "invalid query: missing groupTag")
+	}
+	if strings.TrimSpace(qm.Channel) == "" {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "invalid query: missing channel")
+	}
+	aggregation, err := normalizeTagAggregation(qm.TagAggregation)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	sensors, err := cachedTree(ctx, d.treeCache, "sensors", d.api.GetSensors)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("API request failed: %v", err))
+	}
+
+	groups := make(map[string][]string)
+	var objids []string
+	for _, sensor := range sensors.Sensors {
+		values := tagValues(sensor.Tags, strings.TrimSpace(qm.GroupTag))
+		if len(values) == 0 {
+			continue
+		}
+		objid := strconv.FormatInt(sensor.ObjectId, 10)
+		objids = append(objids, objid)
+		for _, value := range values {
+			groups[value] = append(groups[value], objid)
+		}
+	}
+	if len(objids) > maxTagGroupSensors {
+		return backend.ErrDataResponse(backend.StatusBadRequest,
+			fmt.Sprintf("tag %q matches %d sensors, at most %d are supported", qm.GroupTag, len(objids), maxTagGroupSensors))
+	}
+
+	timezone, _ := d.effectiveTimezone(ctx)
+	fromTime := query.TimeRange.From.UnixMilli()
+	toTime := query.TimeRange.To.UnixMilli()
+
+	// Historic data is fetched concurrently, bounded like the bulk channels route
+	histories := make([]*PrtgHistoricalDataResponse, len(objids))
+	errs := make([]error, len(objids))
+	sem := make(chan struct{}, bulkChannelConcurrency)
+	var wg sync.WaitGroup
+	for i, objid := range objids {
+		wg.Add(1)
+		go func(i int, objid string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			histories[i], errs[i] = d.api.GetHistoricalData(ctx, objid, fromTime, toTime)
+		}(i, objid)
+	}
+	wg.Wait()
+
+	// Buckets follow the averaging interval, raw data uses the query interval
+	bucket := time.Minute
+	averaged := false
+	series := make(map[string]sensorSeries, len(objids))
+	var skipped []string
+	for i, objid := range objids {
+		if errs[i] != nil {
+			backend.Logger.Warn("Skipping sensor of tag group", "objectId", objid, "error", errs[i])
+			skipped = append(skipped, objid)
+			continue
+		}
+		if found, _ := partitionChannels(histories[i], []string{qm.Channel}); len(found) == 0 {
+			skipped = append(skipped, objid)
+			continue
+		}
+		times, values, _, _ := extractChannelSeries(histories[i], qm.Channel, timezone, qm.RangeTimestamp, d.nonNumericPolicy)
+		series[objid] = sensorSeries{times: times, values: values}
+		if interval := time.Duration(histories[i].AvgInterval) * time.Second; interval > 0 {
+			averaged = true
+			if interval > bucket {
+				bucket = interval
+			}
+		}
+	}
+	if !averaged && query.Interval > bucket {
+		bucket = query.Interval
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	timeFieldName, _ := qm.fieldNames("")
+	for _, name := range names {
+		var members []sensorSeries
+		for _, objid := range groups[name] {
+			if s, ok := series[objid]; ok {
+				members = append(members, s)
+			}
+		}
+		times, values := aggregateTagGroup(members, bucket, aggregation)
+
+		frame := data.NewFrame("response",
+			data.NewField(timeFieldName, nil, times),
+			data.NewField(name, data.Labels{tagGroupLabel: name}, values).SetConfig(&data.FieldConfig{
+				DisplayName: name,
+			}),
+		)
+		frame.SetMeta(&data.FrameMeta{
+			Custom: map[string]interface{}{
+				"sensors":     len(members),
+				"aggregation": aggregation,
+				"bucket":      bucket.String(),
+			},
+		})
+		response.Frames = append(response.Frames, frame)
+	}
+
+	if len(response.Frames) == 0 {
+		response.Frames = append(response.Frames, data.NewFrame("response",
+			data.NewField(timeFieldName, nil, []time.Time{}),
+			data.NewField("Value", nil, []float64{}),
+		))
+		response.Frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("No sensor carries a tag starting with %q", qm.GroupTag),
+		})
+	}
+	if len(skipped) > 0 {
+		response.Frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Sensors skipped for missing channel %s or failed requests: %s", qm.Channel, strings.Join(skipped, ", ")),
+		})
+	}
+	return response
+}
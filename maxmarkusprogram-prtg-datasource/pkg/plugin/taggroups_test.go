@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ tagValues test: Values of prefixed tags, case-insensitive and without duplicates
+func TestTagValues(t *testing.T) {
+	tests := []struct {
+		tags     string
+		expected []string
+	}{
+		{"env:production pingsensor", []string{"production"}},
+		{"ENV:staging,env:production env:staging", []string{"staging", "production"}},
+		{"env: pingsensor", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := tagValues(tt.tags, "env:"); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("tagValues(%q) = %v; expected %v", tt.tags, got, tt.expected)
+		}
+	}
+}
+
+// ✅ aggregateTagGroup test: Sensor points are averaged per bucket, then aggregated
+func TestAggregateTagGroup(t *testing.T) {
+	base := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	series := []sensorSeries{
+		// Two points in the first bucket count as one value of 15
+		{times: []time.Time{base, base.Add(30 * time.Second), base.Add(time.Minute)}, values: []float64{10, 20, 30}},
+		{times: []time.Time{base.Add(10 * time.Second)}, values: []float64{5}},
+	}
+
+	tests := []struct {
+		aggregation string
+		expected    []float64
+	}{
+		{tagAggregationAvg, []float64{10, 30}},
+		{tagAggregationSum, []float64{20, 30}},
+		{tagAggregationMin, []float64{5, 30}},
+		{tagAggregationMax, []float64{15, 30}},
+	}
+
+	for _, tt := range tests {
+		times, values := aggregateTagGroup(series, time.Minute, tt.aggregation)
+		if len(times) != 2 || !times[0].Equal(base) || !times[1].Equal(base.Add(time.Minute)) {
+			t.Fatalf("%s: unexpected buckets %v", tt.aggregation, times)
+		}
+		if !reflect.DeepEqual(values, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.aggregation, tt.expected, values)
+		}
+	}
+
+	if _, err := normalizeTagAggregation("median"); err == nil {
+		t.Errorf("Expected an error for an unknown aggregation")
+	}
+}
+
+// ✅ Tag group query: One series per tag value
+func TestQueryData_TagGroups(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"treesize": 4, "sensors": [
+			{"objid": 1, "sensor": "Web 1", "tags": "env:production"},
+			{"objid": 2, "sensor": "Web 2", "tags": "env:production"},
+			{"objid": 3, "sensor": "Web 3", "tags": "env:staging"},
+			{"objid": 4, "sensor": "Backup", "tags": "backup"}
+		]}`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		load := map[string]int{"1": 10, "2": 30, "3": 5}[r.URL.Query().Get("id")]
+		if r.URL.Query().Get("id") == "4" {
+			t.Errorf("Unexpected request for an untagged sensor")
+		}
+		fmt.Fprintf(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "CPU Load": %d}]}`, load)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC}
+	request := func(json string) backend.DataResponse {
+		return ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(json),
+			TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+		})
+	}
+
+	resp := request(`{"queryType":"tagGroups","groupTag":"env:","channel":"CPU Load","tagAggregation":"sum"}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(resp.Frames))
+	}
+	expected := []struct {
+		name  string
+		value float64
+	}{
+		{"production", 40},
+		{"staging", 5},
+	}
+	for i, e := range expected {
+		field := resp.Frames[i].Fields[1]
+		if field.Config.DisplayName != e.name || field.Labels[tagGroupLabel] != e.name {
+			t.Errorf("Expected series %s, got %q %v", e.name, field.Config.DisplayName, field.Labels)
+		}
+		if field.Len() != 1 || field.At(0).(float64) != e.value {
+			t.Errorf("Expected %s = %v, got %v", e.name, e.value, field.At(0))
+		}
+	}
+
+	// Sensors without the channel are skipped with a notice
+	resp = request(`{"queryType":"tagGroups","groupTag":"env:","channel":"Memory"}`)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	notices := resp.Frames[0].Meta.Notices
+	if len(notices) != 1 || !strings.Contains(notices[0].Text, "1, 2, 3") {
+		t.Errorf("Expected a notice listing the skipped sensors, got %+v", notices)
+	}
+
+	if resp := request(`{"queryType":"tagGroups","channel":"CPU Load"}`); resp.Error == nil {
+		t.Errorf("Expected an error without groupTag")
+	}
+}
@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Comparators of the value threshold query.
+const (
+	comparatorGreater      = ">"
+	comparatorGreaterEqual = ">="
+	comparatorLess         = "<"
+	comparatorLessEqual    = "<="
+	comparatorEqual        = "="
+	comparatorNotEqual     = "!="
+)
+
+// parseComparator validates the comparator of a threshold query, an empty value means ">".
+func parseComparator(comparator string) (string, error) {
+	comparator = strings.TrimSpace(comparator)
+	switch comparator {
+	case "":
+		return comparatorGreater, nil
+	case "==":
+		return comparatorEqual, nil
+	case comparatorGreater, comparatorGreaterEqual, comparatorLess, comparatorLessEqual, comparatorEqual, comparatorNotEqual:
+		return comparator, nil
+	}
+	return "", fmt.Errorf("invalid comparator %q", comparator)
+}
+
+// compareThreshold reports whether value crosses the threshold.
+func compareThreshold(value float64, comparator string, threshold float64) bool {
+	switch comparator {
+	case comparatorGreater:
+		return value > threshold
+	case comparatorGreaterEqual:
+		return value >= threshold
+	case comparatorLess:
+		return value < threshold
+	case comparatorLessEqual:
+		return value <= threshold
+	case comparatorEqual:
+		return value == threshold
+	case comparatorNotEqual:
+		return value != threshold
+	}
+	return false
+}
+
+// sensorLastValue returns the numeric last value of a sensor. PRTG's lastvalue_raw is used
+// when it is a number, otherwise the leading number of the formatted lastvalue ("12 %",
+// "1.234 msec", "0,5 MByte") is parsed. Note that the formatted value is in the display
+// unit of the channel while the raw value is in its base unit.
+func sensorLastValue(sensor PrtgSensorListItemStruct) (float64, bool) {
+	switch raw := sensor.LastValueRAW.(type) {
+	case float64:
+		return raw, true
+	case string:
+		if value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+			return value, true
+		}
+	}
+	return parseFormattedValue(cleanMessageHTML(sensor.LastValue))
+}
+
+// sensorsCrossingThreshold filters the sensors by their last value. Sensors without a
+// numeric value are skipped and counted as the last return value.
+func sensorsCrossingThreshold(sensors []PrtgSensorListItemStruct, comparator string, threshold float64) ([]PrtgSensorListItemStruct, []float64, int) {
+	matches := make([]PrtgSensorListItemStruct, 0)
+	values := make([]float64, 0)
+	skipped := 0
+	for _, sensor := range sensors {
+		value, ok := sensorLastValue(sensor)
+		if !ok {
+			skipped++
+			continue
+		}
+		if compareThreshold(value, comparator, threshold) {
+			matches = append(matches, sensor)
+			values = append(values, value)
+		}
+	}
+	return matches, values, skipped
+}
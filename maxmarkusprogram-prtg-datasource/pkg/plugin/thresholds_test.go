@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ sensorLastValue test: Raw and formatted values with mixed units
+func TestSensorLastValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		sensor   PrtgSensorListItemStruct
+		expected float64
+		ok       bool
+	}{
+		{"Raw number", PrtgSensorListItemStruct{LastValue: "45 %", LastValueRAW: 45.5}, 45.5, true},
+		{"Raw string", PrtgSensorListItemStruct{LastValue: "1 GByte", LastValueRAW: "1073741824"}, 1073741824, true},
+		{"Percent", PrtgSensorListItemStruct{LastValue: "87 %", LastValueRAW: ""}, 87, true},
+		{"Milliseconds", PrtgSensorListItemStruct{LastValue: "12 msec"}, 12, true},
+		{"Decimal comma", PrtgSensorListItemStruct{LastValue: "0,5 MByte"}, 0.5, true},
+		{"Thousands separator", PrtgSensorListItemStruct{LastValue: "1.234,5 kbit/s"}, 1234.5, true},
+		{"Negative", PrtgSensorListItemStruct{LastValue: "-3 °C"}, -3, true},
+		{"No data", PrtgSensorListItemStruct{LastValue: "No data"}, 0, false},
+		{"Empty", PrtgSensorListItemStruct{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := sensorLastValue(tt.sensor)
+			if value != tt.expected || ok != tt.ok {
+				t.Errorf("Expected %v, %v; got %v, %v", tt.expected, tt.ok, value, ok)
+			}
+		})
+	}
+}
+
+// ✅ compareThreshold and parseComparator test
+func TestCompareThreshold(t *testing.T) {
+	tests := []struct {
+		comparator string
+		value      float64
+		expected   bool
+	}{
+		{"", 90, true},
+		{">", 80, false},
+		{">=", 80, true},
+		{"<", 70, true},
+		{"<=", 80, true},
+		{"=", 80, true},
+		{"==", 80, true},
+		{"!=", 80, false},
+	}
+
+	for _, tt := range tests {
+		comparator, err := parseComparator(tt.comparator)
+		if err != nil {
+			t.Fatalf("parseComparator(%q) failed: %v", tt.comparator, err)
+		}
+		if got := compareThreshold(tt.value, comparator, 80); got != tt.expected {
+			t.Errorf("%v %s 80 = %v, expected %v", tt.value, comparator, got, tt.expected)
+		}
+	}
+
+	if _, err := parseComparator("~"); err == nil {
+		t.Errorf("Expected an error for an invalid comparator")
+	}
+}
+
+// ✅ Value threshold query: Sensors above the threshold with mixed units
+func TestQueryData_ValueThreshold(t *testing.T) {
+	mockResponse := `{"sensors": [
+		{"objid": 1001, "sensor": "CPU Load", "device": "Server", "lastvalue": "92 %", "lastvalue_raw": 92},
+		{"objid": 1002, "sensor": "Ping", "device": "Router", "lastvalue": "120 msec", "lastvalue_raw": ""},
+		{"objid": 1003, "sensor": "Disk Free", "device": "Server", "lastvalue": "40 %", "lastvalue_raw": 40},
+		{"objid": 1004, "sensor": "HTTP", "device": "Web", "lastvalue": "No data", "lastvalue_raw": ""}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"valueThreshold","comparator":">=","threshold":90}`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 2 {
+		t.Fatalf("Expected 2 sensors, got %d", rows)
+	}
+	if frame.Fields[1].At(0) != "CPU Load" || frame.Fields[1].At(1) != "Ping" || frame.Fields[3].At(1) != float64(120) {
+		t.Errorf("Unexpected sensors: %v=%v, %v=%v", frame.Fields[1].At(0), frame.Fields[3].At(0), frame.Fields[1].At(1), frame.Fields[3].At(1))
+	}
+
+	// A missing threshold is rejected
+	resp = ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"valueThreshold"}`),
+	})
+	if resp.Error == nil {
+		t.Errorf("Expected an error for a missing threshold")
+	}
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Timestamp timezone
+//
+// PRTG delivers most timestamps as local server time without zone ("15.02.2025 13:00:00").
+// The effective timezone used to interpret them is, in order of preference:
+//   - the timezone configured in the datasource settings,
+//   - the UTC offset of the PRTG server, derived from the clock in status.json and kept once known,
+//   - the local timezone of the Grafana server.
+//
+// Timestamps that carry their own offset (RFC 3339) are not affected. The effective
+// timezone is reported in the frame metadata of metrics queries.
+const (
+	timezoneSourceConfigured = "configured"
+	timezoneSourcePRTG       = "prtg"
+	timezoneSourceLocal      = "local"
+)
+
+// serverTimezoneRetryDelay is the time after a failed PRTG clock lookup before it is tried again.
+const serverTimezoneRetryDelay = time.Minute
+
+// clockLayouts are the formats PRTG uses for the clock in status.json.
+var clockLayouts = []string{
+	"02.01.2006 15:04:05",
+	"1/2/2006 3:04:05 PM",
+	"2006-01-02 15:04:05",
+}
+
+// prtgClockLocation derives the server's UTC offset from the local clock string and the
+// JavaScript clock (milliseconds since the epoch) of a status response.
+func prtgClockLocation(status *PrtgStatusListResponse) (*time.Location, error) {
+	if status == nil || status.JsClock <= 0 || status.Clock == "" {
+		return nil, fmt.Errorf("status response contains no clock")
+	}
+
+	for _, layout := range clockLayouts {
+		local, err := time.Parse(layout, status.Clock)
+		if err != nil {
+			continue
+		}
+		// Round to quarter hours, the two clocks are not read at the same instant
+		offset := local.Sub(time.UnixMilli(status.JsClock)).Round(15 * time.Minute)
+		return fixedZone(offset), nil
+	}
+	return nil, fmt.Errorf("unknown clock format %q", status.Clock)
+}
+
+// fixedZone returns a zone with the given offset named like "UTC+01:00".
+func fixedZone(offset time.Duration) *time.Location {
+	if offset == 0 {
+		return time.UTC
+	}
+	sign := "+"
+	abs := offset
+	if offset < 0 {
+		sign = "-"
+		abs = -offset
+	}
+	name := fmt.Sprintf("UTC%s%02d:%02d", sign, int(abs.Hours()), int(abs.Minutes())%60)
+	return time.FixedZone(name, int(offset.Seconds()))
+}
+
+// effectiveTimezone returns the timezone used to interpret PRTG timestamps and its source.
+// The timezone derived from the PRTG clock is kept for the life of the datasource instance.
+// A failed lookup falls back to the local timezone and is retried after
+// serverTimezoneRetryDelay; a lookup aborted by the caller's context is retried right away.
+func (d *Datasource) effectiveTimezone(ctx context.Context) (*time.Location, string) {
+	if d.timezone != nil {
+		return d.timezone, timezoneSourceConfigured
+	}
+
+	d.serverTimezoneMu.Lock()
+	defer d.serverTimezoneMu.Unlock()
+
+	if d.serverTimezone == nil && !time.Now().Before(d.serverTimezoneRetry) {
+		loc, err := d.serverClockLocation(ctx)
+		switch {
+		case err == nil:
+			d.serverTimezone = loc
+		case ctx.Err() != nil:
+			backend.Logger.Debug("PRTG clock lookup aborted, using the local timezone", "error", err)
+		default:
+			backend.Logger.Warn("Could not derive the PRTG timezone, using the local timezone",
+				"retryIn", serverTimezoneRetryDelay, "error", err)
+			d.serverTimezoneRetry = time.Now().Add(serverTimezoneRetryDelay)
+		}
+	}
+
+	if d.serverTimezone != nil {
+		return d.serverTimezone, timezoneSourcePRTG
+	}
+	return time.Local, timezoneSourceLocal
+}
+
+// serverClockLocation reads the PRTG clock and derives the server timezone from it.
+func (d *Datasource) serverClockLocation(ctx context.Context) (*time.Location, error) {
+	status, err := d.api.GetStatusList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the PRTG clock: %w", err)
+	}
+	return prtgClockLocation(status)
+}
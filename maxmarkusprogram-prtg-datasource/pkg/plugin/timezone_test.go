@@ -0,0 +1,188 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ prtgClockLocation test: Offset derived from the PRTG clock
+func TestPrtgClockLocation(t *testing.T) {
+	utc := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		clock    string
+		expected string
+	}{
+		{"15.02.2025 13:00:02", "UTC+01:00"},
+		{"2/15/2025 6:29:58 AM", "UTC-05:30"},
+		{"2025-02-15 12:00:00", "UTC"},
+	}
+
+	for _, tt := range tests {
+		loc, err := prtgClockLocation(&PrtgStatusListResponse{Clock: tt.clock, JsClock: utc.UnixMilli()})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.clock, err)
+		}
+		if loc.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.clock, tt.expected, loc)
+		}
+	}
+
+	if _, err := prtgClockLocation(&PrtgStatusListResponse{Clock: "noon", JsClock: utc.UnixMilli()}); err == nil {
+		t.Errorf("Expected an error for an unknown clock format")
+	}
+}
+
+// ✅ Metrics frame metadata carries the effective timezone
+func TestQueryData_TimezoneMetadata(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "15.02.2025 13:00:00", "Total": 5}]}`)
+	})
+	mux.HandleFunc("/api/status.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"clock": "15.02.2025 13:00:00", "jsclock": %d}`, time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC).UnixMilli())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+
+	query := backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Total"}`),
+		TimeRange: histdataTimeRange,
+	}
+
+	tests := []struct {
+		name           string
+		ds             *Datasource
+		expectedZone   string
+		expectedSource string
+	}{
+		{"Configured", &Datasource{timezone: berlin}, "Europe/Berlin", "configured"},
+		{"Derived from PRTG", &Datasource{}, "UTC+01:00", "prtg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ds.api = NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+			resp := tt.ds.query(context.Background(), backend.PluginContext{}, query)
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			frame := resp.Frames[0]
+			custom := frame.Meta.Custom.(map[string]interface{})
+			if custom["timezone"] != tt.expectedZone || custom["timezoneSource"] != tt.expectedSource {
+				t.Errorf("Expected %s/%s, got %v/%v", tt.expectedZone, tt.expectedSource, custom["timezone"], custom["timezoneSource"])
+			}
+			// 13:00 server time is 12:00 UTC in both cases
+			if ts := frame.Fields[0].At(0).(time.Time); !ts.Equal(time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("Expected 12:00 UTC, got %v", ts.UTC())
+			}
+		})
+	}
+}
+
+// ✅ parsePRTGDateTimeIn test: Local server times across the DST changes
+func TestParsePRTGDateTimeIn_DST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+
+	tests := []struct {
+		datetime string
+		expected time.Time
+	}{
+		{"30.03.2025 01:30:00", time.Date(2025, 3, 30, 0, 30, 0, 0, time.UTC)},
+		{"30.03.2025 03:30:00", time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC)},
+		{"26.10.2025 01:30:00", time.Date(2025, 10, 25, 23, 30, 0, 0, time.UTC)},
+		{"26.10.2025 03:30:00", time.Date(2025, 10, 26, 2, 30, 0, 0, time.UTC)},
+		{"2025-03-30T03:30:00Z", time.Date(2025, 3, 30, 3, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		parsed, _, err := parsePRTGDateTimeIn(tt.datetime, berlin)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.datetime, err)
+		}
+		if !parsed.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.datetime, tt.expected, parsed.UTC())
+		}
+	}
+
+	// The status log is read in the server timezone, so the hour between both entries stays an hour
+	times, _ := statusTransitions([]PrtgMessageListItemStruct{
+		{Datetime: "30.03.2025 03:30:00", StatusRAW: 5, Status: "Down"},
+		{Datetime: "30.03.2025 01:30:00", StatusRAW: 3, Status: "Up"},
+	}, berlin)
+	if len(times) != 1 || !times[0].Equal(time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected one transition at 01:30 UTC, got %v", times)
+	}
+}
+
+// ✅ effectiveTimezone test: Without configuration and PRTG clock the local timezone is used
+func TestEffectiveTimezone_LocalFallback(t *testing.T) {
+	server, api := setupMockAPI(`{}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	loc, source := ds.effectiveTimezone(context.Background())
+	if loc != time.Local || source != timezoneSourceLocal {
+		t.Errorf("Expected the local timezone, got %v/%s", loc, source)
+	}
+}
+
+// ✅ effectiveTimezone test: A failed clock lookup is retried after the delay, a success is kept
+func TestEffectiveTimezone_Retry(t *testing.T) {
+	var requests int
+	available := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status.json", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !available {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"clock": "15.02.2025 13:00:00", "jsclock": %d}`, time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC).UnixMilli())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second)}
+
+	// A cancelled context does not start the backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, source := ds.effectiveTimezone(ctx); source != timezoneSourceLocal {
+		t.Errorf("Expected the local timezone, got %s", source)
+	}
+	if !ds.serverTimezoneRetry.IsZero() {
+		t.Errorf("Expected no backoff after a cancelled lookup")
+	}
+
+	if _, source := ds.effectiveTimezone(context.Background()); source != timezoneSourceLocal {
+		t.Errorf("Expected the local timezone, got %s", source)
+	}
+	calls := requests
+	available = true
+	if _, source := ds.effectiveTimezone(context.Background()); source != timezoneSourceLocal || requests != calls {
+		t.Errorf("Expected no lookup before the retry delay, got %s after %d requests", source, requests-calls)
+	}
+
+	ds.serverTimezoneRetry = time.Now().Add(-time.Second)
+	if _, source := ds.effectiveTimezone(context.Background()); source != timezoneSourcePRTG {
+		t.Errorf("Expected the PRTG timezone after the retry delay, got %s", source)
+	}
+	calls = requests
+	if _, source := ds.effectiveTimezone(context.Background()); source != timezoneSourcePRTG || requests != calls {
+		t.Errorf("Expected the PRTG timezone to be kept without another lookup")
+	}
+}
@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+)
+
+// sensorTree returns groups, devices and sensors as a nested tree. The lists come from the
+// tree cache, so the tree route shares them with the list routes.
+func (d *Datasource) sensorTree(ctx context.Context) (*PrtgObjectTree, error) {
+	groups, err := cachedTree(ctx, d.treeCache, "groups", d.api.GetGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch groups: %w", err)
+	}
+	devices, err := cachedTree(ctx, d.treeCache, "devices", d.api.GetDevices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch devices: %w", err)
+	}
+	sensors, err := cachedTree(ctx, d.treeCache, "sensors", d.api.GetSensors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sensors: %w", err)
+	}
+	return buildObjectTree(groups.Groups, devices.Devices, sensors.Sensors), nil
+}
+
+// buildObjectTree nests the devices into their groups and the sensors into their devices.
+// PRTG lists only carry the names of the parent group and device, so children are attached
+// to the first parent with a matching name, in list order. Devices and sensors whose parent is
+// not listed, e.g. because of a filter or truncation, are kept under a parent with objid 0.
+func buildObjectTree(groups []PrtgGroupListItemStruct, devices []PrtgDeviceListItemStruct, sensors []PrtgSensorListItemStruct) *PrtgObjectTree {
+	type deviceKey struct {
+		group, device string
+	}
+
+	tree := &PrtgObjectTree{Groups: make([]PrtgTreeGroup, 0, len(groups))}
+	groupIndex := make(map[string]int, len(groups))
+	for _, g := range groups {
+		if _, ok := groupIndex[g.Group]; !ok {
+			groupIndex[g.Group] = len(tree.Groups)
+		}
+		tree.Groups = append(tree.Groups, PrtgTreeGroup{ObjectId: g.ObjectId, Name: g.Group, Devices: []PrtgTreeDevice{}})
+	}
+
+	group := func(name string) int {
+		i, ok := groupIndex[name]
+		if !ok {
+			i = len(tree.Groups)
+			groupIndex[name] = i
+			tree.Groups = append(tree.Groups, PrtgTreeGroup{Name: name, Devices: []PrtgTreeDevice{}})
+		}
+		return i
+	}
+
+	// Devices are referenced by index, pointers would not survive appends to the slices
+	deviceIndex := make(map[deviceKey][2]int, len(devices))
+	for _, dev := range devices {
+		gi := group(dev.Group)
+		key := deviceKey{dev.Group, dev.Device}
+		if _, ok := deviceIndex[key]; !ok {
+			deviceIndex[key] = [2]int{gi, len(tree.Groups[gi].Devices)}
+		}
+		tree.Groups[gi].Devices = append(tree.Groups[gi].Devices, PrtgTreeDevice{ObjectId: dev.ObjectId, Name: dev.Device, Sensors: []PrtgTreeSensor{}})
+	}
+
+	for _, s := range sensors {
+		key := deviceKey{s.Group, s.Device}
+		position, ok := deviceIndex[key]
+		if !ok {
+			gi := group(s.Group)
+			position = [2]int{gi, len(tree.Groups[gi].Devices)}
+			deviceIndex[key] = position
+			tree.Groups[gi].Devices = append(tree.Groups[gi].Devices, PrtgTreeDevice{Name: s.Device, Sensors: []PrtgTreeSensor{}})
+		}
+		device := &tree.Groups[position[0]].Devices[position[1]]
+		device.Sensors = append(device.Sensors, PrtgTreeSensor{ObjectId: s.ObjectId, Name: s.Sensor, Status: s.Status})
+	}
+	return tree
+}
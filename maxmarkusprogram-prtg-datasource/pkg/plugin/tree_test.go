@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ buildObjectTree test: Devices and sensors are nested by the names of their parents
+func TestBuildObjectTree(t *testing.T) {
+	groups := []PrtgGroupListItemStruct{
+		{ObjectId: 100, Group: "Berlin"},
+		{ObjectId: 200, Group: "Munich"},
+	}
+	devices := []PrtgDeviceListItemStruct{
+		{ObjectId: 1001, Group: "Berlin", Device: "Router"},
+		{ObjectId: 2001, Group: "Munich", Device: "Router"},
+		{ObjectId: 1002, Group: "Berlin", Device: "Switch"},
+	}
+	sensors := []PrtgSensorListItemStruct{
+		{ObjectId: 5001, Group: "Berlin", Device: "Router", Sensor: "Ping", Status: "Up"},
+		{ObjectId: 5002, Group: "Munich", Device: "Router", Sensor: "Ping", Status: "Down"},
+		{ObjectId: 5003, Group: "Berlin", Device: "Router", Sensor: "Traffic", Status: "Up"},
+		{ObjectId: 5004, Group: "Hamburg", Device: "Firewall", Sensor: "CPU", Status: "Up"},
+	}
+
+	tree := buildObjectTree(groups, devices, sensors)
+	if len(tree.Groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", tree.Groups)
+	}
+
+	berlin := tree.Groups[0]
+	if berlin.ObjectId != 100 || len(berlin.Devices) != 2 || berlin.Devices[1].Name != "Switch" || len(berlin.Devices[1].Sensors) != 0 {
+		t.Errorf("Unexpected Berlin group: %+v", berlin)
+	}
+	router := berlin.Devices[0]
+	if router.ObjectId != 1001 || len(router.Sensors) != 2 || router.Sensors[1].ObjectId != 5003 {
+		t.Errorf("Unexpected Berlin router: %+v", router)
+	}
+	if munich := tree.Groups[1]; len(munich.Devices) != 1 || munich.Devices[0].Sensors[0].Status != "Down" {
+		t.Errorf("Unexpected Munich group: %+v", munich)
+	}
+
+	// The parents of a sensor that are not listed are added without objid
+	hamburg := tree.Groups[2]
+	if hamburg.ObjectId != 0 || hamburg.Name != "Hamburg" || len(hamburg.Devices) != 1 ||
+		hamburg.Devices[0].ObjectId != 0 || hamburg.Devices[0].Sensors[0].ObjectId != 5004 {
+		t.Errorf("Unexpected Hamburg group: %+v", hamburg)
+	}
+}
+
+// ✅ CallResource test: Object tree as nested JSON
+func TestCallResourceTree(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("content") {
+		case "groups":
+			fmt.Fprint(w, `{"groups": [{"objid": 100, "group": "Berlin"}]}`)
+		case "devices":
+			fmt.Fprint(w, `{"devices": [{"objid": 1001, "group": "Berlin", "device": "Router"}]}`)
+		case "sensors":
+			fmt.Fprint(w, `{"sensors": [{"objid": 5001, "group": "Berlin", "device": "Router", "sensor": "Ping", "status": "Up"}]}`)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), treeCache: newTTLCache(defaultTreeCacheTime)}
+	respSender := &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "tree"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d %s", respSender.status, respSender.body)
+	}
+
+	var tree PrtgObjectTree
+	if err := json.Unmarshal(respSender.body, &tree); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(tree.Groups) != 1 || len(tree.Groups[0].Devices) != 1 || len(tree.Groups[0].Devices[0].Sensors) != 1 ||
+		tree.Groups[0].Devices[0].Sensors[0].ObjectId != 5001 {
+		t.Errorf("Unexpected tree: %s", respSender.body)
+	}
+
+	// The tree shares the tree cache with the list routes
+	requests = 0
+	for _, path := range []string{"tree", "groups", "sensors"} {
+		if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: path}, &mockResourceResponseSender{}); err != nil {
+			t.Fatalf("CallResource failed: %v", err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("Expected the cached lists to be used, got %d requests", requests)
+	}
+
+	// A failing list is reported as error
+	ds.treeCache.clear()
+	server.Close()
+	respSender = &mockResourceResponseSender{}
+	if err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: "tree"}, respSender); err != nil {
+		t.Fatalf("CallResource failed: %v", err)
+	}
+	if respSender.status != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", respSender.status)
+	}
+}
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// defaultTruncationWarnInterval is the minimum time between two truncation warnings per content type.
+const defaultTruncationWarnInterval = time.Hour
+
+// truncationWarner logs when a list request returns fewer objects than PRTG reports in the
+// tree. The warning is rate limited per content type, lists are fetched on every refresh.
+type truncationWarner struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+	now      func() time.Time
+}
+
+func newTruncationWarner(interval time.Duration) *truncationWarner {
+	if interval <= 0 {
+		interval = defaultTruncationWarnInterval
+	}
+	return &truncationWarner{
+		interval: interval,
+		last:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// check logs a warning if the list of the given content type was truncated and no warning
+// was logged within the interval. It reports whether a warning was logged.
+func (w *truncationWarner) check(content string, fetched int, treeSize int64) bool {
+	if w == nil || treeSize <= int64(fetched) {
+		return false
+	}
+
+	w.mu.Lock()
+	now := w.now()
+	if last, ok := w.last[content]; ok && now.Sub(last) < w.interval {
+		w.mu.Unlock()
+		return false
+	}
+	w.last[content] = now
+	w.mu.Unlock()
+
+	backend.Logger.Warn("PRTG list truncated, not all objects are available",
+		"content", content,
+		"fetched", fetched,
+		"treeSize", treeSize,
+		"limit", maxTableObjects,
+		"hint", "the plugin pages at most limit objects per list, narrow the query with filters or tags")
+	return true
+}
@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// recordingLogger records the messages logged at Warn level.
+type recordingLogger struct {
+	log.Logger
+	mu    sync.Mutex
+	warns []string
+}
+
+func (l *recordingLogger) Warn(msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, msg)
+}
+
+func (l *recordingLogger) FromContext(ctx context.Context) log.Logger {
+	return l
+}
+
+// ✅ Truncated lists are logged once per interval
+func TestTruncationWarning(t *testing.T) {
+	logger := &recordingLogger{Logger: backend.Logger}
+	original := backend.Logger
+	backend.Logger = logger
+	defer func() { backend.Logger = original }()
+
+	server, api := setupMockServer(`{"treesize": 3, "devices": [{"device": "Router"}, {"device": "Switch"}]}`, http.StatusOK)
+	defer server.Close()
+
+	now := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+	api.truncation.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.GetDevices(context.Background(), listFilter{}); err != nil {
+			t.Fatalf("GetDevices() failed: %v", err)
+		}
+	}
+	if len(logger.warns) != 1 {
+		t.Fatalf("Expected 1 truncation warning, got %d: %v", len(logger.warns), logger.warns)
+	}
+
+	// After the interval the warning is logged again
+	now = now.Add(defaultTruncationWarnInterval)
+	if _, err := api.GetDevices(context.Background(), listFilter{}); err != nil {
+		t.Fatalf("GetDevices() failed: %v", err)
+	}
+	if len(logger.warns) != 2 {
+		t.Errorf("Expected 2 truncation warnings, got %d", len(logger.warns))
+	}
+}
+
+// ✅ Complete lists are not logged
+func TestTruncationWarnerComplete(t *testing.T) {
+	w := newTruncationWarner(time.Minute)
+	if w.check("sensors", 2, 2) {
+		t.Errorf("Expected no warning for a complete list")
+	}
+	if !w.check("sensors", 2, 5) {
+		t.Errorf("Expected a warning for a truncated list")
+	}
+	if !w.check("groups", 1, 5) {
+		t.Errorf("Expected the interval to be tracked per content type")
+	}
+
+	var nilWarner *truncationWarner
+	if nilWarner.check("sensors", 0, 5) {
+		t.Errorf("Expected a nil warner to do nothing")
+	}
+}
@@ -2,12 +2,50 @@ package plugin
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// PrtgVersion is the PRTG version reported in a response. Depending on the PRTG version it is
+// delivered as a string or as an array, see UnmarshalJSON.
+type PrtgVersion string
+
+// UnmarshalJSON accepts a string, a number, null or an array of these. The non-empty elements
+// of an array are joined with ", ", PRTG clusters report one version per node this way.
+func (v *PrtgVersion) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		items = []interface{}{raw}
+	}
+	var parts []string
+	for _, item := range items {
+		switch value := item.(type) {
+		case nil:
+		case string:
+			if value = strings.TrimSpace(value); value != "" {
+				parts = append(parts, value)
+			}
+		case float64:
+			parts = append(parts, strconv.FormatFloat(value, 'f', -1, 64))
+		default:
+			return fmt.Errorf("unexpected prtg-version value %v", item)
+		}
+	}
+	*v = PrtgVersion(strings.Join(parts, ", "))
+	return nil
+}
+
 // PrtgTableListResponse represents the response from PRTG Table List API.
-// Note: Check if "prtg-version" is delivered as array or string.
 type PrtgTableListResponse struct {
-	PrtgVersion []PrtgStatusListResponse   `json:"prtg-version" xml:"prtg-version"`
+	PrtgVersion PrtgVersion                `json:"prtg-version" xml:"prtg-version"`
 	TreeSize    int64                      `json:"treesize" xml:"treesize"`
 	Groups      []PrtgGroupListResponse    `json:"groups,omitempty" xml:"groups,omitempty"`
 	Devices     []PrtgDevicesListResponse  `json:"devices,omitempty" xml:"devices,omitempty"`
@@ -19,9 +57,9 @@ type PrtgTableListResponse struct {
 
 // PrtgGroupListResponse represents the response for groups.
 type PrtgGroupListResponse struct {
-	PrtgVersion string                    `json:"prtg-version" xml:"prtg-version"`
-	TreeSize    int64                     `json:"treesize" xml:"treesize"`
-	Groups      []PrtgGroupListItemStruct `json:"groups" xml:"groups"`
+	PrtgVersion PrtgVersion                         `json:"prtg-version" xml:"prtg-version"`
+	TreeSize    int64                               `json:"treesize" xml:"treesize"`
+	Groups      objectList[PrtgGroupListItemStruct] `json:"groups" xml:"groups"`
 }
 
 // PrtgGroupListItemStruct contains details for a single group.
@@ -38,12 +76,15 @@ type PrtgGroupListItemStruct struct {
 	DownsensRAW    int     `json:"downsens_raw" xml:"downsens_raw"`
 	Group          string  `json:"group" xml:"group"`
 	GroupRAW       string  `json:"group_raw" xml:"group_raw"`
+	Icon           string  `json:"icon" xml:"icon"`
 	Message        string  `json:"message" xml:"message"`
 	MessageRAW     string  `json:"message_raw" xml:"message_raw"`
 	ObjectId       int64   `json:"objid" xml:"objid"`
 	ObjectIdRAW    int64   `json:"objid_raw" xml:"objid_raw"`
+	ParentId       int64   `json:"parentid" xml:"parentid"`
 	Pausedsens     string  `json:"pausedsens" xml:"pausedsens"`
 	PausedsensRAW  int     `json:"pausedsens_raw" xml:"pausedsens_raw"`
+	PositionRAW    int64   `json:"position_raw" xml:"position_raw"`
 	Priority       string  `json:"priority" xml:"priority"`
 	PriorityRAW    int     `json:"priority_raw" xml:"priority_raw"`
 	Sensor         string  `json:"sensor" xml:"sensor"`
@@ -52,6 +93,8 @@ type PrtgGroupListItemStruct struct {
 	StatusRAW      int     `json:"status_raw" xml:"status_raw"`
 	Tags           string  `json:"tags" xml:"tags"`
 	TagsRAW        string  `json:"tags_raw" xml:"tags_raw"`
+	Type           string  `json:"type" xml:"type"`
+	TypeRAW        string  `json:"type_raw" xml:"type_raw"`
 	Totalsens      string  `json:"totalsens" xml:"totalsens"`
 	TotalsensRAW   int     `json:"totalsens_raw" xml:"totalsens_raw"`
 	Unusualsens    string  `json:"unusualsens" xml:"unusualsens"`
@@ -66,9 +109,9 @@ type PrtgGroupListItemStruct struct {
 
 // PrtgDevicesListResponse represents the response for devices.
 type PrtgDevicesListResponse struct {
-	PrtgVersion string                     `json:"prtg-version" xml:"prtg-version"`
-	TreeSize    int64                      `json:"treesize" xml:"treesize"`
-	Devices     []PrtgDeviceListItemStruct `json:"devices" xml:"devices"`
+	PrtgVersion PrtgVersion                          `json:"prtg-version" xml:"prtg-version"`
+	TreeSize    int64                                `json:"treesize" xml:"treesize"`
+	Devices     objectList[PrtgDeviceListItemStruct] `json:"devices" xml:"devices"`
 }
 
 // PrtgDeviceListItemStruct contains details for a single device.
@@ -85,12 +128,15 @@ type PrtgDeviceListItemStruct struct {
 	DownsensRAW    int     `json:"downsens_raw" xml:"downsens_raw"`
 	Group          string  `json:"group" xml:"group"`
 	GroupRAW       string  `json:"group_raw" xml:"group_raw"`
+	Icon           string  `json:"icon" xml:"icon"`
 	Message        string  `json:"message" xml:"message"`
 	MessageRAW     string  `json:"message_raw" xml:"message_raw"`
 	ObjectId       int64   `json:"objid" xml:"objid"`
 	ObjectIdRAW    int64   `json:"objid_raw" xml:"objid_raw"`
+	ParentId       int64   `json:"parentid" xml:"parentid"`
 	Pausedsens     string  `json:"pausedsens" xml:"pausedsens"`
 	PausedsensRAW  int     `json:"pausedsens_raw" xml:"pausedsens_raw"`
+	PositionRAW    int64   `json:"position_raw" xml:"position_raw"`
 	Priority       string  `json:"priority" xml:"priority"`
 	PriorityRAW    int     `json:"priority_raw" xml:"priority_raw"`
 	Sensor         string  `json:"sensor" xml:"sensor"`
@@ -99,6 +145,8 @@ type PrtgDeviceListItemStruct struct {
 	StatusRAW      int     `json:"status_raw" xml:"status_raw"`
 	Tags           string  `json:"tags" xml:"tags"`
 	TagsRAW        string  `json:"tags_raw" xml:"tags_raw"`
+	Type           string  `json:"type" xml:"type"`
+	TypeRAW        string  `json:"type_raw" xml:"type_raw"`
 	Totalsens      string  `json:"totalsens" xml:"totalsens"`
 	TotalsensRAW   int     `json:"totalsens_raw" xml:"totalsens_raw"`
 	Unusualsens    string  `json:"unusualsens" xml:"unusualsens"`
@@ -113,93 +161,216 @@ type PrtgDeviceListItemStruct struct {
 
 // PrtgSensorsListResponse represents the response for sensors.
 type PrtgSensorsListResponse struct {
-	PrtgVersion string                     `json:"prtg-version" xml:"prtg-version"`
-	TreeSize    int64                      `json:"treesize" xml:"treesize"`
-	Sensors     []PrtgSensorListItemStruct `json:"sensors" xml:"sensors"`
+	PrtgVersion PrtgVersion                          `json:"prtg-version" xml:"prtg-version"`
+	TreeSize    int64                                `json:"treesize" xml:"treesize"`
+	Sensors     objectList[PrtgSensorListItemStruct] `json:"sensors" xml:"sensors"`
+	// SinceToken is set by the plugin for incremental list requests, see changedSince.
+	SinceToken string `json:"sinceToken,omitempty" xml:"-"`
 }
 
 // PrtgSensorListItemStruct contains details for a single sensor.
 type PrtgSensorListItemStruct struct {
-	Active         bool    `json:"active" xml:"active"`
-	ActiveRAW      int     `json:"active_raw" xml:"active_raw"`
-	Channel        string  `json:"channel" xml:"channel"`
-	ChannelRAW     int     `json:"channel_raw" xml:"channel_raw"`
-	Datetime       string  `json:"datetime" xml:"datetime"`
-	DatetimeRAW    float64 `json:"datetime_raw" xml:"datetime_raw"`
-	Device         string  `json:"device" xml:"device"`
-	DeviceRAW      string  `json:"device_raw" xml:"device_raw"`
-	Downsens       string  `json:"downsens" xml:"downsens"`
-	DownsensRAW    int     `json:"downsens_raw" xml:"downsens_raw"`
-	Group          string  `json:"group" xml:"group"`
-	GroupRAW       string  `json:"group_raw" xml:"group_raw"`
-	Message        string  `json:"message" xml:"message"`
-	MessageRAW     string  `json:"message_raw" xml:"message_raw"`
-	ObjectId       int64   `json:"objid" xml:"objid"`
-	ObjectIdRAW    int64   `json:"objid_raw" xml:"objid_raw"`
-	Pausedsens     string  `json:"pausedsens" xml:"pausedsens"`
-	PausedsensRAW  int     `json:"pausedsens_raw" xml:"pausedsens_raw"`
-	Priority       string  `json:"priority" xml:"priority"`
-	PriorityRAW    int     `json:"priority_raw" xml:"priority_raw"`
-	Sensor         string  `json:"sensor" xml:"sensor"`
-	SensorRAW      string  `json:"sensor_raw" xml:"sensor_raw"`
-	Status         string  `json:"status" xml:"status"`
-	StatusRAW      int     `json:"status_raw" xml:"status_raw"`
-	Tags           string  `json:"tags" xml:"tags"`
-	TagsRAW        string  `json:"tags_raw" xml:"tags_raw"`
-	Totalsens      string  `json:"totalsens" xml:"totalsens"`
-	TotalsensRAW   int     `json:"totalsens_raw" xml:"totalsens_raw"`
-	Unusualsens    string  `json:"unusualsens" xml:"unusualsens"`
-	UnusualsensRAW int     `json:"unusualsens_raw" xml:"unusualsens_raw"`
-	Upsens         string  `json:"upsens" xml:"upsens"`
-	UpsensRAW      int     `json:"upsens_raw" xml:"upsens_raw"`
-	Warnsens       string  `json:"warnsens" xml:"warnsens"`
-	WarnsensRAW    int     `json:"warnsens_raw" xml:"warnsens_raw"`
+	Active         bool        `json:"active" xml:"active"`
+	ActiveRAW      int         `json:"active_raw" xml:"active_raw"`
+	Channel        string      `json:"channel" xml:"channel"`
+	ChannelRAW     int         `json:"channel_raw" xml:"channel_raw"`
+	Datetime       string      `json:"datetime" xml:"datetime"`
+	DatetimeRAW    float64     `json:"datetime_raw" xml:"datetime_raw"`
+	Device         string      `json:"device" xml:"device"`
+	DeviceRAW      string      `json:"device_raw" xml:"device_raw"`
+	Downsens       string      `json:"downsens" xml:"downsens"`
+	DownsensRAW    int         `json:"downsens_raw" xml:"downsens_raw"`
+	Group          string      `json:"group" xml:"group"`
+	GroupRAW       string      `json:"group_raw" xml:"group_raw"`
+	Icon           string      `json:"icon" xml:"icon"`
+	LastValue      string      `json:"lastvalue" xml:"lastvalue"`
+	LastValueRAW   interface{} `json:"lastvalue_raw" xml:"lastvalue_raw"`
+	Message        string      `json:"message" xml:"message"`
+	MessageRAW     string      `json:"message_raw" xml:"message_raw"`
+	Notifiesx      string      `json:"notifiesx" xml:"notifiesx"`
+	ObjectId       int64       `json:"objid" xml:"objid"`
+	ObjectIdRAW    int64       `json:"objid_raw" xml:"objid_raw"`
+	ParentId       int64       `json:"parentid" xml:"parentid"`
+	Pausedsens     string      `json:"pausedsens" xml:"pausedsens"`
+	PausedsensRAW  int         `json:"pausedsens_raw" xml:"pausedsens_raw"`
+	PositionRAW    int64       `json:"position_raw" xml:"position_raw"`
+	Priority       string      `json:"priority" xml:"priority"`
+	PriorityRAW    int         `json:"priority_raw" xml:"priority_raw"`
+	Sensor         string      `json:"sensor" xml:"sensor"`
+	SensorRAW      string      `json:"sensor_raw" xml:"sensor_raw"`
+	Status         string      `json:"status" xml:"status"`
+	StatusRAW      int         `json:"status_raw" xml:"status_raw"`
+	Tags           string      `json:"tags" xml:"tags"`
+	TagsRAW        string      `json:"tags_raw" xml:"tags_raw"`
+	Type           string      `json:"type" xml:"type"`
+	TypeRAW        string      `json:"type_raw" xml:"type_raw"`
+	Totalsens      string      `json:"totalsens" xml:"totalsens"`
+	TotalsensRAW   int         `json:"totalsens_raw" xml:"totalsens_raw"`
+	Unusualsens    string      `json:"unusualsens" xml:"unusualsens"`
+	UnusualsensRAW int         `json:"unusualsens_raw" xml:"unusualsens_raw"`
+	Upsens         string      `json:"upsens" xml:"upsens"`
+	UpsensRAW      int         `json:"upsens_raw" xml:"upsens_raw"`
+	Warnsens       string      `json:"warnsens" xml:"warnsens"`
+	WarnsensRAW    int         `json:"warnsens_raw" xml:"warnsens_raw"`
+}
+
+//############################# ALARM LIST RESPONSE ####################################
+
+// PrtgAlarmListResponse represents the response for the current alarms. PRTG delivers them
+// as sensor list filtered by the alarm states.
+type PrtgAlarmListResponse struct {
+	PrtgVersion PrtgVersion                          `json:"prtg-version" xml:"prtg-version"`
+	TreeSize    int64                                `json:"treesize" xml:"treesize"`
+	Alarms      objectList[PrtgSensorListItemStruct] `json:"sensors" xml:"sensors"`
+}
+
+//############################# OBJECT TREE ####################################
+
+// PrtgObjectTree is the group, device and sensor hierarchy built from the object lists, see tree.go.
+type PrtgObjectTree struct {
+	Groups []PrtgTreeGroup `json:"groups"`
+}
+
+// PrtgTreeGroup is a group of the object tree with its devices.
+type PrtgTreeGroup struct {
+	ObjectId int64            `json:"objid"`
+	Name     string           `json:"name"`
+	Devices  []PrtgTreeDevice `json:"devices"`
+}
+
+// PrtgTreeDevice is a device of the object tree with its sensors.
+type PrtgTreeDevice struct {
+	ObjectId int64            `json:"objid"`
+	Name     string           `json:"name"`
+	Sensors  []PrtgTreeSensor `json:"sensors"`
+}
+
+// PrtgTreeSensor is a sensor of the object tree.
+type PrtgTreeSensor struct {
+	ObjectId int64  `json:"objid"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
 }
 
 //############################# STATUS LIST RESPONSE ####################################
 
 // PrtgStatusListResponse contains system-wide status information.
 type PrtgStatusListResponse struct {
-	PrtgVersion          string `json:"prtgversion" xml:"prtg-version"`
-	AckAlarms            string `json:"ackalarms" xml:"ackalarms"`
-	Alarms               string `json:"alarms" xml:"alarms"`
-	AutoDiscoTasks       string `json:"autodiscotasks" xml:"autodiscotasks"`
-	BackgroundTasks      string `json:"backgroundtasks" xml:"backgroundtasks"`
-	Clock                string `json:"clock" xml:"clock"`
-	ClusterNodeName      string `json:"clusternodename" xml:"clusternodename"`
-	ClusterType          string `json:"clustertype" xml:"clustertype"`
-	CommercialExpiryDays int    `json:"commercialexpirydays" xml:"commercialexpirydays"`
-	CorrelationTasks     string `json:"correlationtasks" xml:"correlationtasks"`
-	DaysInstalled        int    `json:"daysinstalled" xml:"daysinstalled"`
-	EditionType          string `json:"editiontype" xml:"editiontype"`
-	Favs                 int    `json:"favs" xml:"favs"`
-	JsClock              int64  `json:"jsclock" xml:"jsclock"`
-	LowMem               bool   `json:"lowmem" xml:"lowmem"`
-	MaintExpiryDays      string `json:"maintexpirydays" xml:"maintexpirydays"`
-	MaxSensorCount       string `json:"maxsensorcount" xml:"maxsensorcount"`
-	NewAlarms            string `json:"newalarms" xml:"newalarms"`
-	NewMessages          string `json:"newmessages" xml:"newmessages"`
-	NewTickets           string `json:"newtickets" xml:"newtickets"`
-	Overloadprotection   bool   `json:"overloadprotection" xml:"overloadprotection"`
-	PartialAlarms        string `json:"partialalarms" xml:"partialalarms"`
-	PausedSens           string `json:"pausedsens" xml:"pausedsens"`
-	PRTGUpdateAvailable  bool   `json:"prtgupdateavailable" xml:"prtgupdateavailable"`
-	ReadOnlyUser         string `json:"readonlyuser" xml:"readonlyuser"`
-	ReportTasks          string `json:"reporttasks" xml:"reporttasks"`
-	TotalSens            int    `json:"totalsens"`
-	TrialExpiryDays      int    `json:"trialexpirydays"`
-	UnknownSens          string `json:"unknownsens"`
-	UnusualSens          string `json:"unusualsens"`
-	UpSens               string `json:"upsens"`
-	Version              string `json:"version"`
-	WarnSens             string `json:"warnsens"`
+	PrtgVersion          PrtgVersion `json:"prtgversion" xml:"prtg-version"`
+	AckAlarms            string      `json:"ackalarms" xml:"ackalarms"`
+	Alarms               string      `json:"alarms" xml:"alarms"`
+	AutoDiscoTasks       string      `json:"autodiscotasks" xml:"autodiscotasks"`
+	BackgroundTasks      string      `json:"backgroundtasks" xml:"backgroundtasks"`
+	Clock                string      `json:"clock" xml:"clock"`
+	ClusterNodeName      string      `json:"clusternodename" xml:"clusternodename"`
+	ClusterType          string      `json:"clustertype" xml:"clustertype"`
+	CommercialExpiryDays int         `json:"commercialexpirydays" xml:"commercialexpirydays"`
+	CorrelationTasks     string      `json:"correlationtasks" xml:"correlationtasks"`
+	DaysInstalled        int         `json:"daysinstalled" xml:"daysinstalled"`
+	EditionType          string      `json:"editiontype" xml:"editiontype"`
+	Favs                 int         `json:"favs" xml:"favs"`
+	JsClock              int64       `json:"jsclock" xml:"jsclock"`
+	LowMem               bool        `json:"lowmem" xml:"lowmem"`
+	MaintExpiryDays      string      `json:"maintexpirydays" xml:"maintexpirydays"`
+	MaxSensorCount       string      `json:"maxsensorcount" xml:"maxsensorcount"`
+	NewAlarms            string      `json:"newalarms" xml:"newalarms"`
+	NewMessages          string      `json:"newmessages" xml:"newmessages"`
+	NewTickets           string      `json:"newtickets" xml:"newtickets"`
+	Overloadprotection   bool        `json:"overloadprotection" xml:"overloadprotection"`
+	PartialAlarms        string      `json:"partialalarms" xml:"partialalarms"`
+	PausedSens           string      `json:"pausedsens" xml:"pausedsens"`
+	PRTGUpdateAvailable  bool        `json:"prtgupdateavailable" xml:"prtgupdateavailable"`
+	ReadOnlyUser         string      `json:"readonlyuser" xml:"readonlyuser"`
+	ReportTasks          string      `json:"reporttasks" xml:"reporttasks"`
+	TotalSens            int         `json:"totalsens" xml:"totalsens"`
+	TrialExpiryDays      int         `json:"trialexpirydays" xml:"trialexpirydays"`
+	UnknownSens          string      `json:"unknownsens" xml:"unknownsens"`
+	UnusualSens          string      `json:"unusualsens" xml:"unusualsens"`
+	UpSens               string      `json:"upsens" xml:"upsens"`
+	Version              string      `json:"version" xml:"version"`
+	WarnSens             string      `json:"warnsens" xml:"warnsens"`
+}
+
+//############################# SENSOR DETAILS RESPONSE ####################################
+
+// PrtgSensorDetailsResponse represents the response of sensordetails.json.
+type PrtgSensorDetailsResponse struct {
+	PrtgVersion PrtgVersion             `json:"prtgversion" xml:"prtgversion"`
+	SensorData  PrtgSensorDetailsStruct `json:"sensordata" xml:"sensordata"`
+}
+
+// PrtgSensorDetailsStruct contains the details of a single sensor.
+type PrtgSensorDetailsStruct struct {
+	Name         string `json:"name" xml:"name"`
+	SensorType   string `json:"sensortype" xml:"sensortype"`
+	Interval     string `json:"interval" xml:"interval"`
+	StatusText   string `json:"statustext" xml:"statustext"`
+	StatusId     string `json:"statusid" xml:"statusid"`
+	LastMessage  string `json:"lastmessage" xml:"lastmessage"`
+	LastUp       string `json:"lastup" xml:"lastup"`
+	LastDown     string `json:"lastdown" xml:"lastdown"`
+	LastCheck    string `json:"lastcheck" xml:"lastcheck"`
+	Uptime       string `json:"uptime" xml:"uptime"`
+	UptimeTime   string `json:"uptimetime" xml:"uptimetime"`
+	Downtime     string `json:"downtime" xml:"downtime"`
+	DowntimeTime string `json:"downtimetime" xml:"downtimetime"`
+	UpDownTotal  string `json:"updowntotal" xml:"updowntotal"`
+	UpDownSince  string `json:"updownsince" xml:"updownsince"`
+}
+
+//############################# CHANNEL METADATA RESPONSE ####################################
+
+// PrtgChannelListResponse represents the channel list of a sensor (content=channels).
+type PrtgChannelListResponse struct {
+	PrtgVersion PrtgVersion                 `json:"prtg-version" xml:"prtg-version"`
+	TreeSize    int64                       `json:"treesize" xml:"treesize"`
+	Channels    []PrtgChannelListItemStruct `json:"channels" xml:"channels"`
+}
+
+// PrtgChannelListItemStruct contains a single channel. LastValueRAW is a number for
+// numeric channels and empty otherwise.
+type PrtgChannelListItemStruct struct {
+	ObjectId     int64       `json:"objid" xml:"objid"`
+	Name         string      `json:"name" xml:"name"`
+	LastValue    string      `json:"lastvalue" xml:"lastvalue"`
+	LastValueRAW interface{} `json:"lastvalue_raw" xml:"lastvalue_raw"`
+}
+
+// PrtgObjectPropertyResponse represents the XML response of getobjectproperty.htm.
+type PrtgObjectPropertyResponse struct {
+	Version string `xml:"version"`
+	Result  string `xml:"result"`
+}
+
+//############################# MESSAGE LIST RESPONSE ####################################
+
+// PrtgMessageListResponse represents the response for the message log.
+type PrtgMessageListResponse struct {
+	PrtgVersion PrtgVersion                 `json:"prtg-version" xml:"prtg-version"`
+	TreeSize    int64                       `json:"treesize" xml:"treesize"`
+	Messages    []PrtgMessageListItemStruct `json:"messages" xml:"messages"`
+}
+
+// PrtgMessageListItemStruct contains a single log entry.
+type PrtgMessageListItemStruct struct {
+	ObjectId    int64   `json:"objid" xml:"objid"`
+	Datetime    string  `json:"datetime" xml:"datetime"`
+	DatetimeRAW float64 `json:"datetime_raw" xml:"datetime_raw"`
+	Parent      string  `json:"parent" xml:"parent"`
+	Type        string  `json:"type" xml:"type"`
+	TypeRAW     int     `json:"type_raw" xml:"type_raw"`
+	Name        string  `json:"name" xml:"name"`
+	Status      string  `json:"status" xml:"status"`
+	StatusRAW   int     `json:"status_raw" xml:"status_raw"`
+	Message     string  `json:"message" xml:"message"`
+	MessageRAW  string  `json:"message_raw" xml:"message_raw"`
 }
 
 //############################# CHANNEL LIST RESPONSE ####################################
 
 // PrtgChannelsListResponse represents the response for channel values.
 type PrtgChannelsListResponse struct {
-    PrtgVersion string                   `json:"prtg-version" xml:"prtg-version"`
+    PrtgVersion PrtgVersion              `json:"prtg-version" xml:"prtg-version"`
     TreeSize    int64                    `json:"treesize" xml:"treesize"`
     Values      []PrtgChannelValueStruct `json:"-" xml:"-"`
 }
@@ -232,9 +403,20 @@ func (p *PrtgChannelValueStruct) UnmarshalJSON(data []byte) error {
 
 // PrtgHistoricalDataResponse contains historical values of a sensor.
 type PrtgHistoricalDataResponse struct {
-	PrtgVersion string       `json:"prtg-version" xml:"prtg-version"`
+	PrtgVersion PrtgVersion  `json:"prtg-version" xml:"prtg-version"`
 	TreeSize    int64        `json:"treesize" xml:"treesize"`
 	HistData    []PrtgValues `json:"histdata" xml:"histdata"`
+
+	// AvgInterval is the averaging interval in seconds the data was requested with, 0 for raw data.
+	AvgInterval int64 `json:"-" xml:"-"`
+	// AvgSource tells whether the interval was selected automatically, overridden or a fallback.
+	AvgSource string `json:"-" xml:"-"`
+	// ReturnedCount is the number of points PRTG returned over all chunks, DuplicateCount the
+	// number of those dropped because chunks overlapped.
+	ReturnedCount  int `json:"-" xml:"-"`
+	DuplicateCount int `json:"-" xml:"-"`
+	// Truncated is set if a request still hit the count ceiling after paging, points may be missing.
+	Truncated bool `json:"-" xml:"-"`
 }
 
 // PrtgValues contains the timestamp and dynamic values.
@@ -264,6 +446,30 @@ func (p *PrtgValues) UnmarshalJSON(data []byte) error {
 type Datasource struct {
 	baseURL string
 	api     *Api
+
+	// unknownStatusPolicy controls how PRTG's unknown status is mapped, see status.go
+	unknownStatusPolicy string
+
+	// treeCache holds the group, device and sensor lists of the resource routes
+	treeCache *ttlCache
+
+	// timezone is the configured timezone, serverTimezone the one derived from the PRTG clock.
+	// See timezone.go for how the effective timezone is chosen.
+	// serverTimezoneRetry is the earliest time of the next clock lookup after a failed one.
+	timezone            *time.Location
+	serverTimezone      *time.Location
+	serverTimezoneRetry time.Time
+	serverTimezoneMu    sync.Mutex
+
+	// unitTagPrefix enables units derived from sensor tags, see units.go. Empty disables it.
+	unitTagPrefix string
+
+	// nonNumericPolicy controls text values like "<1", see values.go. Empty drops them.
+	nonNumericPolicy string
+
+	// neverPolledPolicy controls objects without datetime in property queries, see neverpolled.go.
+	// Empty drops them.
+	neverPolledPolicy string
 }
 
 // Group, Device and Sensor serve as simple structures for filtering.
@@ -287,6 +493,7 @@ type queryModel struct {
 	Device            string   `json:"device"`
 	Sensor            string   `json:"sensor"`
 	Channel           string   `json:"channel"`
+	Aggregation       string   `json:"aggregation"` // "avg", "min", "max", "sum" or "p95", see aggregation.go
 	Property          string   `json:"property"`
 	FilterProperty    string   `json:"filterProperty"`
 	IncludeGroupName  bool     `json:"includeGroupName"`
@@ -295,8 +502,64 @@ type queryModel struct {
 	Groups            []string `json:"groups,omitempty"`
 	Devices           []string `json:"devices,omitempty"`
 	Sensors           []string `json:"sensors,omitempty"`
+	Channels          []string `json:"channels,omitempty"`
+	Tags              []string `json:"tags,omitempty"` // property queries: objects with any of the tags, see listFilter
 	From              int64    `json:"from"`
 	To                int64    `json:"to"`
+
+	// Metrics options
+	IncludeFormattedValue bool   `json:"includeFormattedValue"`
+	ChangesOnly           bool   `json:"changesOnly"`
+	CheckSensorState      bool   `json:"checkSensorState"`
+	MissingChannels       string `json:"missingChannels"` // "notice" (default) or "fail"
+	EmptyChannel          string `json:"emptyChannel"`    // "all" (default), "primary" or "error"
+	AlignToClock          bool   `json:"alignToClock"`    // floor averaged timestamps to the interval
+	ChannelColors         bool   `json:"channelColors"`   // use the channel colors configured in PRTG
+	ChannelFormat         bool   `json:"channelFormat"`   // use the decimal places and unit configured in PRTG
+	RangeTimestamp        string `json:"rangeTimestamp"`  // "start" (default), "middle" or "end" of range datetimes
+	NormalizeUnit         string `json:"normalizeUnit"`   // "largest" or a unit like "Mbit/s", see normalize.go
+	SplitFrames           bool   `json:"splitFrames"`     // one frame per channel instead of a single wide frame, see wide.go
+	LatestOnly            bool   `json:"latestOnly"`      // only the newest value of every channel, see latest.go
+	IncludeSummary        bool   `json:"includeSummary"`  // min/max/avg frame per channel, see summary.go
+	Avg                   int64  `json:"avg"`             // averaging interval in seconds, 0 selects it automatically
+
+	// AvgExact passes Avg to PRTG unchanged instead of snapping it to the closest PRTG
+	// interval. With AvgExact an Avg of 0 requests raw data.
+	AvgExact bool `json:"avgExact"`
+
+	// Status transitions options
+	IncludeTransitionTimes bool `json:"includeTransitionTimes"`
+
+	// State since options, see statesince.go
+	StateSinceFormat string `json:"stateSinceFormat"` // "seconds" (default) or "text"
+
+	// Tag group options, see taggroups.go
+	GroupTag       string `json:"groupTag"`       // tag prefix whose remainder names the group, e.g. "env:"
+	TagAggregation string `json:"tagAggregation"` // "avg" (default), "sum", "min" or "max"
+
+	// Notifications options
+	NotifiedOnly bool `json:"notifiedOnly"` // only alarms with at least one notification trigger
+
+	// Value threshold options, the sensors are filtered in the plugin, see thresholds.go
+	Comparator string   `json:"comparator"` // ">" (default), ">=", "<", "<=", "=" or "!="
+	Threshold  *float64 `json:"threshold"`
+
+	// Status rollup options, the worst status of the sensors and devices is returned, see rollup.go
+	ObjectIds []string `json:"objids"`
+
+	// Device reachability options, see reachability.go for the defaults
+	PingSensorType string `json:"pingSensorType"`
+	PingSensorName string `json:"pingSensorName"`
+
+	// Difference series: the channel of SubtractObjectId is subtracted from the queried channel.
+	// SubtractChannel defaults to Channel, AlignTolerance is given in seconds.
+	SubtractObjectId string `json:"subtractObjid"`
+	SubtractChannel  string `json:"subtractChannel"`
+	AlignTolerance   int64  `json:"alignTolerance"`
+
+	// Frame field names, see fieldNames for the defaults
+	TimeFieldName  string `json:"timeFieldName"`
+	ValueFieldName string `json:"valueFieldName"`
 }
 
 // MyDatasource can be used for further internal purposes.
@@ -160,4 +160,38 @@ func TestPrtgHistoricalDataResponse_UnmarshalJSON(t *testing.T) {
 	if response.HistData[0].Value["cpu_load"] != 65.5 {
 		t.Errorf("Expected cpu_load to be 65.5, got %v", response.HistData[0].Value["cpu_load"])
 	}
-}
\ No newline at end of file
+}
+
+// ✅ PrtgVersion JSON Unmarshal Testi: String and array shapes
+func TestPrtgVersion_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected PrtgVersion
+	}{
+		{`{"prtg-version": "23.1.1.1"}`, "23.1.1.1"},
+		{`{"prtg-version": ["23.1.1.1"]}`, "23.1.1.1"},
+		{`{"prtg-version": ["23.1.1.1", "", "23.1.1.2"]}`, "23.1.1.1, 23.1.1.2"},
+		{`{"prtg-version": []}`, ""},
+		{`{"prtg-version": null}`, ""},
+		{`{"prtg-version": 24}`, "24"},
+	}
+
+	for _, tt := range tests {
+		var response PrtgSensorsListResponse
+		if err := json.Unmarshal([]byte(tt.json), &response); err != nil {
+			t.Fatalf("Unmarshal %s failed: %v", tt.json, err)
+		}
+		if response.PrtgVersion != tt.expected {
+			t.Errorf("Unmarshal %s: expected %q, got %q", tt.json, tt.expected, response.PrtgVersion)
+		}
+	}
+
+	var response PrtgTableListResponse
+	if err := json.Unmarshal([]byte(`{"prtg-version": "23.1.1.1"}`), &response); err != nil || response.PrtgVersion != "23.1.1.1" {
+		t.Errorf("Expected the table response to accept a string, got %q (%v)", response.PrtgVersion, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"prtg-version": {"version": "23"}}`), &response); err == nil {
+		t.Errorf("Expected an error for an object")
+	}
+}
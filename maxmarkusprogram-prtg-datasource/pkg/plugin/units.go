@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// unitAliases maps common unit spellings in tags to Grafana unit ids. Other values are
+// used as given, so tags may also carry Grafana unit ids directly (e.g. "unit:decbytes").
+var unitAliases = map[string]string{
+	"%":       "percent",
+	"percent": "percent",
+	"bps":     "bps",
+	"kbps":    "Kbits",
+	"mbps":    "Mbits",
+	"gbps":    "Gbits",
+	"b":       "bytes",
+	"byte":    "bytes",
+	"bytes":   "bytes",
+	"kb":      "kbytes",
+	"mb":      "mbytes",
+	"gb":      "gbytes",
+	"ms":      "ms",
+	"msec":    "ms",
+	"s":       "s",
+	"sec":     "s",
+	"c":       "celsius",
+	"celsius": "celsius",
+	"hz":      "hertz",
+}
+
+// unitFromTags returns the Grafana unit encoded in the tags with the given prefix,
+// e.g. "unit:mbps" with prefix "unit:". The first matching tag wins, see tagValues.
+func unitFromTags(tags, prefix string) (string, bool) {
+	if prefix == "" {
+		return "", false
+	}
+	values := tagValues(tags, prefix)
+	if len(values) == 0 {
+		return "", false
+	}
+	if unit, ok := unitAliases[strings.ToLower(values[0])]; ok {
+		return unit, true
+	}
+	return values[0], true
+}
+
+// tagUnit returns the unit derived from the tags of a sensor. The sensor list of the tree
+// cache is used, so the tags do not cost an extra request per query.
+func (d *Datasource) tagUnit(ctx context.Context, objid string) (string, bool) {
+	if d.unitTagPrefix == "" {
+		return "", false
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(objid), 10, 64)
+	if err != nil {
+		return "", false
+	}
+
+	sensors, err := cachedTree(ctx, d.treeCache, "sensors", d.api.GetSensors)
+	if err != nil {
+		backend.Logger.Warn("Could not read sensor tags", "objectId", objid, "error", err)
+		return "", false
+	}
+	for _, sensor := range sensors.Sensors {
+		if sensor.ObjectId == id {
+			return unitFromTags(sensor.Tags, d.unitTagPrefix)
+		}
+	}
+	return "", false
+}
+
+// captionUnitMatch is the share of captions whose number has to agree with the value for
+// the caption unit to be used.
+const captionUnitMatch = 0.9
+
+// captionFieldUnit derives the Grafana unit of a series from PRTG's value captions, e.g.
+// "5 msec". The values come from the raw columns, which are not always in the caption unit:
+// traffic captions are in bit/s while the raw values are bytes. So the most common caption
+// unit is only used if the values agree with the caption numbers, or, for the units of
+// captionUnits, with the numbers converted to the base unit of the family. Units Grafana
+// does not know are shown as suffix, see channelUnit. Without a matching unit "" is returned.
+func captionFieldUnit(values []float64, captions []string) string {
+	if len(captions) != len(values) {
+		return ""
+	}
+
+	type sample struct {
+		value, number float64
+	}
+	samples := make(map[string][]sample)
+	dominant := ""
+	for i, caption := range captions {
+		text := strings.TrimSpace(cleanMessageHTML(caption))
+		number, unit, ok := splitFormattedValue(text)
+		if !ok {
+			continue
+		}
+		if unit == "" {
+			continue
+		}
+		samples[unit] = append(samples[unit], sample{values[i], number})
+		if len(samples[unit]) > len(samples[dominant]) {
+			dominant = unit
+		}
+	}
+	if dominant == "" {
+		return ""
+	}
+
+	// Captions are rounded, so the numbers only have to be close to the values
+	matches := func(scale float64) bool {
+		matched := 0
+		for _, s := range samples[dominant] {
+			expected := s.number * scale
+			if math.Abs(s.value-expected) <= math.Max(0.05*math.Abs(expected), 0.5*scale) {
+				matched++
+			}
+		}
+		return float64(matched) >= captionUnitMatch*float64(len(samples[dominant]))
+	}
+	if matches(1) {
+		return channelUnit(dominant)
+	}
+	if known, ok := lookupCaptionUnit(dominant); ok && known.factor != 1 && matches(known.factor) {
+		for _, base := range captionUnits {
+			if base.family == known.family && base.factor == 1 {
+				return base.grafana
+			}
+		}
+	}
+	return ""
+}
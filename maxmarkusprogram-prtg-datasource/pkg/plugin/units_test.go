@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ unitFromTags test: Units from tags with a configurable prefix
+func TestUnitFromTags(t *testing.T) {
+	tests := []struct {
+		tags     string
+		prefix   string
+		expected string
+		ok       bool
+	}{
+		{"network unit:mbps", "unit:", "Mbits", true},
+		{"UNIT:Percent,cpu", "unit:", "percent", true},
+		{"unit:decbytes", "unit:", "decbytes", true},
+		{"u_ms pingsensor", "u_", "ms", true},
+		{"network unit:", "unit:", "", false},
+		{"network unit:mbps", "", "", false},
+		{"", "unit:", "", false},
+	}
+
+	for _, tt := range tests {
+		unit, ok := unitFromTags(tt.tags, tt.prefix)
+		if unit != tt.expected || ok != tt.ok {
+			t.Errorf("unitFromTags(%q, %q) = %q, %v; expected %q, %v", tt.tags, tt.prefix, unit, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ captionFieldUnit test: The caption unit is only used if it matches the values
+func TestCaptionFieldUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		captions []string
+		expected string
+	}{
+		{"Milliseconds", []float64{5, 12.4}, []string{"5 msec", "12 msec"}, "ms"},
+		{"Percent", []float64{12, 99.5}, []string{"12 %", "100 %"}, "percent"},
+		{"Unknown unit as suffix", []float64{3, 40}, []string{"3 #", "40 #"}, "suffix:#"},
+		{"Bytes in the base unit", []float64{1572864, 2147483648}, []string{"1,5 MByte", "2 GByte"}, "bytes"},
+		{"Traffic raw values are no bits", []float64{187500, 62500}, []string{"1,5 Mbit/s", "500 kbit/s"}, ""},
+		{"Most common unit wins", []float64{5, 6, 7}, []string{"5 msec", "6 msec", "7 s"}, "ms"},
+		{"No unit", []float64{5}, []string{"5"}, ""},
+		{"No captions", []float64{5}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		if unit := captionFieldUnit(tt.values, tt.captions); unit != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, unit)
+		}
+	}
+}
+
+// ✅ Metrics query: Unit derived from the value captions
+func TestQueryData_UnitFromCaptions(t *testing.T) {
+	server, api := setupMockAPI(`{"histdata": [
+		{"datetime": "15.02.2025 12:00:00", "Ping Time": "5 msec", "Ping Time(RAW)": 5},
+		{"datetime": "15.02.2025 12:01:00", "Ping Time": "7 msec", "Ping Time(RAW)": 7}
+	]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api, timezone: time.UTC}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Ping Time"}`),
+		TimeRange: backend.TimeRange{
+			From: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2025, 2, 16, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if unit := resp.Frames[0].Fields[1].Config.Unit; unit != "ms" {
+		t.Errorf("Expected unit ms, got %q", unit)
+	}
+}
+
+// ✅ Metrics query: Unit derived from the sensor tags
+func TestQueryData_UnitFromTags(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sensors": [{"objid": 1234, "sensor": "Traffic", "tags": "snmptraffic unit:mbps"}]}`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [{"datetime": "2025-02-15T12:00:00Z", "Traffic In": 12}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	api := NewApi(server.URL, "test-api-key", 10*time.Second, 10*time.Second)
+	query := backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"Traffic In"}`),
+		TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+	}
+
+	ds := &Datasource{api: api, unitTagPrefix: "unit:"}
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if unit := resp.Frames[0].Fields[1].Config.Unit; unit != "Mbits" {
+		t.Errorf("Expected unit Mbits, got %q", unit)
+	}
+
+	// Disabled by default
+	ds = &Datasource{api: api}
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if unit := resp.Frames[0].Fields[1].Config.Unit; unit != "" {
+		t.Errorf("Expected no unit without a tag prefix, got %q", unit)
+	}
+}
+
+// ✅ Metrics query: The caption unit of PRTG wins over the unit from the tags
+func TestQueryData_UnitPrecedence(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/table.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sensors": [{"objid": 1234, "sensor": "Ping", "tags": "ping unit:s"}]}`)
+	})
+	mux.HandleFunc("/api/historicdata.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"histdata": [
+			{"datetime": "15.02.2025 12:00:00", "Ping Time": "5 msec", "Ping Time(RAW)": 5},
+			{"datetime": "15.02.2025 12:01:00", "Ping Time": "7 msec", "Ping Time(RAW)": 7}
+		]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := &Datasource{api: NewApi(server.URL, "test-api-key", 0, 10*time.Second), timezone: time.UTC, unitTagPrefix: "unit:"}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID: "A",
+		JSON:  []byte(`{"queryType":"metrics","objid":"1234","channel":"Ping Time"}`),
+		TimeRange: backend.TimeRange{
+			From: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2025, 2, 16, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if unit := resp.Frames[0].Fields[1].Config.Unit; unit != "ms" {
+		t.Errorf("Expected the caption unit ms to win over the tag unit, got %q", unit)
+	}
+}
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Policies for values PRTG delivers as text that is not a plain number, like "<1" or ">99".
+const (
+	nonNumericDrop     = "drop"     // drop the point and report it with a notice (default)
+	nonNumericBound    = "bound"    // use the number of the value: "<1" -> 1, ">99" -> 99
+	nonNumericEstimate = "estimate" // use an estimate: "<x" -> x/2, ">x" -> x
+)
+
+// normalizeNonNumericPolicy returns a valid policy, unknown values fall back to drop.
+func normalizeNonNumericPolicy(policy string) string {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case nonNumericBound:
+		return nonNumericBound
+	case nonNumericEstimate:
+		return nonNumericEstimate
+	}
+	return nonNumericDrop
+}
+
+// groupSeparators are the characters PRTG puts between digit groups besides "." and ",".
+const groupSeparators = " '\u00a0\u202f"
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// parseFormattedValue parses the number at the start of a formatted value like "1,234.5",
+// "1.234,5 kbit/s" or "45 %". Thousands separators and the text after the number are
+// stripped. If "." and "," both occur, the last one is the decimal separator. A separator
+// occurring more than once separates thousands, a single one is the decimal separator, so
+// "1,5" is 1.5 and "1.234" is 1.234. Values not starting with a number, like "<1" or
+// "No data", are rejected.
+func parseFormattedValue(value string) (float64, bool) {
+	number, _, ok := splitFormattedValue(value)
+	return number, ok
+}
+
+// splitFormattedValue parses a formatted value like parseFormattedValue and also returns the
+// text after the number, e.g. "kbit/s" for "1 234,5 kbit/s", with surrounding spaces trimmed.
+func splitFormattedValue(value string) (float64, string, bool) {
+	runes := []rune(strings.TrimSpace(cleanMessageHTML(value)))
+	var number strings.Builder
+	consumed := len(runes)
+scan:
+	for i, r := range runes {
+		switch {
+		case isDigit(r), r == '.', r == ',', i == 0 && (r == '-' || r == '+'):
+			number.WriteRune(r)
+		case strings.ContainsRune(groupSeparators, r) && i > 0 && i+1 < len(runes) && isDigit(runes[i-1]) && isDigit(runes[i+1]):
+			// "1 234" or "1'234", the separator is dropped
+		default:
+			consumed = i
+			break scan
+		}
+	}
+	rest := strings.TrimSpace(string(runes[consumed:]))
+
+	text := strings.TrimRight(number.String(), ".,")
+	decimal := ""
+	switch dots, commas := strings.Count(text, "."), strings.Count(text, ","); {
+	case dots > 0 && commas > 0:
+		decimal = text[strings.LastIndexAny(text, ".,"):][:1]
+	case dots == 1:
+		decimal = "."
+	case commas == 1:
+		decimal = ","
+	}
+	for _, separator := range []string{".", ","} {
+		if separator != decimal {
+			text = strings.ReplaceAll(text, separator, "")
+		}
+	}
+	if decimal != "" {
+		text = strings.ReplaceAll(text, decimal, ".")
+	}
+
+	parsed, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return parsed, rest, true
+}
+
+// comparisonPrefixes are the operators PRTG puts in front of bounded values, longest first.
+var comparisonPrefixes = []string{"<=", ">=", "≤", "≥", "<", ">", "~"}
+
+// parseNonNumericValue interprets a value like "<1", ">99" or "100 %" according to the policy.
+// Comparison operators and unit suffixes are stripped. With the drop policy, or if no number
+// is found, ok is false.
+func parseNonNumericValue(value, policy string) (float64, bool) {
+	if policy != nonNumericBound && policy != nonNumericEstimate {
+		return 0, false
+	}
+
+	text := strings.TrimSpace(cleanMessageHTML(value))
+	operator := ""
+	for _, prefix := range comparisonPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			operator = prefix
+			text = strings.TrimSpace(text[len(prefix):])
+			break
+		}
+	}
+
+	number, ok := parseFormattedValue(text)
+	if !ok {
+		return 0, false
+	}
+	if policy == nonNumericEstimate && (operator == "<" || operator == "≤" || operator == "<=") {
+		return number / 2, true
+	}
+	return number, true
+}
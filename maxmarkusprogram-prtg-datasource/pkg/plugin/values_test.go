@@ -0,0 +1,182 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ parseFormattedValue test: English and German locale formatting with units
+func TestParseFormattedValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+		ok       bool
+	}{
+		{"42", 42, true},
+		{"-3.5", -3.5, true},
+		{"1,234.5", 1234.5, true},
+		{"1.234,5", 1234.5, true},
+		{"1.234.567", 1234567, true},
+		{"1,234,567", 1234567, true},
+		{"1.234.567,89 kbit/s", 1234567.89, true},
+		{"1 234,5 MByte", 1234.5, true},
+		{"1\u00a0234,5 MByte", 1234.5, true},
+		{"1'234.5", 1234.5, true},
+		{"3,5 msec", 3.5, true},
+		{"45 %", 45, true},
+		{"0,05 %", 0.05, true},
+		{"99,9%", 99.9, true},
+		{"12. ", 12, true},
+		{"<1", 0, false},
+		{"No data", 0, false},
+		{"", 0, false},
+		{"1,2,3.4.5", 0, false},
+	}
+
+	for _, tt := range tests {
+		value, ok := parseFormattedValue(tt.value)
+		if value != tt.expected || ok != tt.ok {
+			t.Errorf("parseFormattedValue(%q) = %v, %v; expected %v, %v", tt.value, value, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ splitFormattedValue test: The unit is the text after the consumed number
+func TestSplitFormattedValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+		unit     string
+	}{
+		{"1 234,5 MByte", 1234.5, "MByte"},
+		{"1\u00a0234,5 kbit/s", 1234.5, "kbit/s"},
+		{"1'234.5 msec", 1234.5, "msec"},
+		{"99,9%", 99.9, "%"},
+		{"-3.5 °C", -3.5, "°C"},
+		{"42", 42, ""},
+	}
+
+	for _, tt := range tests {
+		value, unit, ok := splitFormattedValue(tt.value)
+		if !ok || value != tt.expected || unit != tt.unit {
+			t.Errorf("splitFormattedValue(%q) = %v, %q, %v; expected %v, %q", tt.value, value, unit, ok, tt.expected, tt.unit)
+		}
+	}
+}
+
+// ✅ Metrics query: Locale formatted values without raw column are parsed
+func TestQueryData_FormattedValues(t *testing.T) {
+	server, api := setupMockAPI(`{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "Traffic": "1.234,5 kbit/s"},
+		{"datetime": "2025-02-15T12:01:00Z", "Traffic": "1,234.5 kbit/s"},
+		{"datetime": "2025-02-15T12:02:00Z", "Traffic": "45 %"}
+	]}`, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"Traffic"}`),
+		TimeRange: histdataTimeRange,
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	values := resp.Frames[0].Fields[1]
+	expected := []float64{1234.5, 1234.5, 45}
+	if values.Len() != len(expected) {
+		t.Fatalf("Expected %d values, got %d", len(expected), values.Len())
+	}
+	for i, e := range expected {
+		if values.At(i).(float64) != e {
+			t.Errorf("Value %d: expected %v, got %v", i, e, values.At(i))
+		}
+	}
+}
+
+// ✅ parseNonNumericValue test: Every form with every policy
+func TestParseNonNumericValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		policy   string
+		expected float64
+		ok       bool
+	}{
+		{"<1", nonNumericDrop, 0, false},
+		{"<1", nonNumericBound, 1, true},
+		{"<1", nonNumericEstimate, 0.5, true},
+		{"<= 4", nonNumericEstimate, 2, true},
+		{">99", nonNumericBound, 99, true},
+		{">99", nonNumericEstimate, 99, true},
+		{"≥ 10 %", nonNumericBound, 10, true},
+		{"100 %", nonNumericDrop, 0, false},
+		{"100 %", nonNumericBound, 100, true},
+		{"100 %", nonNumericEstimate, 100, true},
+		{"~3,5 msec", nonNumericBound, 3.5, true},
+		{"No data", nonNumericBound, 0, false},
+		{"<", nonNumericEstimate, 0, false},
+	}
+
+	for _, tt := range tests {
+		value, ok := parseNonNumericValue(tt.value, tt.policy)
+		if value != tt.expected || ok != tt.ok {
+			t.Errorf("parseNonNumericValue(%q, %s) = %v, %v; expected %v, %v", tt.value, tt.policy, value, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// ✅ normalizeNonNumericPolicy test
+func TestNormalizeNonNumericPolicy(t *testing.T) {
+	for input, expected := range map[string]string{"": nonNumericDrop, "Bound": nonNumericBound, " estimate ": nonNumericEstimate, "zero": nonNumericDrop} {
+		if got := normalizeNonNumericPolicy(input); got != expected {
+			t.Errorf("normalizeNonNumericPolicy(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+// ✅ Metrics query: Non-numeric values are dropped with a notice or parsed by policy
+func TestQueryData_NonNumericValues(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "Loss": "<1"},
+		{"datetime": "2025-02-15T12:01:00Z", "Loss": "2"},
+		{"datetime": "2025-02-15T12:02:00Z", "Loss": "No data"}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	query := backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","channel":"Loss"}`),
+		TimeRange: histdataTimeRange,
+	}
+
+	ds := &Datasource{api: api}
+	resp := ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	frame := resp.Frames[0]
+	if rows, _ := frame.RowLen(); rows != 1 {
+		t.Errorf("Expected 1 point with the drop policy, got %d", rows)
+	}
+	dropped := frame.Meta.Custom.(map[string]interface{})["pointsDropped"].(map[string]int)
+	if dropped["nonNumeric"] != 1 || dropped["invalidValue"] != 1 {
+		t.Errorf("Unexpected drop counts: %v", dropped)
+	}
+	if len(frame.Meta.Notices) != 1 {
+		t.Errorf("Expected a notice for the dropped value, got %v", frame.Meta.Notices)
+	}
+
+	ds = &Datasource{api: api, nonNumericPolicy: nonNumericEstimate}
+	resp = ds.query(context.Background(), backend.PluginContext{}, query)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	values := resp.Frames[0].Fields[1]
+	if values.Len() != 2 || values.At(0).(float64) != 0.5 || values.At(1).(float64) != 2 {
+		t.Errorf("Expected the estimated value 0.5 and 2, got %d values", values.Len())
+	}
+}
@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// dataChannels returns the channels present in the historical data, sorted by name.
+// The raw columns PRTG delivers next to formatted values and the coverage column are
+// not channels of their own.
+func dataChannels(historicalData *PrtgHistoricalDataResponse) []string {
+	present := make(map[string]bool)
+	for _, item := range historicalData.HistData {
+		for key := range item.Value {
+			present[key] = true
+		}
+	}
+
+	channels := make([]string, 0, len(present))
+	for key := range present {
+		if key == "coverage" || key == "datetime_raw" {
+			continue
+		}
+		if strings.HasSuffix(key, "(RAW)") || strings.HasSuffix(key, "_raw") {
+			continue
+		}
+		channels = append(channels, key)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// mergeChannelFrames joins the frames of several channels into one wide frame on the union
+// of their timestamps. Multi-channel metrics queries return the wide frame unless splitFrames
+// is set. The value fields keep their names, labels and configuration and become
+// nullable, a channel without a point at a timestamp is null there. Notices of all frames are
+// kept, the metadata of the first frame is used and the per channel metadata is listed under
+// "channels".
+func mergeChannelFrames(frames []*data.Frame) *data.Frame {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	rows := make(map[time.Time]int)
+	var times []time.Time
+	for _, frame := range frames {
+		for i := 0; i < frame.Fields[0].Len(); i++ {
+			t := frame.Fields[0].At(i).(time.Time)
+			if _, ok := rows[t]; !ok {
+				rows[t] = 0
+				times = append(times, t)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i, t := range times {
+		rows[t] = i
+	}
+
+	merged := data.NewFrame(frames[0].Name, data.NewField(frames[0].Fields[0].Name, nil, times))
+	var notices []data.Notice
+	var channelMeta []interface{}
+	for _, frame := range frames {
+		for _, src := range frame.Fields[1:] {
+			field := data.NewFieldFromFieldType(src.Type().NullableType(), len(times))
+			field.Name = src.Name
+			field.Labels = src.Labels
+			field.Config = src.Config
+			for i := 0; i < src.Len(); i++ {
+				if value, ok := src.ConcreteAt(i); ok {
+					field.SetConcrete(rows[frame.Fields[0].At(i).(time.Time)], value)
+				}
+			}
+			merged.Fields = append(merged.Fields, field)
+		}
+		if frame.Meta != nil {
+			notices = append(notices, frame.Meta.Notices...)
+			channelMeta = append(channelMeta, frame.Meta.Custom)
+		}
+	}
+
+	meta := &data.FrameMeta{}
+	if frames[0].Meta != nil {
+		meta.Type = frames[0].Meta.Type
+		custom := map[string]interface{}{}
+		if first, ok := frames[0].Meta.Custom.(map[string]interface{}); ok {
+			for key, value := range first {
+				custom[key] = value
+			}
+		}
+		custom["channels"] = channelMeta
+		meta.Custom = custom
+	}
+	meta.Notices = notices
+	merged.SetMeta(meta)
+	return merged
+}
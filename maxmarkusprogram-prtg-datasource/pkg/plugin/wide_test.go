@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ✅ dataChannels test: Raw and coverage columns are no channels
+func TestDataChannels(t *testing.T) {
+	historicalData := &PrtgHistoricalDataResponse{HistData: []PrtgValues{
+		{Datetime: "1", Value: map[string]interface{}{"Traffic Out": "2 Mbit/s", "Traffic Out(RAW)": 250000.0, "coverage": "100 %"}},
+		{Datetime: "2", Value: map[string]interface{}{"Downtime": 0.0, "Traffic In_raw": 1.0, "Traffic In": 1.0}},
+	}}
+
+	expected := []string{"Downtime", "Traffic In", "Traffic Out"}
+	if got := dataChannels(historicalData); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// ✅ Multi-channel query in a single frame, an empty channel list returns all channels
+func TestQueryData_WideFrame(t *testing.T) {
+	mockResponse := `{"histdata": [
+		{"datetime": "2025-02-15T12:00:00Z", "Traffic In": 10, "Traffic Out": 20},
+		{"datetime": "2025-02-15T12:01:00Z", "Traffic In": 11, "Traffic Out": "invalid"},
+		{"datetime": "2025-02-15T12:02:00Z", "Traffic In": "invalid", "Traffic Out": 22}
+	]}`
+	server, api := setupMockAPI(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	ds := &Datasource{api: api}
+	timeRange := histdataTimeRange
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"channels", `{"queryType":"metrics","objid":"1234","channels":["Traffic In","Traffic Out"]}`},
+		{"all channels", `{"queryType":"metrics","objid":"1234"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+				RefID:     "A",
+				JSON:      []byte(tt.query),
+				TimeRange: timeRange,
+			})
+			if resp.Error != nil {
+				t.Fatalf("Unexpected error: %v", resp.Error)
+			}
+			if len(resp.Frames) != 1 {
+				t.Fatalf("Expected 1 frame, got %d", len(resp.Frames))
+			}
+			frame := resp.Frames[0]
+			if len(frame.Fields) != 3 || frame.Fields[0].Len() != 3 {
+				t.Fatalf("Expected a time and two value fields with 3 rows, got %d fields", len(frame.Fields))
+			}
+			in, out := frame.Fields[1], frame.Fields[2]
+			if in.Config.DisplayName != "Traffic In" || out.Config.DisplayName != "Traffic Out" {
+				t.Errorf("Unexpected display names: %q, %q", in.Config.DisplayName, out.Config.DisplayName)
+			}
+			// Each channel is null where its point was dropped
+			if v := in.At(1).(*float64); v == nil || *v != 11 {
+				t.Errorf("Expected 11 for Traffic In at row 1, got %v", v)
+			}
+			if v := in.At(2).(*float64); v != nil {
+				t.Errorf("Expected null for Traffic In at row 2, got %v", *v)
+			}
+			if v := out.At(1).(*float64); v != nil {
+				t.Errorf("Expected null for Traffic Out at row 1, got %v", *v)
+			}
+			custom := frame.Meta.Custom.(map[string]interface{})
+			if channels, ok := custom["channels"].([]interface{}); !ok || len(channels) != 2 {
+				t.Errorf("Expected the metadata of 2 channels, got %v", custom["channels"])
+			}
+		})
+	}
+
+	// With splitFrames every channel keeps its own frame
+	resp := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{
+		RefID:     "A",
+		JSON:      []byte(`{"queryType":"metrics","objid":"1234","splitFrames":true}`),
+		TimeRange: timeRange,
+	})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if len(resp.Frames) != 2 {
+		t.Errorf("Expected 2 frames, got %d", len(resp.Frames))
+	}
+}
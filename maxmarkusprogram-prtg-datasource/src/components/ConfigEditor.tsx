@@ -1,8 +1,13 @@
 import React, { ChangeEvent } from 'react';
-import { InlineField, Input, SecretInput } from '@grafana/ui';
-import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
+import { InlineField, Input, SecretInput, Select } from '@grafana/ui';
+import { DataSourcePluginOptionsEditorProps, SelectableValue } from '@grafana/data';
 import { MyDataSourceOptions, MySecureJsonData } from '../types';
 
+const schemeOptions: Array<SelectableValue<'https' | 'http'>> = [
+  { label: 'HTTPS', value: 'https' },
+  { label: 'HTTP', value: 'http' },
+];
+
 interface Props extends DataSourcePluginOptionsEditorProps<MyDataSourceOptions, MySecureJsonData> {}
 
 export function ConfigEditor(props: Props) {
@@ -19,6 +24,16 @@ export function ConfigEditor(props: Props) {
     });
   };
 
+  const onSchemeChange = (option: SelectableValue<'https' | 'http'> | null) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        scheme: option?.value,
+      },
+    });
+  };
+
   // Secure field (only sent to the backend)
   const onAPIKeyChange = (event: ChangeEvent<HTMLInputElement>) => {
     onOptionsChange({
@@ -53,6 +68,52 @@ export function ConfigEditor(props: Props) {
     });
   }
 
+  // failover nodes of a PRTG cluster, comma separated
+  const onFailoverPathsChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const failoverPaths = event.target.value
+      .split(',')
+      .map((path) => path.trim())
+      .filter((path) => path !== '');
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        failoverPaths: failoverPaths.length > 0 ? failoverPaths : undefined,
+      },
+    });
+  };
+
+  const onRequestTimeoutChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseInt(event.target.value, 10);
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        requestTimeout: value > 0 ? value : undefined,
+      },
+    });
+  };
+
+  const onTimezoneChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        timezone: event.target.value.trim() || undefined,
+      },
+    });
+  };
+
+  const onProxyUrlChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        proxyUrl: event.target.value.trim() || undefined,
+      },
+    });
+  };
+
   return (
     <>
       <InlineField label="Path" labelWidth={14} interactive tooltip={'Json field returned to frontend'}>
@@ -64,6 +125,36 @@ export function ConfigEditor(props: Props) {
           width={60}
         />
       </InlineField>
+      <InlineField
+        label="Scheme"
+        labelWidth={14}
+        interactive
+        tooltip={'HTTP is meant for internal test servers. Without it a scheme typed in the path is used, otherwise HTTPS'}
+      >
+        <Select
+          inputId="config-editor-scheme"
+          options={schemeOptions}
+          value={jsonData.scheme}
+          onChange={onSchemeChange}
+          isClearable
+          placeholder="From the path or HTTPS"
+          width={60}
+        />
+      </InlineField>
+      <InlineField
+        label="Failover Paths"
+        labelWidth={14}
+        interactive
+        tooltip={'Paths of further PRTG cluster nodes, comma separated. They are used in order if the current node is unreachable'}
+      >
+        <Input
+          id="config-editor-failover-paths"
+          onChange={onFailoverPathsChange}
+          defaultValue={(jsonData.failoverPaths ?? []).join(', ')}
+          placeholder="e.g. prtg-failover.example.com"
+          width={60}
+        />
+      </InlineField>
       <InlineField label="API Key" labelWidth={14} interactive tooltip={'Secure json field (backend only)'}>
         <SecretInput
           required
@@ -85,6 +176,45 @@ export function ConfigEditor(props: Props) {
           width={60}
         />
       </InlineField>
+      <InlineField label="Request Timeout" labelWidth={14} interactive tooltip={'Timeout of every PRTG API request in seconds, 10 by default'}>
+        <Input
+          id="config-editor-request-timeout"
+          type="number"
+          min={1}
+          onChange={onRequestTimeoutChange}
+          value={jsonData.requestTimeout ?? ''}
+          placeholder="10"
+          width={60}
+        />
+      </InlineField>
+      <InlineField
+        label="Timezone"
+        labelWidth={14}
+        interactive
+        tooltip={'IANA timezone of the PRTG server, e.g. Europe/Berlin. Without it the timezone is derived from the PRTG clock'}
+      >
+        <Input
+          id="config-editor-timezone"
+          onChange={onTimezoneChange}
+          value={jsonData.timezone ?? ''}
+          placeholder="e.g. Europe/Berlin"
+          width={60}
+        />
+      </InlineField>
+      <InlineField
+        label="Proxy URL"
+        labelWidth={14}
+        interactive
+        tooltip={'Proxy for the requests to PRTG, e.g. http://proxy:3128. Without it HTTP_PROXY and HTTPS_PROXY are used'}
+      >
+        <Input
+          id="config-editor-proxy-url"
+          onChange={onProxyUrlChange}
+          value={jsonData.proxyUrl ?? ''}
+          placeholder="e.g. http://proxy:3128"
+          width={60}
+        />
+      </InlineField>
     </>
   );
 }
@@ -7,6 +7,7 @@ import {
   PRTGDeviceListResponse,
   PRTGSensorListResponse,
   PRTGChannelListResponse,
+  PRTGObjectTree,
 } from './types'
 
 export class DataSource extends DataSourceWithBackend<MyQuery, MyDataSourceOptions> {
@@ -26,16 +27,16 @@ export class DataSource extends DataSourceWithBackend<MyQuery, MyDataSourceOptio
     return !!query.channel
   }
 
-  async getGroups(): Promise<PRTGGroupListResponse> {
-    return this.getResource('groups')
+  async getGroups(tags?: string[]): Promise<PRTGGroupListResponse> {
+    return this.getResource('groups', tags?.length ? { tags: tags.join(',') } : undefined)
   }
 
-  async getDevices(): Promise<PRTGDeviceListResponse> {
-    return this.getResource('devices')
+  async getDevices(tags?: string[]): Promise<PRTGDeviceListResponse> {
+    return this.getResource('devices', tags?.length ? { tags: tags.join(',') } : undefined)
   }
 
-  async getSensors(): Promise<PRTGSensorListResponse> {
-    return this.getResource('sensors')
+  async getSensors(tags?: string[]): Promise<PRTGSensorListResponse> {
+    return this.getResource('sensors', tags?.length ? { tags: tags.join(',') } : undefined)
   }
 
   async getChannels(objid: string): Promise<PRTGChannelListResponse> {
@@ -45,6 +46,21 @@ export class DataSource extends DataSourceWithBackend<MyQuery, MyDataSourceOptio
     return this.getResource(`channels/${objid}`)
   }
 
+  async getChannelNames(objid: string): Promise<string[]> {
+    if (!objid) {
+      throw new Error('objid is required')
+    }
+    return this.getResource(`channelnames/${objid}`)
+  }
+
+  async getSensorTree(): Promise<PRTGObjectTree> {
+    return this.getResource('tree')
+  }
+
+  async ping(): Promise<{ status: string; latencyMs: number }> {
+    return this.getResource('ping')
+  }
+
   //annotations
   annotations = {
   }
@@ -23,6 +23,12 @@ export interface MyQuery extends DataQuery {
   devices: Array<string>
   sensors: Array<string>
   channels: Array<string>
+  // Property queries: only objects carrying at least one of the tags
+  tags?: Array<string>
+  // Averaging interval in seconds, snapped to the closest PRTG interval. Empty selects it automatically.
+  avg?: number
+  // Pass avg to PRTG unchanged instead of snapping it, avg 0 then requests raw data
+  avgExact?: boolean
 
 }
 
@@ -40,7 +46,29 @@ export interface DataSourceResponse {
  */
 export interface MyDataSourceOptions extends DataSourceJsonData {
   path?: string
+  scheme?: 'https' | 'http'
   cacheTime?: number
+  failoverPaths?: string[]
+  trustedRedirectHosts?: string[]
+  proxyUrl?: string
+  unknownStatusPolicy?: 'ignore' | 'down' | 'up'
+  retryAttempts?: number
+  retryDelay?: number
+  attemptTimeout?: number
+  treeCacheTime?: number
+  timezone?: string
+  defaultColumns?: Partial<Record<'groups' | 'devices' | 'sensors', string[]>>
+  truncationWarnInterval?: number
+  minAvgInterval?: number
+  historicMaxPoints?: number
+  unitTagPrefix?: string
+  nonNumericValuePolicy?: 'drop' | 'bound' | 'estimate'
+  neverPolledPolicy?: 'drop' | 'include'
+  dryRun?: boolean
+  requestTimeout?: number
+  statusTimeout?: number
+  tableTimeout?: number
+  historicTimeout?: number
 }
 
 export interface MySecureJsonData {
@@ -87,6 +115,10 @@ export interface PRTGItem {
   status_raw: number
   tags: string
   tags_raw: string
+  // Icon and type metadata, empty if PRTG provides none for the object
+  icon?: string
+  type?: string
+  type_raw?: string
 }
 
 export interface PRTGGroupListResponse {
@@ -113,6 +145,7 @@ export interface PRTGSensorListResponse {
   prtgversion: string
   treesize: number
   sensors: PRTGItem[]
+  sinceToken?: string
 }
 
 export interface PRTGSensorResponse {
@@ -130,6 +163,28 @@ export interface PRTGItemChannel {
   datetime: string
 }
 
+export interface PRTGTreeSensor {
+  objid: number
+  name: string
+  status: string
+}
+
+export interface PRTGTreeDevice {
+  objid: number
+  name: string
+  sensors: PRTGTreeSensor[]
+}
+
+export interface PRTGTreeGroup {
+  objid: number
+  name: string
+  devices: PRTGTreeDevice[]
+}
+
+export interface PRTGObjectTree {
+  groups: PRTGTreeGroup[]
+}
+
 export const filterPropertyList = [
   { name: 'active', visible_name: 'Active' },
   { name: 'message_raw', visible_name: 'Message' },